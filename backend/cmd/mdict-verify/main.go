@@ -0,0 +1,88 @@
+// mdict-verify 校验 MDX/MDD 字典文件的完整性
+//
+// 用法: mdict-verify [-json] [-max-issues N] file.mdx [file.mdd ...]
+//
+// 发现问题时以状态码 1 退出，无法读取文件时以状态码 2 退出。
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"dict-hub/pkg/mdict"
+)
+
+func main() {
+	jsonOutput := flag.Bool("json", false, "以 JSON 格式输出校验结果")
+	maxIssues := flag.Int("max-issues", mdict.DefaultMaxVerifyIssues, "每个文件最多记录的问题数")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-json] [-max-issues N] file.mdx [file.mdd ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	exitCode := 0
+	results := make([]*mdict.VerifyResult, 0, flag.NArg())
+
+	for _, path := range flag.Args() {
+		result, err := mdict.Verify(path, mdict.VerifyOptions{MaxIssues: *maxIssues})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			exitCode = 2
+			continue
+		}
+		if !result.OK() && exitCode == 0 {
+			exitCode = 1
+		}
+		results = append(results, result)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+	} else {
+		for _, result := range results {
+			printResult(result)
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
+// printResult 以文本格式输出单个文件的校验结果
+func printResult(r *mdict.VerifyResult) {
+	status := "OK"
+	if !r.OK() {
+		status = "FAILED"
+	}
+
+	fmt.Printf("%s: %s\n", r.Path, status)
+	fmt.Printf("  version %.1f, encoding %s\n", r.Version, r.Encoding)
+	fmt.Printf("  key blocks:    %d (%d bad)\n", r.KeyBlocks, r.BadKeyBlocks)
+	fmt.Printf("  record blocks: %d (%d bad)\n", r.RecordBlocks, r.BadRecordBlocks)
+	fmt.Printf("  entries:       %d (declared %d)\n", r.Entries, r.DeclaredEntries)
+	fmt.Printf("  duplicate keys: %d, orphan keys: %d, decode errors: %d\n",
+		r.DuplicateKeys, r.OrphanKeys, r.DecodeErrors)
+
+	for _, issue := range r.Issues {
+		location := issue.Section
+		if issue.Block >= 0 {
+			location = fmt.Sprintf("%s #%d", issue.Section, issue.Block)
+		}
+		if issue.Key != "" {
+			location += fmt.Sprintf(" [%s]", issue.Key)
+		}
+		fmt.Printf("  - %s: %s\n", location, issue.Message)
+	}
+	if r.IssuesTruncated {
+		fmt.Println("  - ... more issues omitted")
+	}
+}
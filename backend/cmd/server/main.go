@@ -3,7 +3,10 @@ package main
 import (
 	"fmt"
 	"log"
+	"path/filepath"
+	"time"
 
+	"dict-hub/internal/cache"
 	"dict-hub/internal/config"
 	"dict-hub/internal/database"
 	"dict-hub/internal/router"
@@ -38,27 +41,67 @@ func main() {
 	wordFreqSvc := service.NewWordFreqService(db)
 	dictSourceSvc := service.NewDictSourceService(db, mdxManager, cfg.MDX.DictDir, cfg.MDX.SourceDir)
 	downloadSvc := service.NewDownloadService(db, cfg.MDX.DictDir, dictSourceSvc)
+	verifySvc := service.NewVerifyService(db, dictSourceSvc)
+	wiktionarySvc := service.NewWiktionaryService(db, dictSourceSvc)
+	customDictSvc := service.NewCustomDictService(db, mdxManager)
+	resourceSvc := service.NewResourceService(mdxManager, dictSourceSvc)
+	exportSvc := service.NewExportService(db, mdxManager, dictSourceSvc, filepath.Join(cfg.MDX.DictDir, "exports"))
+	groupSvc := service.NewDictGroupService(db, dictSourceSvc)
+	imageSvc := service.NewImageService(mdxManager, filepath.Join(cfg.MDX.DictDir, "cache", "images"))
 	audioSvc := audio.NewAudioService(mdxManager, cfg.MDX.SoundDir)
 	defer audioSvc.Close()
 
-	// 自动扫描并添加字典目录中的新字典到数据库
-	if cfg.MDX.AutoLoad {
-		log.Printf("Auto-loading dictionaries from %s...", cfg.MDX.SourceDir)
-		addedCount, err := dictSourceSvc.AutoLoadFromDir()
-		if err != nil {
-			log.Printf("Warning: Failed to auto-load dictionaries: %v", err)
-		} else if addedCount > 0 {
-			log.Printf("Auto-loaded %d new dictionaries to database", addedCount)
+	// 搜索缓存，字典变化时清除
+	cacheInstance := cache.New(time.Minute)
+	defer cacheInstance.Stop()
+
+	// 字典在后台加载，HTTP 服务立即开始监听，加载进度见 /health
+	go func() {
+		// 自动扫描并添加字典目录中的新字典到数据库
+		if cfg.MDX.AutoLoad {
+			log.Printf("Auto-loading dictionaries from %s...", cfg.MDX.SourceDir)
+			addedCount, err := dictSourceSvc.AutoLoadFromDir()
+			if err != nil {
+				log.Printf("Warning: Failed to auto-load dictionaries: %v", err)
+			} else if addedCount > 0 {
+				log.Printf("Auto-loaded %d new dictionaries to database", addedCount)
+			}
+
+			// 音频目录中的独立 MDD（发音库）作为资源包登记
+			if addedCount, err := dictSourceSvc.AutoLoadResourcePacks(cfg.MDX.SoundDir); err != nil {
+				log.Printf("Warning: Failed to auto-load resource packs: %v", err)
+			} else if addedCount > 0 {
+				log.Printf("Auto-loaded %d resource packs from %s", addedCount, cfg.MDX.SoundDir)
+			}
+		}
+
+		// 确保用户自建词典已登记，随其他字典一起加载
+		if err := customDictSvc.EnsureSource(); err != nil {
+			log.Printf("Warning: Failed to register custom dictionary: %v", err)
 		}
-	}
 
-	// 启动时同步：从数据库加载已启用的字典
-	log.Printf("Syncing dictionaries from database...")
-	if err := dictSourceSvc.SyncOnStartup(); err != nil {
-		log.Printf("Warning: Failed to sync dictionaries: %v", err)
-	} else {
-		dicts := mdxManager.ListLoaded()
-		log.Printf("Synced %d dictionaries from database", len(dicts))
+		// 启动时同步：从数据库并发加载已启用的字典
+		log.Printf("Syncing dictionaries from database...")
+		if err := dictSourceSvc.SyncOnStartup(cfg.MDX.LoadWorkers); err != nil {
+			log.Printf("Warning: Failed to sync dictionaries: %v", err)
+		} else {
+			progress := dictSourceSvc.LoadProgress()
+			log.Printf("Synced %d dictionaries from database (%d failed)", progress.Loaded, progress.Failed)
+		}
+
+		// 丢弃加载期间缓存的不完整搜索结果
+		cacheInstance.Clear()
+	}()
+
+	// 监视字典源文件目录：新增、覆盖、删除字典文件后自动同步
+	if cfg.MDX.Watch {
+		watcher := service.NewDictWatcher(dictSourceSvc, cfg.MDX.SourceDir, cfg.MDX.WatchDebounce, cfg.MDX.AutoLoad, cacheInstance.Clear)
+		if err := watcher.Start(); err != nil {
+			log.Printf("Warning: Failed to watch %s: %v", cfg.MDX.SourceDir, err)
+		} else {
+			defer watcher.Close()
+			log.Printf("Watching %s for dictionary changes", cfg.MDX.SourceDir)
+		}
 	}
 
 	// 组装服务
@@ -68,6 +111,14 @@ func main() {
 		HistorySvc:    historySvc,
 		WordFreqSvc:   wordFreqSvc,
 		AudioSvc:      audioSvc,
+		VerifySvc:     verifySvc,
+		WiktionarySvc: wiktionarySvc,
+		CustomDictSvc: customDictSvc,
+		ExportSvc:     exportSvc,
+		ResourceSvc:   resourceSvc,
+		ImageSvc:      imageSvc,
+		GroupSvc:      groupSvc,
+		Cache:         cacheInstance,
 	}
 
 	// 获取嵌入的静态文件系统
@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -15,10 +16,13 @@ type Config struct {
 }
 
 type MDXConfig struct {
-	DictDir   string `mapstructure:"dict_dir"`    // 保留兼容性
-	SourceDir string `mapstructure:"source_dir"`  // 字典源文件目录
-	SoundDir  string `mapstructure:"sound_dir"`   // 音频文件目录
-	AutoLoad  bool   `mapstructure:"auto_load"`
+	DictDir       string        `mapstructure:"dict_dir"`   // 保留兼容性
+	SourceDir     string        `mapstructure:"source_dir"` // 字典源文件目录
+	SoundDir      string        `mapstructure:"sound_dir"`  // 音频文件目录
+	AutoLoad      bool          `mapstructure:"auto_load"`
+	Watch         bool          `mapstructure:"watch"`          // 监视字典源文件目录，文件变化后自动同步
+	WatchDebounce time.Duration `mapstructure:"watch_debounce"` // 文件变化平息多久后处理
+	LoadWorkers   int           `mapstructure:"load_workers"`   // 启动时并发加载字典的协程数，0 为 CPU 核数
 }
 
 type ServerConfig struct {
@@ -59,6 +63,9 @@ func Load() (*Config, error) {
 	viper.SetDefault("mdx.source_dir", "./dicts/source")
 	viper.SetDefault("mdx.sound_dir", "./dicts/sound")
 	viper.SetDefault("mdx.auto_load", false)
+	viper.SetDefault("mdx.watch", true)
+	viper.SetDefault("mdx.watch_debounce", "2s")
+	viper.SetDefault("mdx.load_workers", 0)
 
 	// 支持环境变量覆盖配置
 	// 环境变量格式: SERVER_PORT, DATABASE_PATH, MDX_DICT_DIR 等
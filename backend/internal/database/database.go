@@ -44,7 +44,7 @@ func Init(cfg *config.Config) (*gorm.DB, error) {
 }
 
 func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&model.Dictionary{},
 		&model.SearchHistory{},
 		&model.WordFrequency{},
@@ -53,5 +53,31 @@ func autoMigrate(db *gorm.DB) error {
 		&model.Vocabulary{},
 		&model.Note{},
 		&model.ReviewRecord{},
-	)
+		&model.VerifyReport{},
+		&model.WordForm{},
+		&model.WiktionaryImport{},
+		&model.DictExport{},
+		&model.DictResourcePack{},
+		&model.DictGroup{},
+		&model.DictGroupMember{},
+	); err != nil {
+		return err
+	}
+	return createAliasIndex(db)
+}
+
+// createAliasIndex 为字典简称创建不区分大小写的唯一索引（空简称和已删除的字典除外）。
+// 旧版本只在应用层检查，已有的重复简称保留 ID 最小的一个，其余清空
+func createAliasIndex(db *gorm.DB) error {
+	if err := db.Exec(`
+		UPDATE dict_sources SET alias = ''
+		WHERE alias <> '' AND deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM dict_sources d
+			WHERE LOWER(d.alias) = LOWER(dict_sources.alias) AND d.deleted_at IS NULL AND d.id < dict_sources.id
+		)`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_dict_sources_alias_lower
+		ON dict_sources (LOWER(alias)) WHERE alias <> '' AND deleted_at IS NULL`).Error
 }
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dict-hub/internal/cache"
+	"dict-hub/internal/model"
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomDictHandler 用户自建词典处理器
+type CustomDictHandler struct {
+	customDictSvc *service.CustomDictService
+	cache         *cache.Cache
+}
+
+// NewCustomDictHandler 创建自建词典处理器
+func NewCustomDictHandler(customDictSvc *service.CustomDictService, cache *cache.Cache) *CustomDictHandler {
+	return &CustomDictHandler{
+		customDictSvc: customDictSvc,
+		cache:         cache,
+	}
+}
+
+// CustomWordRequest 新增/更新词条请求
+type CustomWordRequest struct {
+	Word         string `json:"word" binding:"required"`
+	Phonetic     string `json:"phonetic"`
+	Definition   string `json:"definition" binding:"required"`
+	Example      string `json:"example"`
+	PartOfSpeech string `json:"part_of_speech"`
+	Synonyms     string `json:"synonyms"`
+	Antonyms     string `json:"antonyms"`
+	Difficulty   int    `json:"difficulty"`
+}
+
+func (r *CustomWordRequest) toModel() *model.Dictionary {
+	difficulty := r.Difficulty
+	if difficulty == 0 {
+		difficulty = 1
+	}
+	return &model.Dictionary{
+		Word:         r.Word,
+		Phonetic:     r.Phonetic,
+		Definition:   r.Definition,
+		Example:      r.Example,
+		PartOfSpeech: r.PartOfSpeech,
+		Synonyms:     r.Synonyms,
+		Antonyms:     r.Antonyms,
+		Difficulty:   difficulty,
+	}
+}
+
+// List 获取词条列表
+// GET /api/v1/my-dictionary?page=1&page_size=20&keyword=xxx
+func (h *CustomDictHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	words, total, err := h.customDictSvc.List(service.CustomWordParams{
+		Page:     page,
+		PageSize: pageSize,
+		Keyword:  c.Query("keyword"),
+	})
+	if err != nil {
+		response.InternalError(c, "failed to list words: "+err.Error())
+		return
+	}
+
+	response.PagedSuccess(c, words, total, page, pageSize)
+}
+
+// Get 获取单个词条
+// GET /api/v1/my-dictionary/:id
+func (h *CustomDictHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid id")
+		return
+	}
+
+	word, err := h.customDictSvc.Get(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCustomWordNotFound) {
+			response.NotFound(c, "word not found")
+			return
+		}
+		response.InternalError(c, "failed to get word: "+err.Error())
+		return
+	}
+
+	response.Success(c, word)
+}
+
+// Create 新增词条
+// POST /api/v1/my-dictionary
+func (h *CustomDictHandler) Create(c *gin.Context) {
+	var req CustomWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	word := req.toModel()
+	if err := h.customDictSvc.Create(word); err != nil {
+		if errors.Is(err, service.ErrCustomWordExists) {
+			response.BadRequest(c, "word already exists")
+			return
+		}
+		if errors.Is(err, service.ErrCustomWordInvalid) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, "failed to create word: "+err.Error())
+		return
+	}
+
+	h.cache.Clear()
+	response.Created(c, word)
+}
+
+// Update 更新词条
+// PUT /api/v1/my-dictionary/:id
+func (h *CustomDictHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid id")
+		return
+	}
+
+	var req CustomWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	word, err := h.customDictSvc.Update(uint(id), req.toModel())
+	if err != nil {
+		switch err {
+		case service.ErrCustomWordNotFound:
+			response.NotFound(c, "word not found")
+		case service.ErrCustomWordExists:
+			response.BadRequest(c, "word already exists")
+		case service.ErrCustomWordInvalid:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to update word: "+err.Error())
+		}
+		return
+	}
+
+	h.cache.Clear()
+	response.Success(c, word)
+}
+
+// Delete 删除词条
+// DELETE /api/v1/my-dictionary/:id
+func (h *CustomDictHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid id")
+		return
+	}
+
+	if err := h.customDictSvc.Delete(uint(id)); err != nil {
+		if errors.Is(err, service.ErrCustomWordNotFound) {
+			response.NotFound(c, "word not found")
+			return
+		}
+		response.InternalError(c, "failed to delete word: "+err.Error())
+		return
+	}
+
+	h.cache.Clear()
+	response.Success(c, gin.H{"message": "word deleted"})
+}
+
+// Import 导入词条（JSON 数组或带表头的 CSV）
+// POST /api/v1/my-dictionary/import
+func (h *CustomDictHandler) Import(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "file is required")
+		return
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	if f := c.PostForm("format"); f != "" {
+		format = f
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.InternalError(c, "failed to open file: "+err.Error())
+		return
+	}
+	defer f.Close()
+
+	result, err := h.customDictSvc.Import(f, format)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImportData) {
+			response.BadRequest(c, "invalid import file, expected JSON array or CSV with header")
+			return
+		}
+		response.InternalError(c, "failed to import: "+err.Error())
+		return
+	}
+
+	h.cache.Clear()
+	response.Success(c, result)
+}
+
+// Export 导出词条
+// GET /api/v1/my-dictionary/export?format=json|csv
+func (h *CustomDictHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.BadRequest(c, "format must be json or csv")
+		return
+	}
+
+	data, err := h.customDictSvc.Export(format)
+	if err != nil {
+		response.InternalError(c, "failed to export: "+err.Error())
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	fileName := "my_dictionary_" + time.Now().Format("20060102_150405") + "." + format
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(http.StatusOK, contentType, data)
+}
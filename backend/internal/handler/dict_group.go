@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"strconv"
+
+	"dict-hub/internal/cache"
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DictGroupHandler 字典分组处理器
+type DictGroupHandler struct {
+	groupSvc *service.DictGroupService
+	cache    *cache.Cache
+}
+
+// NewDictGroupHandler 创建字典分组处理器
+func NewDictGroupHandler(groupSvc *service.DictGroupService, cache *cache.Cache) *DictGroupHandler {
+	return &DictGroupHandler{
+		groupSvc: groupSvc,
+		cache:    cache,
+	}
+}
+
+// List 获取分组列表
+// GET /api/v1/groups
+func (h *DictGroupHandler) List(c *gin.Context) {
+	groups, err := h.groupSvc.List()
+	if err != nil {
+		response.InternalError(c, "failed to list groups: "+err.Error())
+		return
+	}
+
+	response.Success(c, groups)
+}
+
+// Get 获取分组详情
+// GET /api/v1/groups/:id
+func (h *DictGroupHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid group id")
+		return
+	}
+
+	group, err := h.groupSvc.Get(uint(id))
+	if err != nil {
+		response.NotFound(c, "group not found")
+		return
+	}
+
+	response.Success(c, group)
+}
+
+// Create 创建分组
+// POST /api/v1/groups
+func (h *DictGroupHandler) Create(c *gin.Context) {
+	var req service.DictGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	group, err := h.groupSvc.Create(req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	// 新分组可能成为默认分组，清除搜索缓存
+	h.cache.Clear()
+
+	response.Created(c, group)
+}
+
+// Update 修改分组
+// PUT /api/v1/groups/:id
+func (h *DictGroupHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid group id")
+		return
+	}
+
+	var req service.DictGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	group, err := h.groupSvc.Update(uint(id), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.cache.Clear()
+
+	response.Success(c, group)
+}
+
+// Delete 删除分组
+// DELETE /api/v1/groups/:id
+func (h *DictGroupHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid group id")
+		return
+	}
+
+	if err := h.groupSvc.Delete(uint(id)); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.cache.Clear()
+
+	response.Success(c, nil)
+}
+
+// respondError 将分组服务的错误映射为响应
+func (h *DictGroupHandler) respondError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrGroupNotFound:
+		response.NotFound(c, "group not found")
+	case service.ErrGroupNameRequired, service.ErrGroupNameExists, service.ErrGroupNameReserved, service.ErrGroupMemberExists:
+		response.BadRequest(c, err.Error())
+	case service.ErrDictSourceNotFound:
+		response.BadRequest(c, "group member dictionary not found")
+	default:
+		response.InternalError(c, "failed to save group: "+err.Error())
+	}
+}
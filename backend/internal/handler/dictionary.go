@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
 	"dict-hub/internal/cache"
 	"dict-hub/internal/service"
+	"dict-hub/internal/service/mdx"
 	"dict-hub/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -40,7 +43,9 @@ func (h *DictionaryHandler) List(c *gin.Context) {
 
 // AddRequest 添加字典请求
 type AddRequest struct {
-	Path string `json:"path" binding:"required"`
+	Path           string `json:"path" binding:"required"`
+	AllowDuplicate bool   `json:"allow_duplicate"` // 与已有字典元信息相同时仍然添加
+	ReplaceID      uint   `json:"replace_id"`      // 替换指定的已有字典（如旧版本）
 }
 
 // Add 添加字典
@@ -52,13 +57,27 @@ func (h *DictionaryHandler) Add(c *gin.Context) {
 		return
 	}
 
-	source, err := h.dictSourceSvc.Add(req.Path)
+	source, err := h.dictSourceSvc.AddWithOptions(req.Path, service.AddOptions{
+		AllowDuplicate: req.AllowDuplicate,
+		ReplaceID:      req.ReplaceID,
+	})
 	if err != nil {
+		var dupErr *service.DuplicateError
+		if errors.As(err, &dupErr) {
+			respondDuplicate(c, dupErr)
+			return
+		}
 		switch err {
 		case service.ErrDictFileNotFound:
 			response.NotFound(c, "dictionary file not found")
+		case service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary to replace not found")
 		case service.ErrDictAlreadyExists:
 			response.BadRequest(c, "dictionary already exists")
+		case service.ErrReplaceKind:
+			response.BadRequest(c, err.Error())
+		case mdx.ErrUnsupportedFormat:
+			response.BadRequest(c, "unsupported dictionary format")
 		default:
 			response.InternalError(c, "failed to add dictionary: "+err.Error())
 		}
@@ -68,9 +87,22 @@ func (h *DictionaryHandler) Add(c *gin.Context) {
 	// 清除搜索缓存，新增字典可能影响搜索结果
 	h.cache.Clear()
 
+	if req.ReplaceID != 0 {
+		response.Success(c, source)
+		return
+	}
 	response.Created(c, source)
 }
 
+// respondDuplicate 返回 409 及重复的已有字典，客户端可以用 replace_id 替换旧版本或用 allow_duplicate 强制添加
+func respondDuplicate(c *gin.Context, dupErr *service.DuplicateError) {
+	c.JSON(http.StatusConflict, response.Response{
+		Code:    http.StatusConflict,
+		Message: dupErr.Error(),
+		Data:    gin.H{"duplicates": dupErr.Duplicates},
+	})
+}
+
 // Toggle 切换字典启用状态
 // PUT /api/v1/dictionaries/:id/toggle
 func (h *DictionaryHandler) Toggle(c *gin.Context) {
@@ -86,6 +118,10 @@ func (h *DictionaryHandler) Toggle(c *gin.Context) {
 			response.NotFound(c, "dictionary not found")
 			return
 		}
+		if err == service.ErrDictLoading {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		response.InternalError(c, "failed to toggle dictionary: "+err.Error())
 		return
 	}
@@ -96,6 +132,42 @@ func (h *DictionaryHandler) Toggle(c *gin.Context) {
 	response.Success(c, source)
 }
 
+// Update 修改字典的显示标题、简称、语言、标签、备注和主页
+// PATCH /api/v1/dictionaries/:id
+func (h *DictionaryHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	var req service.DictSourceUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	source, err := h.dictSourceSvc.Update(uint(id), req)
+	if err != nil {
+		switch err {
+		case service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary not found")
+		case service.ErrAliasExists, service.ErrInvalidLanguage, service.ErrInvalidHomepage, service.ErrFieldTooLong:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to update dictionary: "+err.Error())
+		}
+		return
+	}
+
+	// 清除搜索缓存，缓存的结果中包含字典标题
+	if req.DisplayTitle != nil {
+		h.cache.Clear()
+	}
+
+	response.Success(c, source)
+}
+
 // Reorder 重新排序字典
 // PUT /api/v1/dictionaries/reorder
 func (h *DictionaryHandler) Reorder(c *gin.Context) {
@@ -110,12 +182,33 @@ func (h *DictionaryHandler) Reorder(c *gin.Context) {
 		return
 	}
 
+	// 清除搜索缓存，未指定分组的结果按字典顺序排列
+	h.cache.Clear()
+
 	response.Success(c, gin.H{"message": "reorder successful"})
 }
 
+// Rescan 按内容指纹找回被移动或重命名的字典文件
+// POST /api/v1/dictionaries/rescan
+func (h *DictionaryHandler) Rescan(c *gin.Context) {
+	result, err := h.dictSourceSvc.Rescan()
+	if err != nil {
+		response.InternalError(c, "failed to rescan dictionaries: "+err.Error())
+		return
+	}
+
+	if len(result.Relinked) > 0 {
+		h.cache.Clear()
+	}
+
+	response.Success(c, result)
+}
+
 // DownloadRequest 下载字典请求
 type DownloadRequest struct {
-	URL string `json:"url" binding:"required"`
+	URL            string `json:"url" binding:"required"`
+	AllowDuplicate bool   `json:"allow_duplicate"` // 与已有字典元信息相同时仍然添加
+	ReplaceID      uint   `json:"replace_id"`      // 下载完成后替换指定的已有字典
 }
 
 // Download 启动异步下载
@@ -127,13 +220,19 @@ func (h *DictionaryHandler) Download(c *gin.Context) {
 		return
 	}
 
-	task, err := h.downloadSvc.StartDownload(req.URL)
+	task, err := h.downloadSvc.StartDownload(req.URL, service.AddOptions{
+		AllowDuplicate: req.AllowDuplicate,
+		ReplaceID:      req.ReplaceID,
+	})
 	if err != nil {
-		switch err {
-		case service.ErrInvalidURL:
+		switch {
+		case err == service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary to replace not found")
+		case err == service.ErrInvalidURL:
 			response.BadRequest(c, "invalid URL")
-		case service.ErrInvalidFileFormat:
-			response.BadRequest(c, "invalid file format, only .mdx files are supported")
+		case errors.Is(err, service.ErrInvalidFileFormat):
+			// 错误信息中包含支持的后缀
+			response.BadRequest(c, err.Error())
 		default:
 			response.InternalError(c, "failed to start download: "+err.Error())
 		}
@@ -188,3 +287,61 @@ func (h *DictionaryHandler) Delete(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "dictionary deleted"})
 }
+
+// ListPacks 获取字典附加的资源包
+// GET /api/v1/dictionaries/:id/packs
+func (h *DictionaryHandler) ListPacks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	packs, err := h.dictSourceSvc.ListResourcePacks(uint(id))
+	if err != nil {
+		if err == service.ErrDictSourceNotFound {
+			response.NotFound(c, "dictionary not found")
+			return
+		}
+		response.InternalError(c, "failed to list resource packs: "+err.Error())
+		return
+	}
+
+	response.Success(c, packs)
+}
+
+// SetPacksRequest 设置资源包请求
+type SetPacksRequest struct {
+	PackIDs []uint `json:"pack_ids"`
+}
+
+// SetPacks 设置字典附加的资源包（替换原有列表，顺序即资源查找顺序）
+// PUT /api/v1/dictionaries/:id/packs
+func (h *DictionaryHandler) SetPacks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	var req SetPacksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	packs, err := h.dictSourceSvc.SetResourcePacks(uint(id), req.PackIDs)
+	if err != nil {
+		switch err {
+		case service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary or resource pack not found")
+		case service.ErrNotResourcePack, service.ErrPackOnResourcePack:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to set resource packs: "+err.Error())
+		}
+		return
+	}
+
+	response.Success(c, packs)
+}
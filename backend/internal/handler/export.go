@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"dict-hub/internal/model"
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler 字典导出处理器
+type ExportHandler struct {
+	exportSvc *service.ExportService
+}
+
+// NewExportHandler 创建字典导出处理器
+func NewExportHandler(exportSvc *service.ExportService) *ExportHandler {
+	return &ExportHandler{exportSvc: exportSvc}
+}
+
+// Start 启动字典导出
+// POST /api/v1/dictionaries/:id/export?format=jsonl|stardict|html
+func (h *ExportHandler) Start(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	task, err := h.exportSvc.StartExport(uint(id), c.DefaultQuery("format", model.ExportFormatJSONL))
+	if err != nil {
+		switch err {
+		case service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary not found")
+		case service.ErrExportUnsupported, service.ErrExportNotLoaded, service.ErrExportInProgress:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to start export: "+err.Error())
+		}
+		return
+	}
+
+	response.Created(c, task)
+}
+
+// List 获取字典的导出任务列表
+// GET /api/v1/dictionaries/:id/export
+func (h *ExportHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	tasks, err := h.exportSvc.ListExports(uint(id))
+	if err != nil {
+		response.InternalError(c, "failed to list exports: "+err.Error())
+		return
+	}
+
+	response.Success(c, tasks)
+}
+
+// Get 获取指定导出任务
+// GET /api/v1/dictionaries/:id/export/:exportId
+func (h *ExportHandler) Get(c *gin.Context) {
+	id, exportID, ok := parseExportIDs(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.exportSvc.GetExport(id, exportID)
+	if err != nil {
+		if err == service.ErrExportNotFound {
+			response.NotFound(c, "export task not found")
+			return
+		}
+		response.InternalError(c, "failed to get export task: "+err.Error())
+		return
+	}
+
+	response.Success(c, task)
+}
+
+// Download 下载导出的压缩包
+// GET /api/v1/dictionaries/:id/export/:exportId/download
+func (h *ExportHandler) Download(c *gin.Context) {
+	id, exportID, ok := parseExportIDs(c)
+	if !ok {
+		return
+	}
+
+	path, err := h.exportSvc.GetExportFile(id, exportID)
+	if err != nil {
+		switch err {
+		case service.ErrExportNotFound:
+			response.NotFound(c, "export file not found")
+		case service.ErrExportNotReady:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to get export file: "+err.Error())
+		}
+		return
+	}
+
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// parseExportIDs 解析字典 ID 和导出任务 ID
+func parseExportIDs(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return 0, 0, false
+	}
+	exportID, err := strconv.ParseUint(c.Param("exportId"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid export id")
+		return 0, 0, false
+	}
+	return uint(id), uint(exportID), true
+}
@@ -1,21 +1,44 @@
 package handler
 
 import (
+	"net/http"
+
+	"dict-hub/internal/service"
 	"dict-hub/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HealthHandler struct{}
+type HealthHandler struct {
+	dictSourceSvc *service.DictSourceService
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(dictSourceSvc *service.DictSourceService) *HealthHandler {
+	return &HealthHandler{dictSourceSvc: dictSourceSvc}
 }
 
+// Check 存活检查，字典加载期间同样返回 200，并附带加载进度
 func (h *HealthHandler) Check(c *gin.Context) {
+	progress := h.dictSourceSvc.LoadProgress()
 	response.Success(c, gin.H{
-		"status":  "healthy",
-		"service": "dict-hub",
-		"version": "1.0.0",
+		"status":       "healthy",
+		"service":      "dict-hub",
+		"version":      "1.0.0",
+		"ready":        progress.Ready,
+		"dictionaries": progress,
 	})
 }
+
+// Ready 就绪检查，启动时的字典加载完成前返回 503
+func (h *HealthHandler) Ready(c *gin.Context) {
+	progress := h.dictSourceSvc.LoadProgress()
+	if !progress.Ready {
+		c.JSON(http.StatusServiceUnavailable, response.Response{
+			Code:    http.StatusServiceUnavailable,
+			Message: "dictionaries are loading",
+			Data:    progress,
+		})
+		return
+	}
+	response.Success(c, progress)
+}
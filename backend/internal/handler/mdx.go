@@ -1,17 +1,20 @@
 package handler
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io"
-	"mime"
 	"net/http"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	"dict-hub/internal/cache"
 	"dict-hub/internal/service"
 	"dict-hub/internal/service/mdx"
+	"dict-hub/pkg/imaging"
 	"dict-hub/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -20,18 +23,29 @@ import (
 type MdxHandler struct {
 	manager        mdx.DictManager
 	historyService *service.HistoryService
+	imageService   *service.ImageService
+	etags          *cache.Cache // 资源内容哈希，键为字典指纹和资源路径
 }
 
+// resourceETagTTL 资源内容哈希的缓存时间
+const resourceETagTTL = time.Hour
+
 func NewMdxHandler(manager mdx.DictManager) *MdxHandler {
-	return &MdxHandler{manager: manager}
+	return &MdxHandler{manager: manager, etags: cache.New(10 * time.Minute)}
 }
 
 // NewMdxHandlerWithHistory 创建带历史服务的 MdxHandler
 func NewMdxHandlerWithHistory(manager mdx.DictManager, historyService *service.HistoryService) *MdxHandler {
-	return &MdxHandler{
-		manager:        manager,
-		historyService: historyService,
-	}
+	h := NewMdxHandler(manager)
+	h.historyService = historyService
+	return h
+}
+
+// NewMdxHandlerWithServices 创建带历史服务和图片缩放服务的 MdxHandler
+func NewMdxHandlerWithServices(manager mdx.DictManager, historyService *service.HistoryService, imageService *service.ImageService) *MdxHandler {
+	h := NewMdxHandlerWithHistory(manager, historyService)
+	h.imageService = imageService
+	return h
 }
 
 // List 列出已加载的字典
@@ -57,6 +71,10 @@ func (h *MdxHandler) Load(c *gin.Context) {
 
 	id, err := h.manager.LoadDict(req.Path)
 	if err != nil {
+		if err == mdx.ErrUnsupportedFormat {
+			response.BadRequest(c, "unsupported dictionary format")
+			return
+		}
 		response.InternalError(c, "failed to load dictionary: "+err.Error())
 		return
 	}
@@ -195,16 +213,20 @@ func (h *MdxHandler) GetResource(c *gin.Context) {
 		return
 	}
 
-	// 生成 ETag
-	etag := fmt.Sprintf(`"%d-%s"`, id, resourcePath)
+	// 图片缩放/格式转换：?w=&h=&fmt=jpeg|png
+	if h.imageService != nil && (c.Query("w") != "" || c.Query("h") != "" || c.Query("fmt") != "") {
+		if h.serveImageVariant(c, uint(id), resourcePath) {
+			return
+		}
+	}
 
-	// 检查条件请求（If-None-Match）
-	if match := c.GetHeader("If-None-Match"); match == etag {
-		c.Status(http.StatusNotModified)
+	// 内容哈希已缓存时（资源此前成功输出过）直接响应条件请求，不读取资源
+	etagKey, etag := h.cachedETag(uint(id), resourcePath)
+	if notModified(c, etag) {
 		return
 	}
 
-	reader, err := h.manager.GetResource(uint(id), resourcePath)
+	content, err := h.manager.OpenResource(uint(id), resourcePath)
 	if err != nil {
 		if err == mdx.ErrDictNotFound {
 			response.NotFound(c, "dictionary not found")
@@ -222,19 +244,113 @@ func (h *MdxHandler) GetResource(c *gin.Context) {
 		return
 	}
 
-	// 根据文件扩展名设置 Content-Type
-	ext := filepath.Ext(resourcePath)
-	contentType := mime.TypeByExtension(ext)
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	// 根据扩展名设置 Content-Type，无扩展名或未知扩展名时按内容嗅探
+	contentType := service.ResourceMIMEType(resourcePath)
+	if contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(content.Data)
 	}
 
-	// 添加 HTTP 缓存头
+	// ETag 为资源内容的哈希；ServeContent 处理条件请求和 Range 请求（音视频拖动）
 	c.Header("Content-Type", contentType)
 	c.Header("Cache-Control", "public, max-age=86400")
-	c.Header("ETag", etag)
-	c.Status(http.StatusOK)
-	io.Copy(c.Writer, reader)
+	c.Header("ETag", h.contentETag(etagKey, etag, content.Data))
+	http.ServeContent(c.Writer, c.Request, path.Base(resourcePath), content.ModTime, bytes.NewReader(content.Data))
+}
+
+// serveImageVariant 输出缩放或转换格式后的图片，资源不是可解码的图片时返回 false，由调用方输出原始资源
+func (h *MdxHandler) serveImageVariant(c *gin.Context, id uint, resourcePath string) bool {
+	var opts imaging.Options
+	var err error
+	if w := c.Query("w"); w != "" {
+		if opts.Width, err = strconv.Atoi(w); err != nil {
+			response.BadRequest(c, "invalid width")
+			return true
+		}
+	}
+	if hv := c.Query("h"); hv != "" {
+		if opts.Height, err = strconv.Atoi(hv); err != nil {
+			response.BadRequest(c, "invalid height")
+			return true
+		}
+	}
+	opts.Format = c.Query("fmt")
+
+	etagKey, etag := h.cachedETag(id, resourcePath, c.Query("w"), c.Query("h"), opts.Format)
+	if notModified(c, etag) {
+		return true
+	}
+
+	variant, err := h.imageService.Variant(id, resourcePath, opts)
+	if err != nil {
+		switch err {
+		case imaging.ErrUnsupportedImage:
+			return false
+		case imaging.ErrInvalidSize:
+			response.BadRequest(c, fmt.Sprintf("width and height must be between 0 and %d", imaging.MaxDimension))
+		case imaging.ErrInvalidFormat:
+			response.BadRequest(c, "invalid format, supported: jpeg, png")
+		case imaging.ErrImageTooLarge:
+			response.BadRequest(c, "image too large to resize")
+		case mdx.ErrDictNotFound:
+			response.NotFound(c, "dictionary not found")
+		case mdx.ErrNoMDD:
+			response.NotFound(c, "no MDD resource file")
+		case mdx.ErrResourceNotFound:
+			response.NotFound(c, "resource not found")
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return true
+	}
+
+	c.Header("Content-Type", variant.ContentType)
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("ETag", h.contentETag(etagKey, etag, variant.Data))
+	http.ServeContent(c.Writer, c.Request, path.Base(resourcePath), variant.ModTime, bytes.NewReader(variant.Data))
+	return true
+}
+
+// cachedETag 返回资源内容哈希的缓存键和已缓存的 ETag（未缓存时为空字符串）；
+// 键包含字典指纹，字典文件或附加的资源包变化后旧的哈希不再命中
+func (h *MdxHandler) cachedETag(id uint, parts ...string) (string, string) {
+	fingerprint, err := h.manager.Fingerprint(id)
+	if err != nil {
+		return "", ""
+	}
+	key := fingerprint + "|" + strings.Join(parts, "|")
+	if etag, ok := h.etags.Get(key); ok {
+		return key, etag.(string)
+	}
+	return key, ""
+}
+
+// contentETag 返回由资源内容哈希生成的 ETag，已缓存时不再计算
+func (h *MdxHandler) contentETag(key, etag string, data []byte) string {
+	if etag != "" {
+		return etag
+	}
+	sum := sha256.Sum256(data)
+	etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	if key != "" {
+		h.etags.Set(key, etag, resourceETagTTL)
+	}
+	return etag
+}
+
+// notModified 请求的 If-None-Match 与 etag 匹配时返回 304，调用方无需再读取资源
+func notModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			c.Header("ETag", etag)
+			c.Header("Cache-Control", "public, max-age=86400")
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
 }
 
 // Unload 卸载字典
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResourceHandler 字典资源（MDD）浏览与提取处理器
+type ResourceHandler struct {
+	resourceSvc *service.ResourceService
+}
+
+// NewResourceHandler 创建资源浏览处理器
+func NewResourceHandler(resourceSvc *service.ResourceService) *ResourceHandler {
+	return &ResourceHandler{resourceSvc: resourceSvc}
+}
+
+// List 列出字典资源
+// GET /api/v1/dictionaries/:id/resources?dir=img&prefix=&page=1&page_size=100
+func (h *ResourceHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "100"))
+
+	listing, err := h.resourceSvc.List(uint(id), service.ResourceListParams{
+		Dir:      c.Query("dir"),
+		Prefix:   c.Query("prefix"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, listing)
+}
+
+// Extract 将全部或筛选后的资源打包为 zip 下载
+// GET /api/v1/dictionaries/:id/resources/extract?dir=img&prefix=&pattern=*.png&ext=png,jpg
+func (h *ResourceHandler) Extract(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	filter := service.ResourceFilter{
+		Dir:     c.Query("dir"),
+		Prefix:  c.Query("prefix"),
+		Pattern: c.Query("pattern"),
+	}
+	if ext := c.Query("ext"); ext != "" {
+		filter.Exts = strings.Split(ext, ",")
+	}
+
+	// 写出响应前检查，出错时仍可返回 JSON
+	count, err := h.resourceSvc.CheckExtract(uint(id), filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("resources_%d_%s.zip", id, time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Header("X-Resource-Count", strconv.Itoa(count))
+	c.Status(http.StatusOK)
+
+	// 已开始写出压缩包，失败时无法再返回错误；中断连接而不是正常结束响应，
+	// 否则客户端会收到一个被截断但看似完整的压缩包
+	if err := h.resourceSvc.Extract(uint(id), filter, c.Writer); err != nil {
+		log.Printf("Failed to extract resources of dictionary %d: %v", id, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// handleError 将服务错误转换为响应
+func (h *ResourceHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrDictSourceNotFound:
+		response.NotFound(c, "dictionary not found")
+	case service.ErrNoMatchingResources:
+		response.NotFound(c, err.Error())
+	case service.ErrDictNotLoaded, service.ErrResourceListUnsupported, service.ErrInvalidResourcePattern:
+		response.BadRequest(c, err.Error())
+	default:
+		response.InternalError(c, "failed to read resources: "+err.Error())
+	}
+}
@@ -31,6 +31,7 @@ type SearchHandler struct {
 	db             *gorm.DB
 	dictSourceSvc  *service.DictSourceService
 	historyService *service.HistoryService
+	groupService   *service.DictGroupService
 }
 
 // NewSearchHandler 创建搜索处理器（向后兼容）
@@ -49,8 +50,51 @@ func NewSearchHandlerWithDictSource(manager mdx.DictManager, cache *cache.Cache,
 	}
 }
 
-// Search 跨字典搜索
-// GET /api/v1/search?word=xxx
+// NewSearchHandlerWithServices 创建支持字典分组的搜索处理器
+func NewSearchHandlerWithServices(manager mdx.DictManager, cache *cache.Cache, db *gorm.DB, dictSourceSvc *service.DictSourceService, historyService *service.HistoryService, groupService *service.DictGroupService) *SearchHandler {
+	h := NewSearchHandlerWithDictSource(manager, cache, db, dictSourceSvc, historyService)
+	h.groupService = groupService
+	return h
+}
+
+// resolveGroup 解析 group 参数，返回要查询的字典运行时 ID 和缓存键中的分组标识；
+// 未指定分组（或 all）时按字典排序查询全部已启用的字典
+func (h *SearchHandler) resolveGroup(c *gin.Context) ([]uint, string, bool) {
+	var dictIDs []uint
+	groupKey := service.GroupAll
+	if h.groupService != nil {
+		var err error
+		dictIDs, groupKey, err = h.groupService.Resolve(c.Query("group"))
+		if err != nil {
+			if err == service.ErrGroupNotFound {
+				response.NotFound(c, "dictionary group not found")
+			} else {
+				response.InternalError(c, "failed to resolve group: "+err.Error())
+			}
+			return nil, "", false
+		}
+	}
+	if dictIDs == nil && h.dictSourceSvc != nil {
+		ordered, err := h.dictSourceSvc.OrderedRuntimeIDs()
+		if err != nil {
+			response.InternalError(c, "failed to list dictionaries: "+err.Error())
+			return nil, "", false
+		}
+		dictIDs = ordered
+	}
+	return dictIDs, groupKey, true
+}
+
+// search 在分组的字典中查询，dictIDs 为空切片（分组内没有可用字典）时不查询
+func (h *SearchHandler) search(word string, dictIDs []uint) []mdx.SearchResult {
+	if dictIDs != nil && len(dictIDs) == 0 {
+		return nil
+	}
+	return h.manager.Search(word, dictIDs...)
+}
+
+// Search 跨字典搜索，group 指定字典分组（ID 或名称），未指定时使用默认分组，all 查询全部字典
+// GET /api/v1/search?word=xxx&group=xxx
 func (h *SearchHandler) Search(c *gin.Context) {
 	start := time.Now()
 
@@ -60,33 +104,48 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		return
 	}
 
+	dictIDs, groupKey, ok := h.resolveGroup(c)
+	if !ok {
+		return
+	}
+
 	// 检查缓存
-	cacheKey := "search:" + word
+	cacheKey := "search:" + groupKey + ":" + word
 	if cached, ok := h.cache.Get(cacheKey); ok {
 		response.Success(c, cached)
 		return
 	}
 
-	results := h.manager.Search(word)
+	results := h.search(word, dictIDs)
 
 	// URL 重写
 	for i := range results {
-		// 获取字典路径以确定静态资源目录
-		dictPath := ""
-		if h.dictSourceSvc != nil {
-			for _, info := range h.manager.ListLoaded() {
-				if info.ID == results[i].DictID {
-					dictPath = info.Path
-					break
-				}
-			}
-		}
-		results[i].Definition = rewriteResourceURLsWithPath(results[i].Definition, results[i].DictID, dictPath, h.dictSourceSvc)
+		results[i].Definition = rewriteResourceURLsWithPath(results[i].Definition, results[i].DictID, h.dictPath(results[i].DictID), h.dictSourceSvc)
 	}
 
 	// 词频排序
 	h.sortByFrequency(results)
 
+	// 词形还原：追加词元（如 went -> go）的释义，排在原词结果之后
+	lemmas := h.lemmasOf(word)
+	if len(lemmas) > 0 {
+		seen := make(map[string]bool)
+		for _, r := range results {
+			seen[strconv.FormatUint(uint64(r.DictID), 10)+":"+r.Word] = true
+		}
+		for _, lemma := range lemmas {
+			for _, r := range h.search(lemma, dictIDs) {
+				key := strconv.FormatUint(uint64(r.DictID), 10) + ":" + r.Word
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				r.Definition = rewriteResourceURLsWithPath(r.Definition, r.DictID, h.dictPath(r.DictID), h.dictSourceSvc)
+				results = append(results, r)
+			}
+		}
+	}
+
 	// 更新词频（异步）
 	go h.updateFrequency(word)
 
@@ -94,6 +153,9 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	data := gin.H{
 		"results": results,
 	}
+	if len(lemmas) > 0 {
+		data["lemmas"] = lemmas
+	}
 
 	// 缓存结果
 	h.cache.Set(cacheKey, data, SearchCacheTTL)
@@ -112,8 +174,8 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	response.Success(c, data)
 }
 
-// Suggest 搜索建议
-// GET /api/v1/search/suggest?q=xxx
+// Suggest 搜索建议，group 参数同 Search
+// GET /api/v1/search/suggest?q=xxx&group=xxx
 func (h *SearchHandler) Suggest(c *gin.Context) {
 	q := c.Query("q")
 	if q == "" {
@@ -127,14 +189,22 @@ func (h *SearchHandler) Suggest(c *gin.Context) {
 		limit = DefaultLimit
 	}
 
+	dictIDs, groupKey, ok := h.resolveGroup(c)
+	if !ok {
+		return
+	}
+
 	// 检查缓存
-	cacheKey := "suggest:" + q
+	cacheKey := "suggest:" + groupKey + ":" + q
 	if cached, ok := h.cache.Get(cacheKey); ok {
 		response.Success(c, cached)
 		return
 	}
 
-	results := h.manager.Suggest(q, limit)
+	var results []mdx.SuggestResult
+	if dictIDs == nil || len(dictIDs) > 0 {
+		results = h.manager.Suggest(q, limit, dictIDs...)
+	}
 
 	// 构建响应数据
 	data := gin.H{
@@ -148,6 +218,31 @@ func (h *SearchHandler) Suggest(c *gin.Context) {
 	response.Success(c, data)
 }
 
+// dictPath 获取字典路径以确定静态资源目录
+func (h *SearchHandler) dictPath(dictID uint) string {
+	if h.dictSourceSvc == nil {
+		return ""
+	}
+	for _, info := range h.manager.ListLoaded() {
+		if info.ID == dictID {
+			return info.Path
+		}
+	}
+	return ""
+}
+
+// lemmasOf 查询词形对应的词元（仅限已启用字典导入的词形）
+func (h *SearchHandler) lemmasOf(word string) []string {
+	var lemmas []string
+	h.db.Model(&model.WordForm{}).
+		Joins("JOIN dict_sources ON dict_sources.id = word_forms.dict_source_id AND dict_sources.enabled = ? AND dict_sources.deleted_at IS NULL", true).
+		Where("word_forms.form IN ? AND word_forms.lemma <> ?", []string{word, strings.ToLower(word)}, word).
+		Distinct().
+		Limit(5).
+		Pluck("word_forms.lemma", &lemmas)
+	return lemmas
+}
+
 // sortByFrequency 按词频排序搜索结果
 func (h *SearchHandler) sortByFrequency(results []mdx.SearchResult) {
 	if len(results) <= 1 {
@@ -169,8 +264,8 @@ func (h *SearchHandler) sortByFrequency(results []mdx.SearchResult) {
 		freqMap[f.Word] = f.SearchCount
 	}
 
-	// 按词频降序排序
-	sort.Slice(results, func(i, j int) bool {
+	// 按词频降序排序，词频相同时保持字典顺序（分组内的顺序）
+	sort.SliceStable(results, func(i, j int) bool {
 		return freqMap[results[i].Word] > freqMap[results[j].Word]
 	})
 }
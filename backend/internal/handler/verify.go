@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"strconv"
+
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyHandler 字典校验处理器
+type VerifyHandler struct {
+	verifySvc *service.VerifyService
+}
+
+// NewVerifyHandler 创建字典校验处理器
+func NewVerifyHandler(verifySvc *service.VerifyService) *VerifyHandler {
+	return &VerifyHandler{verifySvc: verifySvc}
+}
+
+// Start 启动字典完整性校验
+// POST /api/v1/dictionaries/:id/verify
+func (h *VerifyHandler) Start(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	report, err := h.verifySvc.StartVerify(uint(id))
+	if err != nil {
+		switch err {
+		case service.ErrDictSourceNotFound:
+			response.NotFound(c, "dictionary not found")
+		case service.ErrDictFileNotFound:
+			response.NotFound(c, "dictionary file not found")
+		case service.ErrVerifyUnsupported, service.ErrVerifyInProgress:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to start verification: "+err.Error())
+		}
+		return
+	}
+
+	response.Created(c, report)
+}
+
+// List 获取字典的校验报告列表
+// GET /api/v1/dictionaries/:id/verify
+func (h *VerifyHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+
+	reports, err := h.verifySvc.ListReports(uint(id))
+	if err != nil {
+		response.InternalError(c, "failed to list verify reports: "+err.Error())
+		return
+	}
+
+	response.Success(c, reports)
+}
+
+// Get 获取指定校验报告
+// GET /api/v1/dictionaries/:id/verify/:reportId
+func (h *VerifyHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid dictionary id")
+		return
+	}
+	reportID, err := strconv.ParseUint(c.Param("reportId"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid report id")
+		return
+	}
+
+	report, err := h.verifySvc.GetReport(uint(id), uint(reportID))
+	if err != nil {
+		if err == service.ErrVerifyReportNotFound {
+			response.NotFound(c, "verify report not found")
+			return
+		}
+		response.InternalError(c, "failed to get verify report: "+err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
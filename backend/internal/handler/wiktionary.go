@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"strconv"
+
+	"dict-hub/internal/service"
+	"dict-hub/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WiktionaryHandler Wiktionary 导入处理器
+type WiktionaryHandler struct {
+	wiktionarySvc *service.WiktionaryService
+}
+
+// NewWiktionaryHandler 创建 Wiktionary 导入处理器
+func NewWiktionaryHandler(wiktionarySvc *service.WiktionaryService) *WiktionaryHandler {
+	return &WiktionaryHandler{wiktionarySvc: wiktionarySvc}
+}
+
+// WiktionaryImportRequest 导入请求
+type WiktionaryImportRequest struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// Import 启动 kaikki.org JSONL 导入
+// POST /api/v1/dictionaries/wiktionary
+func (h *WiktionaryHandler) Import(c *gin.Context) {
+	var req WiktionaryImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "invalid request: "+err.Error())
+		return
+	}
+
+	task, err := h.wiktionarySvc.StartImport(req.Path)
+	if err != nil {
+		switch err {
+		case service.ErrDictFileNotFound:
+			response.NotFound(c, "file not found")
+		case service.ErrInvalidImportFile, service.ErrImportInProgress, service.ErrImportOutputExists:
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "failed to start import: "+err.Error())
+		}
+		return
+	}
+
+	response.Created(c, task)
+}
+
+// GetImport 获取导入任务状态
+// GET /api/v1/dictionaries/wiktionary/:taskId
+func (h *WiktionaryHandler) GetImport(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "invalid task id")
+		return
+	}
+
+	task, err := h.wiktionarySvc.GetImport(uint(taskID))
+	if err != nil {
+		if err == service.ErrImportNotFound {
+			response.NotFound(c, "import task not found")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, task)
+}
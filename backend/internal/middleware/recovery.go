@@ -13,6 +13,10 @@ func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// 处理器主动中断连接（响应已部分写出），交给 net/http 关闭连接
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
 				log.Printf("[PANIC] %v", err)
 				response.Error(c, http.StatusInternalServerError, 500, "Internal server error")
 				c.Abort()
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 导出任务状态常量
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// 导出格式常量
+const (
+	ExportFormatJSONL    = "jsonl"
+	ExportFormatStarDict = "stardict"
+	ExportFormatHTML     = "html"
+)
+
+// DictExport 字典导出任务
+type DictExport struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	DictSourceID  uint           `gorm:"not null;index" json:"dict_source_id"`          // 被导出的字典ID
+	Format        string         `gorm:"size:20;not null" json:"format"`                // jsonl/stardict/html
+	Status        string         `gorm:"size:20;default:'pending';index" json:"status"` // pending/running/completed/failed
+	Progress      int            `gorm:"default:0" json:"progress"`                     // 进度 0-100
+	EntryCount    int64          `gorm:"default:0" json:"entry_count"`                  // 已导出的词条数
+	ResourceCount int64          `gorm:"default:0" json:"resource_count"`               // 已导出的资源数
+	OutputPath    string         `gorm:"size:1024" json:"output_path"`                  // 生成的压缩包路径
+	FileSize      int64          `gorm:"default:0" json:"file_size"`                    // 压缩包大小（字节）
+	ErrorMsg      string         `gorm:"type:text" json:"error_msg,omitempty"`          // 错误信息
+	StartedAt     *time.Time     `json:"started_at,omitempty"`
+	FinishedAt    *time.Time     `json:"finished_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (DictExport) TableName() string {
+	return "dict_exports"
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DictGroup 字典分组：字典的有序子集（如 "EN-CN learner"、"Etymology"），搜索时可以只查分组内的字典
+type DictGroup struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	Name        string            `gorm:"size:100;not null" json:"name"`     // 分组名称
+	Description string            `gorm:"type:text" json:"description"`      // 分组描述
+	IsDefault   bool              `gorm:"default:false" json:"is_default"`   // 默认分组，未指定分组的搜索只查该分组
+	SortOrder   int               `gorm:"default:0;index" json:"sort_order"` // 排序顺序
+	Members     []DictGroupMember `gorm:"foreignKey:GroupID" json:"members"` // 分组内的字典，按 SortOrder 排列
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+func (DictGroup) TableName() string {
+	return "dict_groups"
+}
+
+// DictGroupMember 分组内的字典，Enabled 只影响该分组，与字典自身的启用状态无关
+type DictGroupMember struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	GroupID      uint      `gorm:"not null;uniqueIndex:idx_dict_group_member" json:"group_id"`             // 分组ID
+	DictSourceID uint      `gorm:"not null;uniqueIndex:idx_dict_group_member;index" json:"dict_source_id"` // 字典ID
+	SortOrder    int       `gorm:"default:0" json:"sort_order"`                                            // 分组内的顺序
+	Enabled      bool      `gorm:"not null" json:"enabled"`                                                // 在该分组中是否启用
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (DictGroupMember) TableName() string {
+	return "dict_group_members"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// DictResourcePack 字典附加的独立 MDD 资源包，字典自身找不到资源时按 SortOrder 依次查找资源包
+type DictResourcePack struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DictSourceID uint      `gorm:"not null;uniqueIndex:idx_dict_resource_pack" json:"dict_source_id"`       // 字典ID
+	PackSourceID uint      `gorm:"not null;uniqueIndex:idx_dict_resource_pack;index" json:"pack_source_id"` // 资源包ID（格式为 mdd 的字典来源）
+	SortOrder    int       `gorm:"default:0" json:"sort_order"`                                             // 查找顺序
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (DictResourcePack) TableName() string {
+	return "dict_resource_packs"
+}
@@ -9,20 +9,49 @@ import (
 // DictSource 字典来源/文件元数据
 // 区别于 Dictionary（词条模型），这是字典文件本身的元信息
 type DictSource struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Name        string         `gorm:"size:255;not null" json:"name"`                   // 字典名称（来自MDX元数据）
-	Title       string         `gorm:"size:255" json:"title"`                           // 字典标题
-	Description string         `gorm:"type:text" json:"description"`                    // 字典描述
-	Path        string         `gorm:"size:1024;not null;uniqueIndex" json:"path"`      // MDX文件路径
-	Enabled     bool           `gorm:"default:true" json:"enabled"`                     // 是否启用
-	SortOrder   int            `gorm:"default:0;index" json:"sort_order"`               // 排序顺序
-	WordCount   int64          `gorm:"default:0" json:"word_count"`                     // 词条数量
-	HasMDD      bool           `gorm:"default:false" json:"has_mdd"`                    // 是否有MDD资源文件
-	SourceURL   string         `gorm:"size:1024" json:"source_url,omitempty"`           // 下载来源URL（可选）
-	FileSize    int64          `gorm:"default:0" json:"file_size"`                      // 文件大小（字节）
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Name         string         `gorm:"size:255;not null" json:"name"`              // 字典名称（来自MDX元数据）
+	Title        string         `gorm:"size:255" json:"title"`                      // 字典标题
+	Description  string         `gorm:"type:text" json:"description"`               // 字典描述
+	Path         string         `gorm:"size:1024;not null;uniqueIndex" json:"path"` // 字典入口文件路径（.mdx/.mdd/.ifo/.dsl/.bgl/.xdxf/.index）
+	Format       string         `gorm:"size:32;default:mdx" json:"format"`          // 字典格式（mdx/mdd/stardict/dsl/bgl/xdxf/dictd/yomitan/zim/glossary/custom）
+	Enabled      bool           `gorm:"default:true" json:"enabled"`                // 是否启用
+	Status       string         `gorm:"size:20;default:'ok'" json:"status"`         // 文件状态（ok/missing/error）
+	LastError    string         `gorm:"type:text" json:"last_error,omitempty"`      // 最近一次文件丢失或加载失败的原因
+	Fingerprint  string         `gorm:"size:64;index" json:"fingerprint,omitempty"` // 入口文件内容指纹，用于找回被移动或重命名的文件
+	SortOrder    int            `gorm:"default:0;index" json:"sort_order"`          // 排序顺序
+	WordCount    int64          `gorm:"default:0" json:"word_count"`                // 词条数量
+	HasMDD       bool           `gorm:"default:false" json:"has_mdd"`               // 是否有MDD资源文件
+	MDDVolumes   int            `gorm:"default:0" json:"mdd_volumes"`               // MDD分卷数量（name.mdd、name.1.mdd…）
+	ResourceSize int64          `gorm:"default:0" json:"resource_size"`             // MDD资源文件总大小（字节）
+	SourceURL    string         `gorm:"size:1024" json:"source_url,omitempty"`      // 下载来源URL（可选）
+	DisplayTitle string         `gorm:"size:255" json:"display_title"`              // 自定义显示标题，为空时使用 Title
+	Alias        string         `gorm:"size:32;index" json:"alias"`                 // 简称
+	SourceLang   string         `gorm:"size:16" json:"source_lang"`                 // 源语言（如 en）
+	TargetLang   string         `gorm:"size:16" json:"target_lang"`                 // 目标语言（如 zh-Hans）
+	Tags         string         `gorm:"size:500" json:"tags"`                       // 标签，逗号分隔
+	Notes        string         `gorm:"type:text" json:"notes"`                     // 备注
+	Homepage     string         `gorm:"size:1024" json:"homepage"`                  // 主页
+	FileSize     int64          `gorm:"default:0" json:"file_size"`                 // 文件大小（字节）
+	FileModTime  time.Time      `json:"-"`                                          // 计算指纹时文件的修改时间，大小和修改时间不变时不重新计算指纹
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// 字典文件状态
+const (
+	DictStatusOK      = "ok"      // 文件存在
+	DictStatusMissing = "missing" // 文件已被删除或移走，文件恢复后自动重新加载
+	DictStatusError   = "error"   // 文件存在但无法加载，原因见 LastError
+)
+
+// DisplayName 返回显示标题，未设置时使用字典自身的标题
+func (d *DictSource) DisplayName() string {
+	if d.DisplayTitle != "" {
+		return d.DisplayTitle
+	}
+	return d.Title
 }
 
 func (DictSource) TableName() string {
@@ -25,6 +25,8 @@ type DownloadTask struct {
 	DownloadSize int64          `gorm:"default:0" json:"download_size"`                     // 已下载大小（字节）
 	ErrorMsg     string         `gorm:"type:text" json:"error_msg,omitempty"`               // 错误信息
 	DictSourceID *uint          `json:"dict_source_id,omitempty"`                           // 下载完成后关联的字典ID
+	ReplaceID    *uint          `json:"replace_id,omitempty"`                               // 下载完成后替换的字典ID
+	DuplicateOf  *uint          `json:"duplicate_of,omitempty"`                             // 与已有字典重复时，重复的字典ID（可用 replace_id 重新下载替换）
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
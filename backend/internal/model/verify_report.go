@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"dict-hub/pkg/mdict"
+
+	"gorm.io/gorm"
+)
+
+// 校验任务状态常量
+const (
+	VerifyStatusPending   = "pending"
+	VerifyStatusRunning   = "running"
+	VerifyStatusCompleted = "completed"
+	VerifyStatusFailed    = "failed"
+)
+
+// VerifyReport 字典完整性校验报告
+type VerifyReport struct {
+	ID           uint                 `gorm:"primaryKey" json:"id"`
+	DictSourceID uint                 `gorm:"not null;index" json:"dict_source_id"`          // 被校验的字典ID
+	Status       string               `gorm:"size:20;default:'pending';index" json:"status"` // pending/running/completed/failed
+	Progress     int                  `gorm:"default:0" json:"progress"`                     // 进度 0-100
+	OK           bool                 `gorm:"default:false" json:"ok"`                       // 是否未发现问题
+	IssueCount   int                  `gorm:"default:0" json:"issue_count"`                  // 问题总数
+	Files        []mdict.VerifyResult `gorm:"serializer:json;type:text" json:"files"`        // 每个文件（MDX/MDD）的校验结果
+	ErrorMsg     string               `gorm:"type:text" json:"error_msg,omitempty"`          // 错误信息
+	StartedAt    *time.Time           `json:"started_at,omitempty"`
+	FinishedAt   *time.Time           `json:"finished_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+func (VerifyReport) TableName() string {
+	return "verify_reports"
+}
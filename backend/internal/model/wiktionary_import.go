@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 导入任务状态常量
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// WiktionaryImport Wiktionary（kaikki.org JSONL）导入任务
+type WiktionaryImport struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	SourcePath   string         `gorm:"size:1024;not null" json:"source_path"`         // JSONL 文件路径
+	OutputPath   string         `gorm:"size:1024" json:"output_path"`                  // 生成的 MDX 路径
+	Status       string         `gorm:"size:20;default:'pending';index" json:"status"` // pending/running/completed/failed
+	Progress     int            `gorm:"default:0" json:"progress"`                     // 进度 0-100
+	EntryCount   int64          `gorm:"default:0" json:"entry_count"`                  // 已读取的条目数
+	WordCount    int64          `gorm:"default:0" json:"word_count"`                   // 生成的词条数
+	FormCount    int64          `gorm:"default:0" json:"form_count"`                   // 导入的词形数
+	ErrorMsg     string         `gorm:"type:text" json:"error_msg,omitempty"`          // 错误信息
+	DictSourceID *uint          `json:"dict_source_id,omitempty"`                      // 导入完成后关联的字典ID
+	StartedAt    *time.Time     `json:"started_at,omitempty"`
+	FinishedAt   *time.Time     `json:"finished_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (WiktionaryImport) TableName() string {
+	return "wiktionary_imports"
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// WordForm 词形到词元的映射（如 went -> go），由 Wiktionary 导入生成
+type WordForm struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	DictSourceID uint      `gorm:"not null;index" json:"dict_source_id"` // 来源字典ID
+	Form         string    `gorm:"size:255;not null;index" json:"form"`  // 词形
+	Lemma        string    `gorm:"size:255;not null" json:"lemma"`       // 词元
+	Tags         string    `gorm:"size:255" json:"tags"`                 // 语法标签，如 "past plural"
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (WordForm) TableName() string {
+	return "word_forms"
+}
@@ -2,7 +2,6 @@ package router
 
 import (
 	"io/fs"
-	"time"
 
 	"dict-hub/internal/cache"
 	"dict-hub/internal/config"
@@ -23,6 +22,14 @@ type Services struct {
 	HistorySvc    *service.HistoryService
 	WordFreqSvc   *service.WordFreqService
 	AudioSvc      *audio.AudioService
+	VerifySvc     *service.VerifyService
+	WiktionarySvc *service.WiktionaryService
+	CustomDictSvc *service.CustomDictService
+	ExportSvc     *service.ExportService
+	ResourceSvc   *service.ResourceService
+	ImageSvc      *service.ImageService
+	GroupSvc      *service.DictGroupService
+	Cache         *cache.Cache
 }
 
 func Setup(cfg *config.Config, db *gorm.DB, mdxManager mdx.DictManager, svcs *Services, staticFS fs.FS) *gin.Engine {
@@ -36,8 +43,9 @@ func Setup(cfg *config.Config, db *gorm.DB, mdxManager mdx.DictManager, svcs *Se
 	r.Use(middleware.Logger())
 	r.Use(middleware.CORS(cfg))
 
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(svcs.DictSourceSvc)
 	r.GET("/health", healthHandler.Check)
+	r.GET("/health/ready", healthHandler.Ready)
 
 	// 静态资源目录（支持独立的音频、图片等文件）
 	r.Static("/static", cfg.MDX.DictDir)
@@ -45,8 +53,8 @@ func Setup(cfg *config.Config, db *gorm.DB, mdxManager mdx.DictManager, svcs *Se
 	// 字典静态资源目录（CSS/JS/图片等）
 	r.Static("/dict-assets", cfg.MDX.SourceDir)
 
-	// 创建缓存实例（每分钟清理过期项）
-	cacheInstance := cache.New(time.Minute)
+	// 搜索缓存由调用方创建，字典目录监视器在文件变化后清除它
+	cacheInstance := svcs.Cache
 
 	api := r.Group("/api/v1")
 	{
@@ -58,12 +66,12 @@ func Setup(cfg *config.Config, db *gorm.DB, mdxManager mdx.DictManager, svcs *Se
 		}
 
 		// 搜索路由（新增）
-		searchHandler := handler.NewSearchHandlerWithDictSource(mdxManager, cacheInstance, db, svcs.DictSourceSvc, svcs.HistorySvc)
+		searchHandler := handler.NewSearchHandlerWithServices(mdxManager, cacheInstance, db, svcs.DictSourceSvc, svcs.HistorySvc, svcs.GroupSvc)
 		api.GET("/search", searchHandler.Search)
 		api.GET("/search/suggest", searchHandler.Suggest)
 
 		// MDX 字典路由（现有，保持兼容）
-		mdxHandler := handler.NewMdxHandlerWithHistory(mdxManager, svcs.HistorySvc)
+		mdxHandler := handler.NewMdxHandlerWithServices(mdxManager, svcs.HistorySvc, svcs.ImageSvc)
 
 		// 字典查询路由（新增别名）
 		api.GET("/dictionaries/:id/lookup", mdxHandler.Lookup)
@@ -91,9 +99,60 @@ func Setup(cfg *config.Config, db *gorm.DB, mdxManager mdx.DictManager, svcs *Se
 			dictionaries.POST("", dictHandler.Add)
 			dictionaries.PUT("/:id/toggle", dictHandler.Toggle)
 			dictionaries.PUT("/reorder", dictHandler.Reorder)
+			dictionaries.POST("/rescan", dictHandler.Rescan)
 			dictionaries.POST("/download", dictHandler.Download)
 			dictionaries.GET("/download/:taskId", dictHandler.GetDownloadStatus)
+			dictionaries.PATCH("/:id", dictHandler.Update)
 			dictionaries.DELETE("/:id", dictHandler.Delete)
+			dictionaries.GET("/:id/packs", dictHandler.ListPacks)
+			dictionaries.PUT("/:id/packs", dictHandler.SetPacks)
+		}
+
+		// 字典校验路由
+		verifyHandler := handler.NewVerifyHandler(svcs.VerifySvc)
+		dictionaries.POST("/:id/verify", verifyHandler.Start)
+		dictionaries.GET("/:id/verify", verifyHandler.List)
+		dictionaries.GET("/:id/verify/:reportId", verifyHandler.Get)
+
+		// 字典导出路由
+		exportHandler := handler.NewExportHandler(svcs.ExportSvc)
+		dictionaries.POST("/:id/export", exportHandler.Start)
+		dictionaries.GET("/:id/export", exportHandler.List)
+		dictionaries.GET("/:id/export/:exportId", exportHandler.Get)
+		dictionaries.GET("/:id/export/:exportId/download", exportHandler.Download)
+
+		// 字典资源浏览路由
+		resourceHandler := handler.NewResourceHandler(svcs.ResourceSvc)
+		dictionaries.GET("/:id/resources", resourceHandler.List)
+		dictionaries.GET("/:id/resources/extract", resourceHandler.Extract)
+
+		// Wiktionary 导入路由
+		wiktionaryHandler := handler.NewWiktionaryHandler(svcs.WiktionarySvc)
+		dictionaries.POST("/wiktionary", wiktionaryHandler.Import)
+		dictionaries.GET("/wiktionary/:taskId", wiktionaryHandler.GetImport)
+
+		// 字典分组路由
+		groupHandler := handler.NewDictGroupHandler(svcs.GroupSvc, cacheInstance)
+		groups := api.Group("/groups")
+		{
+			groups.GET("", groupHandler.List)
+			groups.POST("", groupHandler.Create)
+			groups.GET("/:id", groupHandler.Get)
+			groups.PUT("/:id", groupHandler.Update)
+			groups.DELETE("/:id", groupHandler.Delete)
+		}
+
+		// 自建词典路由
+		customDictHandler := handler.NewCustomDictHandler(svcs.CustomDictSvc, cacheInstance)
+		myDict := api.Group("/my-dictionary")
+		{
+			myDict.GET("", customDictHandler.List)
+			myDict.POST("", customDictHandler.Create)
+			myDict.GET("/export", customDictHandler.Export)
+			myDict.POST("/import", customDictHandler.Import)
+			myDict.GET("/:id", customDictHandler.Get)
+			myDict.PUT("/:id", customDictHandler.Update)
+			myDict.DELETE("/:id", customDictHandler.Delete)
 		}
 
 		// 历史记录路由（新增）
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -82,7 +83,7 @@ func (s *AudioService) ensureLSALoaded() (*LSAReader, error) {
 }
 
 // GetAudio 按优先级查找音频
-// 优先级：1. MDD 字典内音频 -> 2. 独立 wav 文件 -> 3. LSA 音频包
+// 优先级：1. MDD 资源包和字典内音频 -> 2. 独立 wav 文件 -> 3. LSA 音频包
 func (s *AudioService) GetAudio(word string) (io.Reader, string, error) {
 	word = strings.ToLower(strings.TrimSpace(word))
 	if word == "" {
@@ -107,7 +108,7 @@ func (s *AudioService) GetAudio(word string) (io.Reader, string, error) {
 	return nil, "", ErrAudioNotFound
 }
 
-// getFromMDD 从 MDD 字典资源中获取音频
+// getFromMDD 从 MDD 资源包和字典资源中获取音频
 func (s *AudioService) getFromMDD(word string) (io.Reader, string, error) {
 	// 尝试常见的音频路径格式
 	audioPatterns := []string{
@@ -120,6 +121,7 @@ func (s *AudioService) getFromMDD(word string) (io.Reader, string, error) {
 		word + ".mp3",
 		word + ".wav",
 		word + ".ogg",
+		word + ".spx",
 		"audio/" + word + ".mp3",
 		"audio/" + word + ".wav",
 		"sound/" + word + ".mp3",
@@ -128,16 +130,13 @@ func (s *AudioService) getFromMDD(word string) (io.Reader, string, error) {
 		strings.ToUpper(word[:1]) + "/" + word + ".wav",
 	}
 
-	for _, info := range s.mdxManager.ListLoaded() {
-		if !info.HasMDD {
-			continue
-		}
+	for _, id := range s.resourceDictIDs() {
 		for _, pattern := range audioPatterns {
-			if reader, err := s.mdxManager.GetResource(info.ID, pattern); err == nil {
+			if reader, err := s.mdxManager.GetResource(id, pattern); err == nil {
 				contentType := "audio/mpeg"
 				if strings.HasSuffix(pattern, ".wav") {
 					contentType = "audio/wav"
-				} else if strings.HasSuffix(pattern, ".ogg") {
+				} else if strings.HasSuffix(pattern, ".ogg") || strings.HasSuffix(pattern, ".spx") {
 					contentType = "audio/ogg"
 				}
 				return reader, contentType, nil
@@ -148,6 +147,22 @@ func (s *AudioService) getFromMDD(word string) (io.Reader, string, error) {
 	return nil, "", ErrAudioNotFound
 }
 
+// resourceDictIDs 返回可查找音频的字典：先是独立 MDD 资源包（发音库），再是带 MDD 的字典
+func (s *AudioService) resourceDictIDs() []uint {
+	var packs, dicts []uint
+	for _, info := range s.mdxManager.ListLoaded() {
+		switch {
+		case info.Format == mdx.FormatResourcePack:
+			packs = append(packs, info.ID)
+		case info.HasMDD:
+			dicts = append(dicts, info.ID)
+		}
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i] < packs[j] })
+	sort.Slice(dicts, func(i, j int) bool { return dicts[i] < dicts[j] })
+	return append(packs, dicts...)
+}
+
 // getFromWavFile 从独立 wav 文件获取音频
 // 文件路径格式：WyabdcRealPeopleTTS/{首字母}/{word}.wav
 func (s *AudioService) getFromWavFile(word string) (io.Reader, string, error) {
@@ -205,12 +220,9 @@ func (s *AudioService) getFromMDDByType(word, audioType string) (io.Reader, stri
 		return nil, "", ErrAudioNotFound
 	}
 
-	for _, info := range s.mdxManager.ListLoaded() {
-		if !info.HasMDD {
-			continue
-		}
+	for _, id := range s.resourceDictIDs() {
 		for _, pattern := range patterns {
-			if reader, err := s.mdxManager.GetResource(info.ID, pattern); err == nil {
+			if reader, err := s.mdxManager.GetResource(id, pattern); err == nil {
 				return reader, "audio/mpeg", nil
 			}
 		}
@@ -228,14 +240,11 @@ func (s *AudioService) CheckAudioAvailability(word string) (hasGB, hasUS bool) {
 	gbPatterns := []string{word + "__gb_1.mp3", word + "__gb_2.mp3"}
 	usPatterns := []string{word + "__us_1.mp3", word + "__us_2.mp3"}
 
-	for _, info := range s.mdxManager.ListLoaded() {
-		if !info.HasMDD {
-			continue
-		}
+	for _, id := range s.resourceDictIDs() {
 		// 检查 GB
 		if !hasGB {
 			for _, pattern := range gbPatterns {
-				if _, err := s.mdxManager.GetResource(info.ID, pattern); err == nil {
+				if _, err := s.mdxManager.GetResource(id, pattern); err == nil {
 					hasGB = true
 					break
 				}
@@ -244,7 +253,7 @@ func (s *AudioService) CheckAudioAvailability(word string) (hasGB, hasUS bool) {
 		// 检查 US
 		if !hasUS {
 			for _, pattern := range usPatterns {
-				if _, err := s.mdxManager.GetResource(info.ID, pattern); err == nil {
+				if _, err := s.mdxManager.GetResource(id, pattern); err == nil {
 					hasUS = true
 					break
 				}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"dict-hub/internal/model"
+	"dict-hub/internal/service/mdx"
+
+	"github.com/yuin/goldmark"
+	"gorm.io/gorm"
+)
+
+// CustomDictPath 用户自建词典在 DictSource 中的路径
+const CustomDictPath = "custom://my-dictionary"
+
+// CustomDictFormat 用户自建词典的格式名称
+const CustomDictFormat = "custom"
+
+var (
+	ErrCustomWordNotFound = errors.New("word not found in custom dictionary")
+	ErrCustomWordExists   = errors.New("word already exists in custom dictionary")
+	ErrInvalidImportData  = errors.New("invalid import data")
+	ErrCustomWordInvalid  = errors.New("word and definition must not be empty")
+)
+
+// customExportColumns 导入导出的 CSV 列
+var customExportColumns = []string{"word", "phonetic", "definition", "example", "part_of_speech", "synonyms", "antonyms", "difficulty"}
+
+// CustomDictService 用户自建词典服务（基于 model.Dictionary）
+type CustomDictService struct {
+	db *gorm.DB
+}
+
+// NewCustomDictService 创建自建词典服务，并向字典管理器注册 custom 格式，
+// 使自建词典像文件词典一样参与搜索、建议和排序
+func NewCustomDictService(db *gorm.DB, mdxManager mdx.DictManager) *CustomDictService {
+	s := &CustomDictService{db: db}
+	mdxManager.RegisterFormat(mdx.Format{
+		Name: CustomDictFormat,
+		Detect: func(path string) bool {
+			return path == CustomDictPath
+		},
+		Open: func(path string) (mdx.Dictionary, error) {
+			return &customDictionary{db: db}, nil
+		},
+	})
+	return s
+}
+
+// EnsureSource 首次启动时创建自建词典的 DictSource 记录（排在最后）
+// 用户删除后不再自动创建，可通过添加字典接口以 CustomDictPath 重新添加
+func (s *CustomDictService) EnsureSource() error {
+	var existing model.DictSource
+	if err := s.db.Unscoped().Where("path = ?", CustomDictPath).First(&existing).Error; err == nil {
+		return nil
+	}
+
+	var maxOrder int
+	s.db.Model(&model.DictSource{}).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+
+	var count int64
+	s.db.Model(&model.Dictionary{}).Count(&count)
+
+	return s.db.Create(&model.DictSource{
+		Name:        "my-dictionary",
+		Title:       customDictTitle,
+		Description: customDictDescription,
+		Path:        CustomDictPath,
+		Format:      CustomDictFormat,
+		Enabled:     true,
+		SortOrder:   maxOrder + 1,
+		WordCount:   count,
+	}).Error
+}
+
+// CustomWordParams 列表查询参数
+type CustomWordParams struct {
+	Page     int
+	PageSize int
+	Keyword  string
+}
+
+// List 分页查询词条
+func (s *CustomDictService) List(params CustomWordParams) ([]model.Dictionary, int64, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 || params.PageSize > 100 {
+		params.PageSize = 20
+	}
+
+	query := s.db.Model(&model.Dictionary{})
+	if params.Keyword != "" {
+		query = query.Where("word LIKE ?", "%"+params.Keyword+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var words []model.Dictionary
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Order("word ASC").Offset(offset).Limit(params.PageSize).Find(&words).Error; err != nil {
+		return nil, 0, err
+	}
+	return words, total, nil
+}
+
+// Get 根据 ID 获取词条
+func (s *CustomDictService) Get(id uint) (*model.Dictionary, error) {
+	var word model.Dictionary
+	if err := s.db.First(&word, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomWordNotFound
+		}
+		return nil, err
+	}
+	return &word, nil
+}
+
+// normalizeCustomWord 去除单词和释义首尾空白，二者为空时返回 ErrCustomWordInvalid；
+// 未去除空白的单词无法查到，也会绕过同名检查
+func normalizeCustomWord(word *model.Dictionary) error {
+	word.Word = strings.TrimSpace(word.Word)
+	word.Definition = strings.TrimSpace(word.Definition)
+	if word.Word == "" || word.Definition == "" {
+		return ErrCustomWordInvalid
+	}
+	return nil
+}
+
+// Create 新增词条
+func (s *CustomDictService) Create(word *model.Dictionary) error {
+	if err := normalizeCustomWord(word); err != nil {
+		return err
+	}
+	var existing model.Dictionary
+	if err := s.db.Where("word = ?", word.Word).First(&existing).Error; err == nil {
+		return ErrCustomWordExists
+	}
+	// 词条表 word 列有唯一索引，清理已软删除的同名记录
+	s.db.Unscoped().Where("word = ? AND deleted_at IS NOT NULL", word.Word).Delete(&model.Dictionary{})
+
+	if err := s.db.Create(word).Error; err != nil {
+		return err
+	}
+	s.syncWordCount()
+	return nil
+}
+
+// Update 更新词条
+func (s *CustomDictService) Update(id uint, word *model.Dictionary) (*model.Dictionary, error) {
+	if err := normalizeCustomWord(word); err != nil {
+		return nil, err
+	}
+	existing, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if word.Word != existing.Word {
+		var other model.Dictionary
+		if err := s.db.Where("word = ? AND id <> ?", word.Word, id).First(&other).Error; err == nil {
+			return nil, ErrCustomWordExists
+		}
+	}
+
+	word.ID = id
+	word.CreatedAt = existing.CreatedAt
+	if err := s.db.Model(existing).Select("word", "phonetic", "definition", "example", "part_of_speech", "synonyms", "antonyms", "difficulty").Updates(word).Error; err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// Delete 删除词条
+func (s *CustomDictService) Delete(id uint) error {
+	result := s.db.Unscoped().Delete(&model.Dictionary{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomWordNotFound
+	}
+	s.syncWordCount()
+	return nil
+}
+
+// Import 导入词条（json 为词条数组，csv 需包含表头），同名词条会被更新
+func (s *CustomDictService) Import(reader io.Reader, format string) (*ImportResult, error) {
+	var words []model.Dictionary
+	switch format {
+	case "json":
+		if err := json.NewDecoder(reader).Decode(&words); err != nil {
+			return nil, ErrInvalidImportData
+		}
+	case "csv":
+		records, err := csv.NewReader(reader).ReadAll()
+		if err != nil || len(records) == 0 {
+			return nil, ErrInvalidImportData
+		}
+		header := make(map[string]int)
+		for i, name := range records[0] {
+			header[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := header["word"]; !ok {
+			return nil, ErrInvalidImportData
+		}
+		get := func(record []string, name string) string {
+			if i, ok := header[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		for _, record := range records[1:] {
+			difficulty, _ := strconv.Atoi(get(record, "difficulty"))
+			words = append(words, model.Dictionary{
+				Word:         get(record, "word"),
+				Phonetic:     get(record, "phonetic"),
+				Definition:   get(record, "definition"),
+				Example:      get(record, "example"),
+				PartOfSpeech: get(record, "part_of_speech"),
+				Synonyms:     get(record, "synonyms"),
+				Antonyms:     get(record, "antonyms"),
+				Difficulty:   difficulty,
+			})
+		}
+	default:
+		return nil, ErrInvalidImportData
+	}
+
+	result := &ImportResult{
+		TotalLines: len(words),
+		Errors:     make([]string, 0),
+	}
+	for i := range words {
+		w := &words[i]
+		w.ID = 0
+		if normalizeCustomWord(w) != nil {
+			result.SkippedCount++
+			continue
+		}
+		if w.Difficulty == 0 {
+			w.Difficulty = 1
+		}
+
+		var existing model.Dictionary
+		var err error
+		if s.db.Where("word = ?", w.Word).First(&existing).Error == nil {
+			_, err = s.Update(existing.ID, w)
+		} else {
+			s.db.Unscoped().Where("word = ? AND deleted_at IS NOT NULL", w.Word).Delete(&model.Dictionary{})
+			err = s.db.Create(w).Error
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, w.Word+": "+err.Error())
+			continue
+		}
+		result.ImportedCount++
+	}
+
+	s.syncWordCount()
+	return result, nil
+}
+
+// Export 导出全部词条（json 或 csv）
+func (s *CustomDictService) Export(format string) ([]byte, error) {
+	var words []model.Dictionary
+	if err := s.db.Order("word ASC").Find(&words).Error; err != nil {
+		return nil, err
+	}
+
+	if format == "json" {
+		return json.MarshalIndent(words, "", "  ")
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(customExportColumns); err != nil {
+		return nil, err
+	}
+	for _, w := range words {
+		record := []string{w.Word, w.Phonetic, w.Definition, w.Example, w.PartOfSpeech, w.Synonyms, w.Antonyms, strconv.Itoa(w.Difficulty)}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// syncWordCount 同步 DictSource 中记录的词条数量
+func (s *CustomDictService) syncWordCount() {
+	var count int64
+	s.db.Model(&model.Dictionary{}).Count(&count)
+	s.db.Model(&model.DictSource{}).Where("path = ?", CustomDictPath).Update("word_count", count)
+}
+
+const (
+	customDictTitle       = "My Dictionary"
+	customDictDescription = "User-authored entries"
+)
+
+// customDictionary 实现 mdx.Dictionary，每次查询直接读取数据库，编辑后立即生效
+type customDictionary struct {
+	db *gorm.DB
+}
+
+func (d *customDictionary) Name() string {
+	return "my-dictionary"
+}
+
+func (d *customDictionary) Title() string {
+	return customDictTitle
+}
+
+func (d *customDictionary) Description() string {
+	return customDictDescription
+}
+
+func (d *customDictionary) WordCount() int64 {
+	var count int64
+	d.db.Model(&model.Dictionary{}).Count(&count)
+	return count
+}
+
+func (d *customDictionary) Lookup(word string) ([]byte, error) {
+	var entry model.Dictionary
+	if err := d.db.Where("LOWER(word) = LOWER(?)", strings.TrimSpace(word)).First(&entry).Error; err != nil {
+		return nil, mdx.ErrWordNotFound
+	}
+	return []byte(renderCustomWord(&entry)), nil
+}
+
+func (d *customDictionary) Suggest(prefix string, limit int) []string {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+	var words []string
+	d.db.Model(&model.Dictionary{}).
+		Where("LOWER(word) LIKE LOWER(?)", prefix+"%").
+		Order("word ASC").
+		Limit(limit).
+		Pluck("word", &words)
+	return words
+}
+
+func (d *customDictionary) Iterate(fn func(key string, definition []byte) error) error {
+	var words []model.Dictionary
+	var iterErr error
+	err := d.db.Order("id ASC").FindInBatches(&words, 500, func(tx *gorm.DB, batch int) error {
+		for i := range words {
+			if err := fn(words[i].Word, []byte(renderCustomWord(&words[i]))); err != nil {
+				iterErr = err
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if iterErr != nil {
+		return iterErr
+	}
+	return err
+}
+
+func (d *customDictionary) Close() error {
+	return nil
+}
+
+// renderMarkdown 将 Markdown 渲染为 HTML（不允许原始 HTML）
+func renderMarkdown(text string) string {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(text), &buf); err != nil {
+		return html.EscapeString(text)
+	}
+	return buf.String()
+}
+
+// renderWordLinks 将逗号分隔的单词渲染为 entry:// 链接
+func renderWordLinks(words string) string {
+	var links []string
+	for _, w := range strings.FieldsFunc(words, func(r rune) bool { return r == ',' || r == '，' || r == ';' }) {
+		if w = strings.TrimSpace(w); w != "" {
+			links = append(links, `<a href="entry://`+html.EscapeString(w)+`">`+html.EscapeString(w)+`</a>`)
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// renderCustomWord 渲染自建词条
+func renderCustomWord(w *model.Dictionary) string {
+	var sb strings.Builder
+	sb.WriteString(`<div class="custom-entry"><div class="custom-head"><b>` + html.EscapeString(w.Word) + "</b>")
+	if w.Phonetic != "" {
+		sb.WriteString(` <span class="phonetic">` + html.EscapeString(w.Phonetic) + "</span>")
+	}
+	if w.PartOfSpeech != "" {
+		sb.WriteString(` <i class="pos">` + html.EscapeString(w.PartOfSpeech) + "</i>")
+	}
+	sb.WriteString("</div>")
+	sb.WriteString(`<div class="custom-definition">` + renderMarkdown(w.Definition) + "</div>")
+	if w.Example != "" {
+		sb.WriteString(`<div class="custom-example">` + renderMarkdown(w.Example) + "</div>")
+	}
+	if links := renderWordLinks(w.Synonyms); links != "" {
+		sb.WriteString(`<div class="custom-synonyms"><b>Synonyms</b>: ` + links + "</div>")
+	}
+	if links := renderWordLinks(w.Antonyms); links != "" {
+		sb.WriteString(`<div class="custom-antonyms"><b>Antonyms</b>: ` + links + "</div>")
+	}
+	sb.WriteString("</div>")
+	return sb.String()
+}
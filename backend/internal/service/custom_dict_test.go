@@ -0,0 +1,57 @@
+package service
+
+import (
+	"testing"
+
+	"dict-hub/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestCustomDict(t *testing.T) *CustomDictService {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Dictionary{}, &model.DictSource{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &CustomDictService{db: db}
+}
+
+func TestCustomDictTrimsAndRejectsBlankFields(t *testing.T) {
+	s := newTestCustomDict(t)
+
+	for _, w := range []model.Dictionary{{Word: "   ", Definition: "x"}, {Word: "foo", Definition: " \n\t"}} {
+		if err := s.Create(&w); err != ErrCustomWordInvalid {
+			t.Errorf("Create(%q, %q) error = %v, want ErrCustomWordInvalid", w.Word, w.Definition, err)
+		}
+	}
+
+	word := &model.Dictionary{Word: " foo ", Definition: " a word \n"}
+	if err := s.Create(word); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if word.Word != "foo" || word.Definition != "a word" {
+		t.Errorf("Create stored %q, %q, want trimmed values", word.Word, word.Definition)
+	}
+	if err := s.Create(&model.Dictionary{Word: "foo ", Definition: "again"}); err != ErrCustomWordExists {
+		t.Errorf("Create(\"foo \") error = %v, want ErrCustomWordExists", err)
+	}
+
+	if _, err := s.Update(word.ID, &model.Dictionary{Word: "foo", Definition: "  "}); err != ErrCustomWordInvalid {
+		t.Errorf("Update with blank definition error = %v, want ErrCustomWordInvalid", err)
+	}
+	updated, err := s.Update(word.ID, &model.Dictionary{Word: "\tbar ", Definition: " updated "})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.Word != "bar" || updated.Definition != "updated" {
+		t.Errorf("Update stored %q, %q, want trimmed values", updated.Word, updated.Definition)
+	}
+}
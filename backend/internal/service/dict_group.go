@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"dict-hub/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGroupNotFound     = errors.New("dictionary group not found")
+	ErrGroupNameRequired = errors.New("group name is required")
+	ErrGroupNameExists   = errors.New("group name already exists")
+	ErrGroupNameReserved = errors.New("group name is reserved")
+	ErrGroupMemberExists = errors.New("dictionary appears more than once in the group")
+)
+
+// GroupAll 搜索时指定 group=all 查询全部已启用的字典，忽略默认分组
+const GroupAll = "all"
+
+// DictGroupMemberItem 分组成员
+type DictGroupMemberItem struct {
+	DictSourceID uint  `json:"dict_source_id"`
+	Enabled      *bool `json:"enabled"` // 为空时启用
+}
+
+// DictGroupRequest 创建或修改分组，修改时 nil 字段保持不变
+type DictGroupRequest struct {
+	Name        *string                `json:"name"`
+	Description *string                `json:"description"`
+	IsDefault   *bool                  `json:"is_default"`
+	SortOrder   *int                   `json:"sort_order"`
+	Members     *[]DictGroupMemberItem `json:"members"` // 按数组顺序排列，替换原有成员
+}
+
+// DictGroupService 字典分组服务
+type DictGroupService struct {
+	db            *gorm.DB
+	dictSourceSvc *DictSourceService
+}
+
+// NewDictGroupService 创建字典分组服务
+func NewDictGroupService(db *gorm.DB, dictSourceSvc *DictSourceService) *DictGroupService {
+	return &DictGroupService{
+		db:            db,
+		dictSourceSvc: dictSourceSvc,
+	}
+}
+
+// List 列出所有分组及其成员
+func (s *DictGroupService) List() ([]model.DictGroup, error) {
+	var groups []model.DictGroup
+	err := s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).Order("sort_order ASC, id ASC").Find(&groups).Error
+	return groups, err
+}
+
+// Get 获取分组及其成员
+func (s *DictGroupService) Get(id uint) (*model.DictGroup, error) {
+	var group model.DictGroup
+	err := s.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).First(&group, id).Error
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	return &group, nil
+}
+
+// Create 创建分组
+func (s *DictGroupService) Create(req DictGroupRequest) (*model.DictGroup, error) {
+	if req.Name == nil {
+		return nil, ErrGroupNameRequired
+	}
+	group := &model.DictGroup{}
+	if err := s.save(group, req); err != nil {
+		return nil, err
+	}
+	return s.Get(group.ID)
+}
+
+// Update 修改分组
+func (s *DictGroupService) Update(id uint, req DictGroupRequest) (*model.DictGroup, error) {
+	group, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.save(group, req); err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// save 校验请求并在事务中保存分组、成员和默认分组标记
+func (s *DictGroupService) save(group *model.DictGroup, req DictGroupRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return ErrGroupNameRequired
+		}
+		if strings.EqualFold(name, GroupAll) {
+			return ErrGroupNameReserved
+		}
+		if _, err := strconv.ParseUint(name, 10, 32); err == nil {
+			// 纯数字的名称会与 group= 参数中的分组 ID 混淆
+			return ErrGroupNameReserved
+		}
+		var count int64
+		s.db.Model(&model.DictGroup{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, group.ID).Count(&count)
+		if count > 0 {
+			return ErrGroupNameExists
+		}
+		group.Name = name
+	}
+	if req.Description != nil {
+		group.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.IsDefault != nil {
+		group.IsDefault = *req.IsDefault
+	}
+	if req.SortOrder != nil {
+		group.SortOrder = *req.SortOrder
+	}
+
+	var members []model.DictGroupMember
+	if req.Members != nil {
+		seen := make(map[uint]bool)
+		for i, item := range *req.Members {
+			if seen[item.DictSourceID] {
+				return ErrGroupMemberExists
+			}
+			seen[item.DictSourceID] = true
+			if _, err := s.dictSourceSvc.GetByID(item.DictSourceID); err != nil {
+				return err
+			}
+			members = append(members, model.DictGroupMember{
+				DictSourceID: item.DictSourceID,
+				SortOrder:    i,
+				Enabled:      item.Enabled == nil || *item.Enabled,
+			})
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 只能有一个默认分组
+		if group.IsDefault {
+			if err := tx.Model(&model.DictGroup{}).Where("is_default = ? AND id <> ?", true, group.ID).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("Members").Save(group).Error; err != nil {
+			return err
+		}
+		if req.Members == nil {
+			return nil
+		}
+		if err := tx.Where("group_id = ?", group.ID).Delete(&model.DictGroupMember{}).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			members[i].GroupID = group.ID
+		}
+		if len(members) > 0 {
+			return tx.Create(&members).Error
+		}
+		return nil
+	})
+}
+
+// Delete 删除分组
+func (s *DictGroupService) Delete(id uint) error {
+	group, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&model.DictGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	})
+}
+
+// Resolve 解析搜索参数中的分组（ID 或名称，不区分大小写），返回分组内已启用且已加载字典的运行时 ID（按分组顺序）
+// 和用于缓存键的分组标识。group 为空时使用默认分组，没有默认分组或 group 为 all 时返回 nil，表示查询全部字典
+func (s *DictGroupService) Resolve(group string) ([]uint, string, error) {
+	group = strings.TrimSpace(group)
+	if strings.EqualFold(group, GroupAll) {
+		return nil, GroupAll, nil
+	}
+
+	var g model.DictGroup
+	switch {
+	case group == "":
+		if err := s.db.Where("is_default = ?", true).First(&g).Error; err != nil {
+			return nil, GroupAll, nil
+		}
+	default:
+		query := s.db.Where("LOWER(name) = LOWER(?)", group)
+		if id, err := strconv.ParseUint(group, 10, 32); err == nil {
+			query = s.db.Where("id = ?", id)
+		}
+		if err := query.First(&g).Error; err != nil {
+			return nil, "", ErrGroupNotFound
+		}
+	}
+
+	var members []model.DictGroupMember
+	s.db.Joins("JOIN dict_sources ON dict_sources.id = dict_group_members.dict_source_id AND dict_sources.enabled = ? AND dict_sources.deleted_at IS NULL", true).
+		Where("dict_group_members.group_id = ? AND dict_group_members.enabled = ?", g.ID, true).
+		Order("dict_group_members.sort_order ASC").
+		Find(&members)
+
+	dictIDs := []uint{}
+	for _, member := range members {
+		if runtimeID, ok := s.dictSourceSvc.GetRuntimeID(member.DictSourceID); ok {
+			dictIDs = append(dictIDs, runtimeID)
+		}
+	}
+	return dictIDs, "group:" + strconv.FormatUint(uint64(g.ID), 10), nil
+}
@@ -2,10 +2,17 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"dict-hub/internal/model"
 	"dict-hub/internal/service/mdx"
@@ -17,8 +24,54 @@ var (
 	ErrDictSourceNotFound = errors.New("dictionary source not found")
 	ErrDictFileNotFound   = errors.New("dictionary file not found")
 	ErrDictAlreadyExists  = errors.New("dictionary already exists")
+	ErrNotResourcePack    = errors.New("dictionary is not a resource pack")
+	ErrPackOnResourcePack = errors.New("resource packs cannot be attached to a resource pack")
+	ErrDictDuplicate      = errors.New("dictionary duplicates an existing dictionary")
+	ErrReplaceKind        = errors.New("a resource pack can only be replaced by a resource pack")
+	ErrAliasExists        = errors.New("alias is already used by another dictionary")
+	ErrInvalidLanguage    = errors.New("invalid language code")
+	ErrInvalidHomepage    = errors.New("homepage must be an http or https URL")
+	ErrFieldTooLong       = errors.New("field is too long")
+	ErrDictLoading        = errors.New("dictionary is still loading")
 )
 
+// languageCode 匹配 BCP 47 风格的语言代码（en、zh-Hans、pt-BR）
+var languageCode = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// 重复原因
+const (
+	DuplicateSameContent  = "same_content"  // 文件内容相同（指纹一致）
+	DuplicateSameMetadata = "same_metadata" // 格式、标题和描述相同，可能是同一字典的其他版本
+)
+
+// mdictPlaceholderTitle MdxBuilder 未填写标题时写入的默认值，不能用于判断重复
+const mdictPlaceholderTitle = "Title (No HTML code allowed)"
+
+// DuplicateInfo 与新字典重复的已有字典
+type DuplicateInfo struct {
+	Existing model.DictSource `json:"existing"`
+	Reason   string           `json:"reason"`
+}
+
+// DuplicateError 新字典与已有字典重复，Duplicates 中内容相同的排在前面
+type DuplicateError struct {
+	Duplicates []DuplicateInfo
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrDictDuplicate.Error()
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDictDuplicate
+}
+
+// AddOptions 添加字典选项
+type AddOptions struct {
+	AllowDuplicate bool // 与已有字典元信息相同时仍然添加；内容完全相同的文件始终拒绝
+	ReplaceID      uint // 替换已有字典（如旧版本），沿用其 ID、排序、启用状态和资源包关联
+}
+
 // ReorderItem 排序项
 type ReorderItem struct {
 	ID        uint `json:"id"`
@@ -29,12 +82,36 @@ type ReorderItem struct {
 type DictSourceService struct {
 	db         *gorm.DB
 	mdxManager mdx.DictManager
-	dictDir    string // 保留兼容性
-	sourceDir  string // 字典源文件目录
-	runtimeIDs map[uint]uint // DB ID -> Runtime ID 映射
+	dictDir    string             // 保留兼容性
+	sourceDir  string             // 字典源文件目录
+	runtimeIDs map[uint]uint      // DB ID -> Runtime ID 映射
+	loadStates map[uint]loadState // 正在加载或加载失败的字典
+	reserved   map[string]bool    // 正在由导入任务生成的文件，文件监视和目录扫描跳过
+	synced     bool               // 启动同步是否完成
 	mu         sync.RWMutex
 }
 
+// 字典加载状态
+const (
+	LoadStatusLoading = "loading"
+	LoadStatusLoaded  = "loaded"
+	LoadStatusFailed  = "failed"
+)
+
+// loadState 未加载成功的字典的加载状态
+type loadState struct {
+	status string
+	err    string
+}
+
+// LoadProgress 字典加载进度
+type LoadProgress struct {
+	Ready   bool `json:"ready"`   // 启动时的字典加载是否完成
+	Loaded  int  `json:"loaded"`  // 已加载的字典数
+	Loading int  `json:"loading"` // 正在加载的字典数
+	Failed  int  `json:"failed"`  // 加载失败的字典数
+}
+
 // NewDictSourceService 创建字典来源服务
 func NewDictSourceService(db *gorm.DB, mdxManager mdx.DictManager, dictDir, sourceDir string) *DictSourceService {
 	// 如果 sourceDir 为空，使用 dictDir 作为默认值
@@ -47,9 +124,30 @@ func NewDictSourceService(db *gorm.DB, mdxManager mdx.DictManager, dictDir, sour
 		dictDir:    dictDir,
 		sourceDir:  sourceDir,
 		runtimeIDs: make(map[uint]uint),
+		loadStates: make(map[uint]loadState),
+		reserved:   make(map[string]bool),
+	}
+}
+
+// reservePath 标记路径正在由导入任务生成并登记，期间文件监视和目录扫描不处理该文件；返回释放函数
+func (s *DictSourceService) reservePath(path string) func() {
+	s.mu.Lock()
+	s.reserved[path] = true
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.reserved, path)
+		s.mu.Unlock()
 	}
 }
 
+// isReserved 判断路径是否正在由导入任务生成
+func (s *DictSourceService) isReserved(path string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reserved[path]
+}
+
 // GetSourceDir 获取字典源文件目录
 func (s *DictSourceService) GetSourceDir() string {
 	return s.sourceDir
@@ -58,7 +156,45 @@ func (s *DictSourceService) GetSourceDir() string {
 // DictSourceResponse 字典响应（包含加载状态）
 type DictSourceResponse struct {
 	model.DictSource
-	Loaded bool `json:"loaded"`
+	Loaded     bool   `json:"loaded"`
+	LoadStatus string `json:"load_status,omitempty"` // loading、loaded 或 failed，未加载的禁用字典为空
+	LoadError  string `json:"load_error,omitempty"`  // 加载失败的原因
+
+	Duplicates []DuplicateInfo `json:"duplicates,omitempty"` // 添加时发现的元信息相同的已有字典
+}
+
+// response 生成字典响应（调用方持有 s.mu）
+func (s *DictSourceService) response(source model.DictSource) DictSourceResponse {
+	resp := DictSourceResponse{DictSource: source}
+	if _, ok := s.runtimeIDs[source.ID]; ok {
+		resp.Loaded = true
+		resp.LoadStatus = LoadStatusLoaded
+	} else if state, ok := s.loadStates[source.ID]; ok {
+		resp.LoadStatus = state.status
+		resp.LoadError = state.err
+	}
+	return resp
+}
+
+// setLoadFailed 记录字典加载失败的原因（调用方持有 s.mu）
+func (s *DictSourceService) setLoadFailed(id uint, err error) {
+	s.loadStates[id] = loadState{status: LoadStatusFailed, err: err.Error()}
+}
+
+// LoadProgress 返回字典加载进度
+func (s *DictSourceService) LoadProgress() LoadProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress := LoadProgress{Ready: s.synced, Loaded: len(s.runtimeIDs)}
+	for _, state := range s.loadStates {
+		if state.status == LoadStatusLoading {
+			progress.Loading++
+		} else {
+			progress.Failed++
+		}
+	}
+	return progress
 }
 
 // List 列出所有字典，按 SortOrder 排序
@@ -73,31 +209,46 @@ func (s *DictSourceService) List() ([]DictSourceResponse, error) {
 
 	responses := make([]DictSourceResponse, len(sources))
 	for i, src := range sources {
-		_, loaded := s.runtimeIDs[src.ID]
-		responses[i] = DictSourceResponse{
-			DictSource: src,
-			Loaded:     loaded,
-		}
+		responses[i] = s.response(src)
 	}
 
 	return responses, nil
 }
 
-// Add 添加字典
+// Add 添加字典，与已有字典重复时返回 *DuplicateError
 func (s *DictSourceService) Add(path string) (*DictSourceResponse, error) {
-	// 检查文件是否存在
-	fileInfo, err := os.Stat(path)
-	if err != nil {
-		return nil, ErrDictFileNotFound
+	return s.AddWithOptions(path, AddOptions{})
+}
+
+// AddWithOptions 添加字典或替换已有字典
+func (s *DictSourceService) AddWithOptions(path string, opts AddOptions) (*DictSourceResponse, error) {
+	var target *model.DictSource
+	if opts.ReplaceID != 0 {
+		var err error
+		if target, err = s.GetByID(opts.ReplaceID); err != nil {
+			return nil, err
+		}
 	}
 
-	// 检查是否已存在
+	// 检查文件是否存在（虚拟字典没有文件）
+	var file fileState
+	if !mdx.IsVirtualPath(path) {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, ErrDictFileNotFound
+		}
+		file.size = fileInfo.Size()
+		file.modTime = fileInfo.ModTime()
+		file.fingerprint, _ = mdx.FileFingerprint(path)
+	}
+
+	// 检查是否已存在（替换时可以是被替换字典自身的路径）
 	var existing model.DictSource
-	if err := s.db.Where("path = ?", path).First(&existing).Error; err == nil {
+	if err := s.db.Where("path = ?", path).First(&existing).Error; err == nil && (target == nil || existing.ID != target.ID) {
 		return nil, ErrDictAlreadyExists
 	}
 
-	// 加载到 MDX 管理器获取元信息
+	// 加载到字典管理器获取元信息
 	runtimeID, err := s.mdxManager.LoadDict(path)
 	if err != nil {
 		return nil, err
@@ -112,21 +263,40 @@ func (s *DictSourceService) Add(path string) (*DictSourceResponse, error) {
 		}
 	}
 
+	if target != nil {
+		return s.replaceSource(target, path, runtimeID, dictInfo, file)
+	}
+
+	var duplicates []DuplicateInfo
+	if !mdx.IsVirtualPath(path) {
+		duplicates = s.findDuplicates(dictInfo, file.fingerprint)
+	}
+	if len(duplicates) > 0 && (!opts.AllowDuplicate || duplicates[0].Reason == DuplicateSameContent) {
+		s.mdxManager.Unload(runtimeID)
+		return nil, &DuplicateError{Duplicates: duplicates}
+	}
+
 	// 获取最大排序值
 	var maxOrder int
 	s.db.Model(&model.DictSource{}).Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
 
 	// 创建数据库记录
 	source := &model.DictSource{
-		Name:        dictInfo.Name,
-		Title:       dictInfo.Title,
-		Description: dictInfo.Description,
-		Path:        path,
-		Enabled:     true,
-		SortOrder:   maxOrder + 1,
-		WordCount:   dictInfo.WordCount,
-		HasMDD:      dictInfo.HasMDD,
-		FileSize:    fileInfo.Size(),
+		Name:         dictInfo.Name,
+		Title:        dictInfo.Title,
+		Description:  dictInfo.Description,
+		Path:         path,
+		Format:       dictInfo.Format,
+		Enabled:      true,
+		Status:       model.DictStatusOK,
+		SortOrder:    maxOrder + 1,
+		WordCount:    dictInfo.WordCount,
+		HasMDD:       dictInfo.HasMDD,
+		MDDVolumes:   dictInfo.MDDVolumes,
+		ResourceSize: dictInfo.ResourceSize,
+		FileSize:     file.size,
+		FileModTime:  file.modTime,
+		Fingerprint:  file.fingerprint,
 	}
 
 	if err := s.db.Create(source).Error; err != nil {
@@ -137,13 +307,101 @@ func (s *DictSourceService) Add(path string) (*DictSourceResponse, error) {
 
 	// 维护 ID 映射
 	s.mu.Lock()
-	s.runtimeIDs[source.ID] = runtimeID
+	s.setRuntimeID(source, runtimeID)
+	resp := s.response(*source)
+	s.mu.Unlock()
+
+	s.importFrequencies(runtimeID)
+
+	resp.Duplicates = duplicates
+	return &resp, nil
+}
+
+// replaceSource 用新加载的字典替换已有字典记录，ID、排序、启用状态、下载来源和资源包关联保持不变
+func (s *DictSourceService) replaceSource(source *model.DictSource, path string, runtimeID uint, info mdx.DictInfo, file fileState) (*DictSourceResponse, error) {
+	if (source.Format == mdx.FormatResourcePack) != (info.Format == mdx.FormatResourcePack) {
+		s.mdxManager.Unload(runtimeID)
+		return nil, ErrReplaceKind
+	}
+
+	source.Name = info.Name
+	source.Title = info.Title
+	source.Description = info.Description
+	source.Path = path
+	source.Format = info.Format
+	source.Status = model.DictStatusOK
+	source.LastError = ""
+	source.WordCount = info.WordCount
+	source.HasMDD = info.HasMDD
+	source.MDDVolumes = info.MDDVolumes
+	source.ResourceSize = info.ResourceSize
+	source.FileSize = file.size
+	source.FileModTime = file.modTime
+	source.Fingerprint = file.fingerprint
+	if err := s.db.Save(source).Error; err != nil {
+		s.mdxManager.Unload(runtimeID)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if oldID, ok := s.runtimeIDs[source.ID]; ok {
+		s.mdxManager.Unload(oldID)
+		delete(s.runtimeIDs, source.ID)
+	}
+	delete(s.loadStates, source.ID)
+	if source.Enabled {
+		s.setRuntimeID(source, runtimeID)
+	} else {
+		s.mdxManager.Unload(runtimeID)
+	}
+	s.applyAllResourcePacks()
+	resp := s.response(*source)
 	s.mu.Unlock()
 
-	return &DictSourceResponse{
-		DictSource: *source,
-		Loaded:     true,
-	}, nil
+	if source.Enabled {
+		s.importFrequencies(runtimeID)
+	}
+	return &resp, nil
+}
+
+// findDuplicates 查找与新字典重复的已有字典：内容指纹相同，或格式、标题和描述都相同
+func (s *DictSourceService) findDuplicates(info mdx.DictInfo, fingerprint string) []DuplicateInfo {
+	var sameContent, sameMetadata []DuplicateInfo
+
+	if fingerprint != "" {
+		var sources []model.DictSource
+		s.db.Where("fingerprint = ?", fingerprint).Order("sort_order ASC, id ASC").Find(&sources)
+		for _, src := range sources {
+			sameContent = append(sameContent, DuplicateInfo{Existing: src, Reason: DuplicateSameContent})
+		}
+	}
+
+	title := strings.TrimSpace(info.Title)
+	if title != "" && title != mdictPlaceholderTitle {
+		var sources []model.DictSource
+		s.db.Where("format = ? AND title = ? AND description = ?", info.Format, info.Title, info.Description).
+			Order("sort_order ASC, id ASC").Find(&sources)
+		for _, src := range sources {
+			if fingerprint != "" && src.Fingerprint == fingerprint {
+				continue
+			}
+			if mdx.IsVirtualPath(src.Path) {
+				continue
+			}
+			sameMetadata = append(sameMetadata, DuplicateInfo{Existing: src, Reason: DuplicateSameMetadata})
+		}
+	}
+
+	return append(sameContent, sameMetadata...)
+}
+
+// importFrequencies 词典自带词频数据时导入词频表
+func (s *DictSourceService) importFrequencies(runtimeID uint) {
+	if dict, err := s.mdxManager.GetDictionary(runtimeID); err == nil {
+		if provider, ok := dict.(mdx.FrequencyProvider); ok {
+			NewWordFreqService(s.db).ImportScores(provider.Frequencies())
+		}
+	}
 }
 
 // Toggle 切换字典启用状态
@@ -152,37 +410,215 @@ func (s *DictSourceService) Toggle(id uint) (*DictSourceResponse, error) {
 	if err := s.db.First(&source, id).Error; err != nil {
 		return nil, ErrDictSourceNotFound
 	}
+	if !source.Enabled {
+		return s.enable(&source)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if source.Enabled {
-		// 禁用：卸载字典
-		if runtimeID, ok := s.runtimeIDs[id]; ok {
-			if err := s.mdxManager.Unload(runtimeID); err != nil {
+	// 禁用：卸载字典；正在加载的字典加载完成后会被丢弃
+	if runtimeID, ok := s.runtimeIDs[id]; ok {
+		if err := s.mdxManager.Unload(runtimeID); err != nil {
+			return nil, err
+		}
+		delete(s.runtimeIDs, id)
+	}
+	delete(s.loadStates, id)
+	source.Enabled = false
+	return s.saveToggled(&source)
+}
+
+// enable 启用并加载字典；打开文件时不持有锁（与 refreshSource 相同），
+// 大字典加载期间搜索和列表等请求不受影响
+func (s *DictSourceService) enable(source *model.DictSource) (*DictSourceResponse, error) {
+	s.mu.Lock()
+	if state, ok := s.loadStates[source.ID]; ok && state.status == LoadStatusLoading {
+		s.mu.Unlock()
+		return nil, ErrDictLoading
+	}
+	s.loadStates[source.ID] = loadState{status: LoadStatusLoading}
+	s.mu.Unlock()
+
+	runtimeID, err := s.mdxManager.LoadDict(source.Path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 加载期间字典被删除，丢弃本次结果
+	if state, ok := s.loadStates[source.ID]; !ok || state.status != LoadStatusLoading {
+		if err == nil {
+			s.mdxManager.Unload(runtimeID)
+		}
+		return nil, ErrDictSourceNotFound
+	}
+	if err != nil {
+		s.setLoadFailed(source.ID, err)
+		if !mdx.IsVirtualPath(source.Path) {
+			s.setSourceError(source, sourceErrorStatus(source.Path), err)
+		}
+		return nil, err
+	}
+	s.setRuntimeID(source, runtimeID)
+	delete(s.loadStates, source.ID)
+	source.Enabled = true
+	source.Status = model.DictStatusOK
+	source.LastError = ""
+	s.applyResourceInfo(source, runtimeID)
+	return s.saveToggled(source)
+}
+
+// saveToggled 保存启用状态的变化（调用方持有 s.mu）
+func (s *DictSourceService) saveToggled(source *model.DictSource) (*DictSourceResponse, error) {
+	// 资源包的运行时 ID 随加载变化，重新同步所有字典的资源包关联
+	s.applyAllResourcePacks()
+
+	if err := s.db.Save(source).Error; err != nil {
+		return nil, err
+	}
+
+	resp := s.response(*source)
+	return &resp, nil
+}
+
+// DictSourceUpdate 可编辑的字典信息，nil 字段保持不变，空字符串清除该字段
+type DictSourceUpdate struct {
+	DisplayTitle *string `json:"display_title"`
+	Alias        *string `json:"alias"`
+	SourceLang   *string `json:"source_lang"`
+	TargetLang   *string `json:"target_lang"`
+	Tags         *string `json:"tags"` // 逗号分隔
+	Notes        *string `json:"notes"`
+	Homepage     *string `json:"homepage"`
+}
+
+// Update 修改字典的显示标题、简称、语言、标签等信息，显示标题立即用于搜索结果和建议
+func (s *DictSourceService) Update(id uint, update DictSourceUpdate) (*DictSourceResponse, error) {
+	source, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	if update.DisplayTitle != nil {
+		if source.DisplayTitle, err = limitLength(strings.TrimSpace(*update.DisplayTitle), 255); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "display_title")
+	}
+	if update.Alias != nil {
+		if source.Alias, err = limitLength(strings.TrimSpace(*update.Alias), 32); err != nil {
+			return nil, err
+		}
+		if source.Alias != "" {
+			var count int64
+			if err := s.db.Model(&model.DictSource{}).Where("LOWER(alias) = LOWER(?) AND id <> ?", source.Alias, id).Count(&count).Error; err != nil {
 				return nil, err
 			}
-			delete(s.runtimeIDs, id)
+			if count > 0 {
+				return nil, ErrAliasExists
+			}
 		}
-		source.Enabled = false
-	} else {
-		// 启用：加载字典
-		runtimeID, err := s.mdxManager.LoadDict(source.Path)
-		if err != nil {
+		columns = append(columns, "alias")
+	}
+	if update.SourceLang != nil {
+		if source.SourceLang, err = normalizeLanguage(*update.SourceLang); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "source_lang")
+	}
+	if update.TargetLang != nil {
+		if source.TargetLang, err = normalizeLanguage(*update.TargetLang); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "target_lang")
+	}
+	if update.Tags != nil {
+		if source.Tags, err = limitLength(normalizeTags(*update.Tags), 500); err != nil {
 			return nil, err
 		}
-		s.runtimeIDs[id] = runtimeID
-		source.Enabled = true
+		columns = append(columns, "tags")
+	}
+	if update.Notes != nil {
+		source.Notes = strings.TrimSpace(*update.Notes)
+		columns = append(columns, "notes")
+	}
+	if update.Homepage != nil {
+		if source.Homepage, err = normalizeHomepage(*update.Homepage); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "homepage")
 	}
 
-	if err := s.db.Save(&source).Error; err != nil {
-		return nil, err
+	if len(columns) > 0 {
+		if err := s.db.Model(source).Select(columns).Updates(source).Error; err != nil {
+			// 并发修改时由简称唯一索引兜底
+			if update.Alias != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return nil, ErrAliasExists
+			}
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if runtimeID, ok := s.runtimeIDs[id]; ok && update.DisplayTitle != nil {
+		s.mdxManager.SetDisplayTitle(runtimeID, source.DisplayTitle)
+	}
+	resp := s.response(*source)
+	return &resp, nil
+}
+
+// limitLength 检查字段长度（按字符计）
+func limitLength(value string, max int) (string, error) {
+	if utf8.RuneCountInString(value) > max {
+		return "", ErrFieldTooLong
+	}
+	return value, nil
+}
+
+// normalizeLanguage 校验语言代码，统一主语言子标签为小写
+func normalizeLanguage(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", nil
 	}
+	if len(code) > 16 || !languageCode.MatchString(code) {
+		return "", ErrInvalidLanguage
+	}
+	primary, rest, _ := strings.Cut(code, "-")
+	if rest == "" {
+		return strings.ToLower(primary), nil
+	}
+	return strings.ToLower(primary) + "-" + rest, nil
+}
+
+// normalizeTags 去除空白和重复的标签（不区分大小写），以逗号连接
+func normalizeTags(tags string) string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == '，' }) {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return strings.Join(result, ",")
+}
 
-	return &DictSourceResponse{
-		DictSource: source,
-		Loaded:     source.Enabled,
-	}, nil
+// normalizeHomepage 校验主页地址
+func normalizeHomepage(homepage string) (string, error) {
+	homepage = strings.TrimSpace(homepage)
+	if homepage == "" {
+		return "", nil
+	}
+	u, err := url.Parse(homepage)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(homepage) > 1024 {
+		return "", ErrInvalidHomepage
+	}
+	return homepage, nil
 }
 
 // Reorder 重新排序字典
@@ -212,6 +648,17 @@ func (s *DictSourceService) Delete(id uint) error {
 		s.mdxManager.Unload(runtimeID)
 		delete(s.runtimeIDs, id)
 	}
+	delete(s.loadStates, id)
+
+	// 删除该字典导入的词形
+	s.db.Where("dict_source_id = ?", id).Delete(&model.WordForm{})
+
+	// 从所有分组中移除
+	s.db.Where("dict_source_id = ?", id).Delete(&model.DictGroupMember{})
+
+	// 删除该字典（或资源包）的资源包关联
+	s.db.Where("dict_source_id = ? OR pack_source_id = ?", id, id).Delete(&model.DictResourcePack{})
+	s.applyAllResourcePacks()
 
 	// 软删除数据库记录
 	return s.db.Delete(&source).Error
@@ -235,53 +682,270 @@ func (s *DictSourceService) GetEnabled() ([]model.DictSource, error) {
 	return sources, nil
 }
 
-// SyncOnStartup 启动时同步：加载数据库中已启用的字典
-func (s *DictSourceService) SyncOnStartup() error {
+// SyncOnStartup 启动时同步：用 workers 个协程并发加载数据库中已启用的字典，workers 不大于 0 时使用 CPU 核数。
+// 加载期间字典状态为 loading，进度可通过 LoadProgress 查看
+func (s *DictSourceService) SyncOnStartup(workers int) error {
 	sources, err := s.GetEnabled()
 	if err != nil {
+		s.mu.Lock()
+		s.synced = true
+		s.mu.Unlock()
 		return err
 	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	s.mu.Lock()
+	var pending []model.DictSource
+	for _, src := range sources {
+		// 自动扫描时刚添加的字典已经加载
+		if _, ok := s.runtimeIDs[src.ID]; ok {
+			continue
+		}
+		s.loadStates[src.ID] = loadState{status: LoadStatusLoading}
+		pending = append(pending, src)
+	}
+	s.mu.Unlock()
+
+	jobs := make(chan model.DictSource)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for src := range jobs {
+				s.loadOnStartup(src)
+			}
+		}()
+	}
+	for _, src := range pending {
+		jobs <- src
+	}
+	close(jobs)
+	wg.Wait()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.applyAllResourcePacks()
+	s.synced = true
+	return nil
+}
 
-	for _, src := range sources {
-		// 检查文件是否存在
-		if _, err := os.Stat(src.Path); err != nil {
-			// 文件不存在，禁用该字典
-			s.db.Model(&src).Update("enabled", false)
+// loadOnStartup 加载单个字典，打开文件时不持有锁，其他请求可以正常处理
+func (s *DictSourceService) loadOnStartup(src model.DictSource) {
+	// 在后台协程中运行，任何意外的 panic 都只标记这一个字典加载失败
+	// （先注册，在解锁之后执行）
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic while loading: %v", r)
+			log.Printf("Failed to load dictionary %d (%s): %v", src.ID, src.Path, err)
+			s.mu.Lock()
+			s.setLoadFailed(src.ID, err)
+			s.mu.Unlock()
+			s.setSourceError(&src, model.DictStatusError, err)
+		}
+	}()
+
+	var runtimeID uint
+	var err error
+	// 检查文件是否存在
+	if !mdx.IsVirtualPath(src.Path) && !fileExists(src.Path) {
+		err = ErrDictFileNotFound
+	} else {
+		runtimeID, err = s.mdxManager.LoadDict(src.Path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 加载期间字典被禁用、删除或已由目录监视加载，丢弃本次结果
+	if state, ok := s.loadStates[src.ID]; !ok || state.status != LoadStatusLoading {
+		if err == nil {
+			s.mdxManager.Unload(runtimeID)
+		}
+		return
+	}
+	if err != nil {
+		// 加载失败时保留启用状态并记录原因，文件恢复或修复后由目录监视或重新扫描重新加载
+		s.setLoadFailed(src.ID, err)
+		if !mdx.IsVirtualPath(src.Path) {
+			s.setSourceError(&src, sourceErrorStatus(src.Path), err)
+		}
+		return
+	}
+
+	// 维护 ID 映射
+	s.setRuntimeID(&src, runtimeID)
+	delete(s.loadStates, src.ID)
+
+	// MDD 分卷可能在上次启动后增减，同步资源信息；文件变化后重新计算指纹（早期添加的字典没有指纹）
+	changed := s.applyResourceInfo(&src, runtimeID)
+	if !mdx.IsVirtualPath(src.Path) && refreshFingerprint(&src) {
+		changed = true
+	}
+	if src.Status != model.DictStatusOK || src.LastError != "" {
+		src.Status = model.DictStatusOK
+		src.LastError = ""
+		changed = true
+	}
+	if changed {
+		s.db.Model(&src).Select("has_mdd", "mdd_volumes", "resource_size", "fingerprint", "file_size", "file_mod_time", "status", "last_error").Updates(&src)
+	}
+}
+
+// setRuntimeID 记录字典的运行时 ID 并应用显示标题（调用方持有 s.mu）
+func (s *DictSourceService) setRuntimeID(source *model.DictSource, runtimeID uint) {
+	s.runtimeIDs[source.ID] = runtimeID
+	if source.DisplayTitle != "" {
+		s.mdxManager.SetDisplayTitle(runtimeID, source.DisplayTitle)
+	}
+}
+
+// applyResourceInfo 用已加载字典的资源信息更新记录，返回是否有变化
+func (s *DictSourceService) applyResourceInfo(source *model.DictSource, runtimeID uint) bool {
+	for _, info := range s.mdxManager.ListLoaded() {
+		if info.ID != runtimeID {
 			continue
 		}
+		if source.HasMDD == info.HasMDD && source.MDDVolumes == info.MDDVolumes && source.ResourceSize == info.ResourceSize {
+			return false
+		}
+		source.HasMDD = info.HasMDD
+		source.MDDVolumes = info.MDDVolumes
+		source.ResourceSize = info.ResourceSize
+		return true
+	}
+	return false
+}
 
-		// 加载字典
-		runtimeID, err := s.mdxManager.LoadDict(src.Path)
-		if err != nil {
-			// 加载失败，禁用该字典
-			s.db.Model(&src).Update("enabled", false)
+// ListResourcePacks 列出字典附加的资源包，按查找顺序排列
+func (s *DictSourceService) ListResourcePacks(id uint) ([]DictSourceResponse, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	var packs []model.DictSource
+	err := s.db.Joins("JOIN dict_resource_packs ON dict_resource_packs.pack_source_id = dict_sources.id").
+		Where("dict_resource_packs.dict_source_id = ?", id).
+		Order("dict_resource_packs.sort_order ASC").
+		Find(&packs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	responses := make([]DictSourceResponse, len(packs))
+	for i, pack := range packs {
+		responses[i] = s.response(pack)
+	}
+	return responses, nil
+}
+
+// SetResourcePacks 设置字典附加的资源包（替换原有列表），packIDs 的顺序即资源查找顺序
+func (s *DictSourceService) SetResourcePacks(id uint, packIDs []uint) ([]DictSourceResponse, error) {
+	source, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if source.Format == mdx.FormatResourcePack {
+		return nil, ErrPackOnResourcePack
+	}
+
+	var links []model.DictResourcePack
+	seen := make(map[uint]bool)
+	for _, packID := range packIDs {
+		if seen[packID] {
 			continue
 		}
+		seen[packID] = true
 
-		// 维护 ID 映射
-		s.runtimeIDs[src.ID] = runtimeID
+		pack, err := s.GetByID(packID)
+		if err != nil {
+			return nil, err
+		}
+		if pack.Format != mdx.FormatResourcePack {
+			return nil, ErrNotResourcePack
+		}
+		links = append(links, model.DictResourcePack{
+			DictSourceID: id,
+			PackSourceID: packID,
+			SortOrder:    len(links),
+		})
 	}
 
-	return nil
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dict_source_id = ?", id).Delete(&model.DictResourcePack{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.applyResourcePacks(id)
+	s.mu.Unlock()
+
+	return s.ListResourcePacks(id)
+}
+
+// applyResourcePacks 将字典的资源包关联同步到字典管理器（调用方持有 s.mu）
+func (s *DictSourceService) applyResourcePacks(id uint) {
+	runtimeID, ok := s.runtimeIDs[id]
+	if !ok {
+		return
+	}
+
+	var links []model.DictResourcePack
+	s.db.Where("dict_source_id = ?", id).Order("sort_order ASC").Find(&links)
+
+	var packIDs []uint
+	for _, link := range links {
+		// 未加载（禁用或文件缺失）的资源包被跳过
+		if packRuntimeID, ok := s.runtimeIDs[link.PackSourceID]; ok {
+			packIDs = append(packIDs, packRuntimeID)
+		}
+	}
+	s.mdxManager.SetResourcePacks(runtimeID, packIDs)
+}
+
+// applyAllResourcePacks 同步所有已加载字典的资源包关联（调用方持有 s.mu）
+func (s *DictSourceService) applyAllResourcePacks() {
+	for id := range s.runtimeIDs {
+		s.applyResourcePacks(id)
+	}
 }
 
 // AutoLoadFromDir 自动扫描目录中的字典文件并添加到数据库（支持递归扫描子目录）
 func (s *DictSourceService) AutoLoadFromDir() (int, error) {
+	// 只处理已注册格式的入口文件（.mdx、.ifo 等）
+	return s.autoLoad(s.sourceDir, s.mdxManager.IsSupported)
+}
+
+// AutoLoadResourcePacks 扫描目录（如音频目录）中的独立 MDD 资源包并添加到数据库
+func (s *DictSourceService) AutoLoadResourcePacks(dir string) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return 0, nil
+	}
+	return s.autoLoad(dir, mdx.IsResourcePack)
+}
+
+// autoLoad 递归扫描目录，将 accept 接受且尚未登记的文件添加到数据库
+func (s *DictSourceService) autoLoad(dir string, accept func(path string) bool) (int, error) {
 	var addedCount int
-	
-	err := filepath.WalkDir(s.sourceDir, func(path string, d os.DirEntry, err error) error {
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // 忽略单个文件错误，继续扫描
 		}
-		if d.IsDir() {
-			return nil
-		}
-		
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if ext != ".mdx" {
+		if d.IsDir() || !accept(path) || s.isReserved(path) {
 			return nil
 		}
 
@@ -292,10 +956,16 @@ func (s *DictSourceService) AutoLoadFromDir() (int, error) {
 			return nil
 		}
 
+		// 已登记的字典被移动或重命名：重新关联
+		if s.relinkMoved(path) {
+			return nil
+		}
+
 		// 添加到数据库
 		_, err = s.Add(path)
 		if err != nil {
 			// 记录错误但继续处理其他文件
+			logDuplicate(path, err)
 			return nil
 		}
 		addedCount++
@@ -309,6 +979,398 @@ func (s *DictSourceService) AutoLoadFromDir() (int, error) {
 	return addedCount, nil
 }
 
+// HandleFileChange 处理字典目录中的文件变化（由 DictWatcher 在变化平息后调用），返回是否影响了已加载的字典：
+// 入口文件被覆盖时热替换，被删除时标记为 missing，恢复后重新加载；配套文件（MDD 分卷、.idx 等）
+// 变化时重新加载所属字典；addNew 为 true 时自动添加新的字典文件
+func (s *DictSourceService) HandleFileChange(path string, addNew bool) bool {
+	if s.isReserved(path) {
+		return false
+	}
+	info, statErr := os.Stat(path)
+	exists := statErr == nil && !info.IsDir()
+
+	var source model.DictSource
+	if err := s.db.Where("path = ?", path).First(&source).Error; err == nil {
+		if exists {
+			return s.refreshSource(&source)
+		}
+		return s.markMissing(&source)
+	}
+
+	changed := false
+
+	// 目录被删除或移走时，其中的字典都标记为 missing
+	if statErr != nil {
+		prefix := path + string(filepath.Separator)
+		var sources []model.DictSource
+		s.db.Where("path LIKE ?", prefix+"%").Find(&sources)
+		for i := range sources {
+			if strings.HasPrefix(sources[i].Path, prefix) && s.markMissing(&sources[i]) {
+				changed = true
+			}
+		}
+	}
+
+	// 配套文件变化：重新加载同目录下同名的字典
+	dropped := false
+	for _, companion := range s.companionSources(path) {
+		if s.dropSupersededPack(&companion) {
+			dropped = true
+			continue
+		}
+		if s.refreshSource(&companion) {
+			changed = true
+		}
+	}
+	if changed || !exists || !s.mdxManager.IsSupported(path) {
+		return changed || dropped
+	}
+
+	// 新字典文件；已被用户删除（软删除）的不再自动添加
+	if err := s.db.Unscoped().Where("path = ?", path).First(&source).Error; err == nil {
+		return dropped
+	}
+	// 已登记的字典被移动或重命名：重新关联，不作为新字典添加
+	if s.relinkMoved(path) {
+		return true
+	}
+	if !addNew {
+		return dropped
+	}
+	_, err := s.Add(path)
+	logDuplicate(path, err)
+	return err == nil || dropped
+}
+
+// dropSupersededPack 同名 MDX 出现后，登记为独立资源包的 MDD 实际是该 MDX 的资源：
+// 文件监视按路径分别防抖，先复制完成的 MDD 会被先登记为资源包，这里将其删除，由 MDX 加载
+func (s *DictSourceService) dropSupersededPack(source *model.DictSource) bool {
+	if source.Format != mdx.FormatResourcePack || mdx.IsResourcePack(source.Path) {
+		return false
+	}
+	if err := s.Delete(source.ID); err != nil {
+		log.Printf("Failed to remove resource pack %s: %v", source.Path, err)
+		return false
+	}
+	log.Printf("Removed resource pack %s: it belongs to a dictionary now", source.Path)
+	return true
+}
+
+// logDuplicate 自动添加的文件与已有字典重复时记录日志，需要更新旧版本时通过替换接口添加
+func logDuplicate(path string, err error) {
+	var dupErr *DuplicateError
+	if errors.As(err, &dupErr) {
+		dup := dupErr.Duplicates[0]
+		log.Printf("Skipped %s: duplicates dictionary %d %q (%s)", path, dup.Existing.ID, dup.Existing.DisplayName(), dup.Reason)
+	}
+}
+
+// refreshSource 字典文件存在时同步状态：missing 的字典恢复并重新加载，已加载的字典热替换
+// 打开文件时不持有锁（与 loadOnStartup 相同），大字典热替换期间搜索和列表等请求不受影响
+func (s *DictSourceService) refreshSource(source *model.DictSource) bool {
+	s.mu.Lock()
+	runtimeID, loaded := s.runtimeIDs[source.ID]
+	if !loaded && source.Enabled {
+		s.loadStates[source.ID] = loadState{status: LoadStatusLoading}
+	}
+	s.mu.Unlock()
+
+	switch {
+	case loaded:
+		// Reload 在新文件打开后原子替换，运行时 ID 不变
+		if err := s.mdxManager.Reload(runtimeID); err != nil {
+			return false
+		}
+	case source.Enabled:
+		var err error
+		runtimeID, err = s.mdxManager.LoadDict(source.Path)
+
+		s.mu.Lock()
+		// 加载期间字典被禁用、删除或已由其他路径加载，丢弃本次结果
+		if state, ok := s.loadStates[source.ID]; !ok || state.status != LoadStatusLoading {
+			s.mu.Unlock()
+			if err == nil {
+				s.mdxManager.Unload(runtimeID)
+			}
+			return false
+		}
+		if err != nil {
+			s.setLoadFailed(source.ID, err)
+			s.mu.Unlock()
+			s.setSourceError(source, model.DictStatusError, err)
+			return false
+		}
+		s.setRuntimeID(source, runtimeID)
+		delete(s.loadStates, source.ID)
+		s.applyAllResourcePacks()
+		s.mu.Unlock()
+	default:
+		// 已禁用的字典只恢复状态
+		if source.Status != model.DictStatusOK {
+			source.Status = model.DictStatusOK
+			source.LastError = ""
+			s.db.Model(source).Select("status", "last_error").Updates(source)
+		}
+		return false
+	}
+
+	source.Status = model.DictStatusOK
+	source.LastError = ""
+	refreshFingerprint(source)
+	for _, info := range s.mdxManager.ListLoaded() {
+		if info.ID == runtimeID {
+			source.WordCount = info.WordCount
+			break
+		}
+	}
+	s.applyResourceInfo(source, runtimeID)
+	s.db.Model(source).Select("status", "last_error", "fingerprint", "word_count", "has_mdd", "mdd_volumes", "resource_size", "file_size", "file_mod_time").Updates(source)
+	return true
+}
+
+// fileState 添加字典时入口文件的大小、修改时间和内容指纹
+type fileState struct {
+	size        int64
+	modTime     time.Time
+	fingerprint string
+}
+
+// refreshFingerprint 文件大小或修改时间变化（或还没有指纹）时重新计算内容指纹，返回是否有变化
+func refreshFingerprint(source *model.DictSource) bool {
+	info, err := os.Stat(source.Path)
+	if err != nil {
+		return false
+	}
+	if source.Fingerprint != "" && info.Size() == source.FileSize && info.ModTime().Equal(source.FileModTime) {
+		return false
+	}
+	fingerprint, err := mdx.FileFingerprint(source.Path)
+	if err != nil {
+		return false
+	}
+	source.Fingerprint = fingerprint
+	source.FileSize = info.Size()
+	source.FileModTime = info.ModTime()
+	return true
+}
+
+// markMissing 字典文件被删除或移走：卸载字典并标记为 missing，保留启用状态以便文件恢复后自动加载
+func (s *DictSourceService) markMissing(source *model.DictSource) bool {
+	if mdx.IsVirtualPath(source.Path) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	if runtimeID, ok := s.runtimeIDs[source.ID]; ok {
+		s.mdxManager.Unload(runtimeID)
+		delete(s.runtimeIDs, source.ID)
+		s.applyAllResourcePacks()
+		changed = true
+	}
+	if source.Status != model.DictStatusMissing {
+		s.setSourceError(source, model.DictStatusMissing, ErrDictFileNotFound)
+	}
+	return changed
+}
+
+// setSourceError 记录字典文件的异常状态和原因
+func (s *DictSourceService) setSourceError(source *model.DictSource, status string, err error) {
+	source.Status = status
+	source.LastError = err.Error()
+	s.db.Model(source).Select("status", "last_error").Updates(source)
+}
+
+// sourceErrorStatus 根据文件是否存在区分加载失败的状态
+func sourceErrorStatus(path string) string {
+	if fileExists(path) {
+		return model.DictStatusError
+	}
+	return model.DictStatusMissing
+}
+
+// RelinkedSource 重新关联到新路径的字典
+type RelinkedSource struct {
+	ID      uint   `json:"id"`
+	Title   string `json:"title"`
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
+
+// RescanResult 重新扫描结果
+type RescanResult struct {
+	Relinked []RelinkedSource   `json:"relinked"`
+	Missing  []model.DictSource `json:"missing"` // 仍未找到文件的字典
+}
+
+// Rescan 扫描字典源文件目录，按内容指纹找回被移动或重命名的字典文件并重新关联。
+// 字典记录原地更新路径，ID、排序、启用状态、资源包关联和词形等引用都保持不变
+func (s *DictSourceService) Rescan() (*RescanResult, error) {
+	var sources []model.DictSource
+	if err := s.db.Order("sort_order ASC, id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	result := &RescanResult{Relinked: []RelinkedSource{}, Missing: []model.DictSource{}}
+	var lost []model.DictSource
+	for _, src := range sources {
+		if !mdx.IsVirtualPath(src.Path) && !fileExists(src.Path) {
+			lost = append(lost, src)
+		}
+	}
+	if len(lost) == 0 {
+		return result, nil
+	}
+
+	candidates, err := s.unregisteredFiles()
+	if err != nil {
+		return nil, err
+	}
+	for i := range lost {
+		source := &lost[i]
+		newPath, ok := candidates[source.Fingerprint]
+		if source.Fingerprint == "" || !ok {
+			s.markMissing(source)
+			result.Missing = append(result.Missing, *source)
+			continue
+		}
+		delete(candidates, source.Fingerprint)
+
+		oldPath := source.Path
+		if err := s.relink(source, newPath); err != nil {
+			return nil, err
+		}
+		result.Relinked = append(result.Relinked, RelinkedSource{
+			ID:      source.ID,
+			Title:   source.DisplayName(),
+			OldPath: oldPath,
+			NewPath: newPath,
+		})
+	}
+	return result, nil
+}
+
+// unregisteredFiles 计算字典源文件目录中未登记（含已软删除）的字典入口文件的指纹，返回指纹到路径的映射
+func (s *DictSourceService) unregisteredFiles() (map[string]string, error) {
+	var paths []string
+	if err := s.db.Unscoped().Model(&model.DictSource{}).Pluck("path", &paths).Error; err != nil {
+		return nil, err
+	}
+	registered := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		registered[path] = true
+	}
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(s.sourceDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // 忽略无法访问的子目录
+		}
+		if path != s.sourceDir && isHiddenFile(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || registered[path] || !s.mdxManager.IsSupported(path) || s.isReserved(path) {
+			return nil
+		}
+		if fingerprint, err := mdx.FileFingerprint(path); err == nil {
+			files[fingerprint] = path
+		}
+		return nil
+	})
+	return files, err
+}
+
+// relinkMoved 新文件与某个文件已丢失的字典指纹相同时（字典被移动或重命名），重新关联该字典
+func (s *DictSourceService) relinkMoved(path string) bool {
+	fingerprint, err := mdx.FileFingerprint(path)
+	if err != nil {
+		return false
+	}
+
+	var sources []model.DictSource
+	s.db.Where("fingerprint = ?", fingerprint).Order("id ASC").Find(&sources)
+	for i := range sources {
+		// 原文件仍然存在时新文件是副本
+		if mdx.IsVirtualPath(sources[i].Path) || fileExists(sources[i].Path) {
+			continue
+		}
+		return s.relink(&sources[i], path) == nil
+	}
+	return false
+}
+
+// relink 将字典关联到新的文件路径并按新路径重新加载
+func (s *DictSourceService) relink(source *model.DictSource, path string) error {
+	if err := s.db.Model(source).Update("path", path).Error; err != nil {
+		return err
+	}
+	source.Path = path
+
+	// 按旧路径加载的字典无法热替换，卸载后重新加载
+	s.mu.Lock()
+	if runtimeID, ok := s.runtimeIDs[source.ID]; ok {
+		s.mdxManager.Unload(runtimeID)
+		delete(s.runtimeIDs, source.ID)
+	}
+	s.mu.Unlock()
+
+	s.refreshSource(source)
+	return nil
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// companionSources 查找以 path 为配套文件的字典：同一目录下，文件名以字典主名加 "." 开头
+// （如 oald.mdx 的 oald.mdd、oald.1.mdd，x.ifo 的 x.idx、x.dict.dz）
+func (s *DictSourceService) companionSources(path string) []model.DictSource {
+	dir := filepath.Dir(path)
+	name := strings.ToLower(filepath.Base(path))
+
+	var sources []model.DictSource
+	s.db.Where("path LIKE ?", dir+string(filepath.Separator)+"%").Find(&sources)
+
+	var companions []model.DictSource
+	for _, src := range sources {
+		if filepath.Dir(src.Path) != dir {
+			continue
+		}
+		base := filepath.Base(src.Path)
+		stem := strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base)))
+		if strings.HasPrefix(name, stem+".") {
+			companions = append(companions, src)
+		}
+	}
+	return companions
+}
+
+// OrderedRuntimeIDs 返回已启用且已加载的字典的运行时 ID，按 sort_order、id 排序，
+// 未指定分组的搜索按此顺序查询字典
+func (s *DictSourceService) OrderedRuntimeIDs() ([]uint, error) {
+	var sources []model.DictSource
+	if err := s.db.Select("id").Where("enabled = ?", true).Order("sort_order ASC, id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dictIDs := make([]uint, 0, len(sources))
+	for _, src := range sources {
+		if runtimeID, ok := s.runtimeIDs[src.ID]; ok {
+			dictIDs = append(dictIDs, runtimeID)
+		}
+	}
+	return dictIDs, nil
+}
+
 // GetRuntimeID 获取字典的运行时 ID（供其他服务使用）
 func (s *DictSourceService) GetRuntimeID(dbID uint) (uint, bool) {
 	s.mu.RLock()
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce 文件最后一次变化后等待的时间，复制大文件时会持续产生写事件
+const DefaultWatchDebounce = 2 * time.Second
+
+// DictWatcher 监视字典源文件目录（含子目录），新增、覆盖、删除字典文件后自动同步
+type DictWatcher struct {
+	dictSourceSvc *DictSourceService
+	dir           string
+	debounce      time.Duration
+	addNew        bool   // 是否自动添加新的字典文件
+	onChange      func() // 已加载的字典发生变化后调用（如清除搜索缓存）
+
+	watcher *fsnotify.Watcher
+	timers  map[string]*time.Timer // 每个路径的防抖计时器
+	mu      sync.Mutex
+}
+
+// NewDictWatcher 创建字典目录监视器，debounce 不大于 0 时使用 DefaultWatchDebounce；
+// addNew 为 false 时只同步已登记的字典，不添加新文件
+func NewDictWatcher(dictSourceSvc *DictSourceService, dir string, debounce time.Duration, addNew bool, onChange func()) *DictWatcher {
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	return &DictWatcher{
+		dictSourceSvc: dictSourceSvc,
+		dir:           dir,
+		debounce:      debounce,
+		addNew:        addNew,
+		onChange:      onChange,
+		timers:        make(map[string]*time.Timer),
+	}
+}
+
+// Start 开始监视目录
+func (w *DictWatcher) Start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w.watcher = watcher
+
+	if err := w.addDir(w.dir, false); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go w.loop()
+	return nil
+}
+
+// Close 停止监视
+func (w *DictWatcher) Close() error {
+	w.mu.Lock()
+	for path, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, path)
+	}
+	w.mu.Unlock()
+
+	if w.watcher == nil {
+		return nil
+	}
+	return w.watcher.Close()
+}
+
+// loop 处理文件系统事件
+func (w *DictWatcher) loop() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if isIgnoredFile(event.Name) {
+				continue
+			}
+			// 新建或移入的目录：监视并处理其中已有的文件
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addDir(event.Name, true)
+					continue
+				}
+			}
+			w.schedule(event.Name)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Dictionary watcher error: %v", err)
+		}
+	}
+}
+
+// addDir 递归监视目录，schedule 为 true 时同时处理目录中已有的文件
+func (w *DictWatcher) addDir(dir string, schedule bool) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // 忽略无法访问的子目录
+		}
+		if d.IsDir() {
+			if path != dir && isHiddenFile(path) {
+				return filepath.SkipDir
+			}
+			if err := w.watcher.Add(path); err != nil && path == dir {
+				return err
+			}
+			return nil
+		}
+		if schedule && !isIgnoredFile(path) {
+			w.schedule(path)
+		}
+		return nil
+	})
+}
+
+// schedule 在路径的变化平息 debounce 时长后处理，期间的新事件会重新计时
+func (w *DictWatcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if timer, ok := w.timers[path]; ok {
+		timer.Reset(w.debounce)
+		return
+	}
+	w.timers[path] = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.timers, path)
+		w.mu.Unlock()
+
+		if w.dictSourceSvc.HandleFileChange(path, w.addNew) {
+			log.Printf("Dictionary files changed: %s", path)
+			if w.onChange != nil {
+				w.onChange()
+			}
+		}
+	})
+}
+
+// isHiddenFile 判断是否为隐藏文件或目录（如编辑器、下载工具的临时文件）
+func isHiddenFile(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".")
+}
+
+// inProgressSuffixes 正在写入、完成后会被重命名的文件后缀：字典 Writer 和下载任务的 .tmp、
+// 导出的 .part，以及浏览器未完成的下载
+var inProgressSuffixes = []string{".tmp", ".part", ".crdownload", ".download"}
+
+// isIgnoredFile 判断监视器是否忽略该文件：隐藏文件和正在写入的临时文件，
+// 写完重命名为最终文件名时会产生新的事件
+func isIgnoredFile(path string) bool {
+	if isHiddenFile(path) {
+		return true
+	}
+	lower := strings.ToLower(path)
+	for _, suffix := range inProgressSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -19,7 +20,7 @@ var (
 	ErrInvalidURL        = errors.New("invalid URL")
 	ErrDownloadFailed    = errors.New("download failed")
 	ErrTaskNotFound      = errors.New("download task not found")
-	ErrInvalidFileFormat = errors.New("invalid file format, only .mdx files are supported")
+	ErrInvalidFileFormat = errors.New("invalid file format")
 )
 
 // DownloadService 下载服务
@@ -41,8 +42,8 @@ func NewDownloadService(db *gorm.DB, dictDir string, dictSourceSvc *DictSourceSe
 	}
 }
 
-// StartDownload 启动异步下载任务
-func (s *DownloadService) StartDownload(downloadURL string) (*model.DownloadTask, error) {
+// StartDownload 启动异步下载任务，下载完成后按 opts 添加字典或替换已有字典
+func (s *DownloadService) StartDownload(downloadURL string, opts AddOptions) (*model.DownloadTask, error) {
 	// 验证 URL
 	parsedURL, err := url.Parse(downloadURL)
 	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
@@ -55,9 +56,10 @@ func (s *DownloadService) StartDownload(downloadURL string) (*model.DownloadTask
 		fileName = "dictionary.mdx"
 	}
 
-	// 确保是 .mdx 文件
-	if !strings.HasSuffix(strings.ToLower(fileName), ".mdx") {
-		return nil, ErrInvalidFileFormat
+	// 确保是支持的字典格式，错误信息中列出支持的后缀
+	if s.extension(fileName) == "" {
+		exts := s.dictSourceSvc.mdxManager.SupportedExtensions()
+		return nil, fmt.Errorf("%w, supported extensions: %s", ErrInvalidFileFormat, strings.Join(exts, ", "))
 	}
 
 	// 创建下载任务
@@ -66,13 +68,19 @@ func (s *DownloadService) StartDownload(downloadURL string) (*model.DownloadTask
 		FileName: fileName,
 		Status:   model.DownloadStatusPending,
 	}
+	if opts.ReplaceID != 0 {
+		if _, err := s.dictSourceSvc.GetByID(opts.ReplaceID); err != nil {
+			return nil, err
+		}
+		task.ReplaceID = &opts.ReplaceID
+	}
 
 	if err := s.db.Create(task).Error; err != nil {
 		return nil, err
 	}
 
 	// 启动后台下载
-	go s.downloadWorker(task.ID)
+	go s.downloadWorker(task.ID, opts)
 
 	return task, nil
 }
@@ -87,7 +95,7 @@ func (s *DownloadService) GetTaskStatus(taskID uint) (*model.DownloadTask, error
 }
 
 // downloadWorker 后台下载工作协程
-func (s *DownloadService) downloadWorker(taskID uint) {
+func (s *DownloadService) downloadWorker(taskID uint, opts AddOptions) {
 	var task model.DownloadTask
 	if err := s.db.First(&task, taskID).Error; err != nil {
 		return
@@ -176,8 +184,17 @@ func (s *DownloadService) downloadWorker(taskID uint) {
 		return
 	}
 
-	// 添加到字典源
-	dictSource, err := s.dictSourceSvc.Add(filePath)
+	// 添加到字典源；与已有字典重复时删除下载的文件，记录重复的字典供替换
+	dictSource, err := s.dictSourceSvc.AddWithOptions(filePath, opts)
+	var dupErr *DuplicateError
+	if errors.As(err, &dupErr) {
+		os.Remove(filePath)
+		dup := dupErr.Duplicates[0]
+		s.updateTaskStatus(taskID, model.DownloadStatusFailed, 100, totalSize, downloaded,
+			fmt.Sprintf("dictionary duplicates %q (id %d, %s)", dup.Existing.DisplayName(), dup.Existing.ID, dup.Reason))
+		s.db.Model(&model.DownloadTask{}).Where("id = ?", taskID).Update("duplicate_of", dup.Existing.ID)
+		return
+	}
 	if err != nil {
 		s.updateTaskStatus(taskID, model.DownloadStatusFailed, 100, totalSize, downloaded, "file saved but failed to load dictionary: "+err.Error())
 		return
@@ -211,14 +228,30 @@ func (s *DownloadService) updateTaskStatus(taskID uint, status string, progress
 	s.db.Model(&model.DownloadTask{}).Where("id = ?", taskID).Updates(updates)
 }
 
+// extension 返回文件名匹配的最长字典格式后缀（保留原大小写），不支持的格式返回空字符串
+func (s *DownloadService) extension(fileName string) string {
+	name := strings.ToLower(fileName)
+	var matched string
+	for _, ext := range s.dictSourceSvc.mdxManager.SupportedExtensions() {
+		if strings.HasSuffix(name, ext) && len(ext) > len(matched) {
+			matched = ext
+		}
+	}
+	return fileName[len(fileName)-len(matched):]
+}
+
 // uniqueFilePath 生成唯一文件路径
 func (s *DownloadService) uniqueFilePath(path string) string {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return path
 	}
 
-	ext := filepath.Ext(path)
-	base := strings.TrimSuffix(path, ext)
+	// 按完整后缀（如 .dsl.dz）插入编号，保证新文件名仍能识别格式
+	ext := s.extension(path)
+	if ext == "" {
+		ext = filepath.Ext(path)
+	}
+	base := path[:len(path)-len(ext)]
 
 	for i := 1; i < 1000; i++ {
 		newPath := base + "_" + string(rune('0'+i/100)) + string(rune('0'+(i/10)%10)) + string(rune('0'+i%10)) + ext
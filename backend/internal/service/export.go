@@ -0,0 +1,437 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"dict-hub/internal/model"
+	"dict-hub/internal/service/mdx"
+	"dict-hub/pkg/stardict"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound    = errors.New("export task not found")
+	ErrExportInProgress  = errors.New("export already in progress")
+	ErrExportUnsupported = errors.New("unsupported export format, expected jsonl, stardict or html")
+	ErrExportNotLoaded   = errors.New("dictionary is not loaded, enable it before exporting")
+	ErrExportNotReady    = errors.New("export is not completed")
+)
+
+// mdictLinkPrefix MDX 中跳转到其他词条的记录前缀
+const mdictLinkPrefix = "@@@LINK="
+
+var (
+	entryLinkPattern = regexp.MustCompile(`(?i)(href\s*=\s*["'])entry://([^"'#]*)`)
+	soundLinkPattern = regexp.MustCompile(`(?i)(href\s*=\s*["'])sound://`)
+	unsafeNameChars  = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+)
+
+// ExportService 字典导出服务，将已加载的字典导出为 JSONL、StarDict 或 HTML 压缩包
+type ExportService struct {
+	db            *gorm.DB
+	mdxManager    mdx.DictManager
+	dictSourceSvc *DictSourceService
+	outputDir     string
+	running       map[uint]bool // DictSource ID -> 是否正在导出
+	mu            sync.Mutex
+}
+
+// NewExportService 创建导出服务，导出文件保存在 outputDir
+func NewExportService(db *gorm.DB, mdxManager mdx.DictManager, dictSourceSvc *DictSourceService, outputDir string) *ExportService {
+	return &ExportService{
+		db:            db,
+		mdxManager:    mdxManager,
+		dictSourceSvc: dictSourceSvc,
+		outputDir:     outputDir,
+		running:       make(map[uint]bool),
+	}
+}
+
+// StartExport 启动异步导出任务
+func (s *ExportService) StartExport(dictSourceID uint, format string) (*model.DictExport, error) {
+	switch format {
+	case model.ExportFormatJSONL, model.ExportFormatStarDict, model.ExportFormatHTML:
+	default:
+		return nil, ErrExportUnsupported
+	}
+
+	source, err := s.dictSourceSvc.GetByID(dictSourceID)
+	if err != nil {
+		return nil, err
+	}
+	runtimeID, ok := s.dictSourceSvc.GetRuntimeID(dictSourceID)
+	if !ok {
+		return nil, ErrExportNotLoaded
+	}
+	dict, err := s.mdxManager.GetDictionary(runtimeID)
+	if err != nil {
+		return nil, ErrExportNotLoaded
+	}
+
+	s.mu.Lock()
+	if s.running[dictSourceID] {
+		s.mu.Unlock()
+		return nil, ErrExportInProgress
+	}
+	s.running[dictSourceID] = true
+	s.mu.Unlock()
+
+	task := &model.DictExport{
+		DictSourceID: dictSourceID,
+		Format:       format,
+		Status:       model.ExportStatusPending,
+	}
+	if err := s.db.Create(task).Error; err != nil {
+		s.finish(dictSourceID)
+		return nil, err
+	}
+
+	name := unsafeNameChars.ReplaceAllString(source.Name, "_")
+	if name == "" || name == "_" {
+		name = fmt.Sprintf("dict_%d", dictSourceID)
+	}
+
+	// 启动后台导出
+	go s.exportWorker(task.ID, dictSourceID, dict, name, format)
+
+	return task, nil
+}
+
+// ListExports 获取字典的导出任务（最新的在前）
+func (s *ExportService) ListExports(dictSourceID uint) ([]model.DictExport, error) {
+	var tasks []model.DictExport
+	if err := s.db.Where("dict_source_id = ?", dictSourceID).Order("id DESC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetExport 获取指定导出任务
+func (s *ExportService) GetExport(dictSourceID, exportID uint) (*model.DictExport, error) {
+	var task model.DictExport
+	if err := s.db.Where("dict_source_id = ?", dictSourceID).First(&task, exportID).Error; err != nil {
+		return nil, ErrExportNotFound
+	}
+	return &task, nil
+}
+
+// GetExportFile 获取已完成导出任务的文件路径
+func (s *ExportService) GetExportFile(dictSourceID, exportID uint) (string, error) {
+	task, err := s.GetExport(dictSourceID, exportID)
+	if err != nil {
+		return "", err
+	}
+	if task.Status != model.ExportStatusCompleted {
+		return "", ErrExportNotReady
+	}
+	if _, err := os.Stat(task.OutputPath); err != nil {
+		return "", ErrExportNotFound
+	}
+	return task.OutputPath, nil
+}
+
+// exportProgress 导出进度，词条阶段占 0-90，资源阶段占 90-99
+type exportProgress struct {
+	svc       *ExportService
+	taskID    uint
+	total     int64
+	entries   int64
+	resources int64
+	last      int
+}
+
+func (p *exportProgress) entry() {
+	p.entries++
+	if p.total <= 0 {
+		return
+	}
+	p.update(int(p.entries * 90 / p.total))
+}
+
+func (p *exportProgress) resource() {
+	p.resources++
+	p.update(90)
+}
+
+func (p *exportProgress) update(progress int) {
+	if progress > 99 {
+		progress = 99
+	}
+	if progress >= p.last+5 || (progress > p.last && progress >= 90) {
+		p.svc.db.Model(&model.DictExport{}).Where("id = ?", p.taskID).Update("progress", progress)
+		p.last = progress
+	}
+}
+
+// exportWorker 后台导出工作协程，词条和资源各顺序遍历一次
+func (s *ExportService) exportWorker(taskID, dictSourceID uint, dict mdx.Dictionary, name, format string) {
+	defer s.finish(dictSourceID)
+
+	startedAt := time.Now()
+	s.db.Model(&model.DictExport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"status":     model.ExportStatusRunning,
+		"started_at": startedAt,
+	})
+
+	fail := func(err error) {
+		s.db.Model(&model.DictExport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+			"status":      model.ExportStatusFailed,
+			"error_msg":   err.Error(),
+			"finished_at": time.Now(),
+		})
+	}
+
+	if err := os.MkdirAll(s.outputDir, 0755); err != nil {
+		fail(err)
+		return
+	}
+	outputPath := filepath.Join(s.outputDir, fmt.Sprintf("%s-%s-%s.zip", name, format, startedAt.Format("20060102_150405")))
+	tmpPath := outputPath + ".part"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	progress := &exportProgress{svc: s, taskID: taskID, total: dict.WordCount()}
+	err = writeArchive(file, dict, name, format, progress)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, outputPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		fail(err)
+		return
+	}
+
+	var fileSize int64
+	if info, err := os.Stat(outputPath); err == nil {
+		fileSize = info.Size()
+	}
+
+	s.db.Model(&model.DictExport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"status":         model.ExportStatusCompleted,
+		"progress":       100,
+		"entry_count":    progress.entries,
+		"resource_count": progress.resources,
+		"output_path":    outputPath,
+		"file_size":      fileSize,
+		"finished_at":    time.Now(),
+	})
+}
+
+// writeArchive 按格式写入 zip 归档；遍历第三方格式的损坏文件时解析器的 panic
+// 转换为错误，只让这一个导出任务失败
+func writeArchive(w io.Writer, dict mdx.Dictionary, name, format string, progress *exportProgress) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while exporting: %v", r)
+		}
+	}()
+
+	zw := zip.NewWriter(w)
+	switch format {
+	case model.ExportFormatJSONL:
+		err = exportJSONL(zw, dict, name, progress)
+	case model.ExportFormatStarDict:
+		err = exportStarDict(zw, dict, name, progress)
+	case model.ExportFormatHTML:
+		err = exportHTML(zw, dict, name, progress)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	return err
+}
+
+// finish 清除运行标记
+func (s *ExportService) finish(dictSourceID uint) {
+	s.mu.Lock()
+	delete(s.running, dictSourceID)
+	s.mu.Unlock()
+}
+
+// exportJSONL 每行一个 {"word", "definition"} 对象
+func exportJSONL(zw *zip.Writer, dict mdx.Dictionary, name string, progress *exportProgress) error {
+	w, err := createZipEntry(zw, name+".jsonl")
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	err = dict.Iterate(func(key string, definition []byte) error {
+		progress.entry()
+		return enc.Encode(struct {
+			Word       string `json:"word"`
+			Definition string `json:"definition"`
+		}{key, string(definition)})
+	})
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// exportStarDict 生成 StarDict（sametypesequence=h），MDX 跳转记录转为同义词，资源放在 res/ 下
+func exportStarDict(zw *zip.Writer, dict mdx.Dictionary, name string, progress *exportProgress) error {
+	sw := stardict.NewWriter(stardict.WriterOptions{
+		BookName:    dict.Title(),
+		Description: dict.Description(),
+	})
+	defer sw.Close()
+
+	err := dict.Iterate(func(key string, definition []byte) error {
+		progress.entry()
+		if target, ok := linkTarget(definition); ok {
+			sw.AddSynonym(key, target)
+			return nil
+		}
+		def := entryLinkPattern.ReplaceAllString(string(definition), "${1}bword://$2")
+		if err := sw.Add(key, []byte(def)); err != nil && err != stardict.ErrEmptyWord {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	files, err := sw.Build()
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		ext  string
+		data []byte
+	}{
+		{".ifo", files.Ifo},
+		{".idx", files.Idx},
+		{".syn", files.Syn},
+	} {
+		if len(f.data) == 0 {
+			continue
+		}
+		if err := writeZipFile(zw, name+f.ext, f.data); err != nil {
+			return err
+		}
+	}
+	w, err := createZipEntry(zw, name+".dict.dz")
+	if err != nil {
+		return err
+	}
+	// 数据文件从 Writer 的临时文件流式压缩写入
+	if err := files.WriteDict(w); err != nil {
+		return err
+	}
+
+	return exportResources(zw, dict, "res/", progress)
+}
+
+// exportHTML 生成单个 HTML 文件，词条链接改为页内锚点，资源按原路径放在 HTML 旁边
+func exportHTML(zw *zip.Writer, dict mdx.Dictionary, name string, progress *exportProgress) error {
+	w, err := createZipEntry(zw, name+".html")
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+
+	title := html.EscapeString(dict.Title())
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + title + "</title>\n</head>\n<body>\n")
+	buf.WriteString("<h1>" + title + "</h1>\n")
+	if desc := dict.Description(); desc != "" {
+		buf.WriteString("<div class=\"description\">" + desc + "</div>\n")
+	}
+
+	err = dict.Iterate(func(key string, definition []byte) error {
+		progress.entry()
+		buf.WriteString(`<section class="entry" id="` + html.EscapeString(key) + `">` + "\n")
+		buf.WriteString("<h2>" + html.EscapeString(key) + "</h2>\n")
+		if target, ok := linkTarget(definition); ok {
+			buf.WriteString(`<p>&rarr; <a href="#` + url.PathEscape(target) + `">` + html.EscapeString(target) + "</a></p>\n")
+		} else {
+			def := entryLinkPattern.ReplaceAllStringFunc(string(definition), func(m string) string {
+				parts := entryLinkPattern.FindStringSubmatch(m)
+				return parts[1] + "#" + url.PathEscape(html.UnescapeString(parts[2]))
+			})
+			def = soundLinkPattern.ReplaceAllString(def, "${1}")
+			buf.WriteString(def + "\n")
+		}
+		_, err := buf.WriteString("</section>\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	buf.WriteString("</body>\n</html>\n")
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+
+	return exportResources(zw, dict, "", progress)
+}
+
+// exportResources 将字典资源写入压缩包的 prefix 目录
+func exportResources(zw *zip.Writer, dict mdx.Dictionary, prefix string, progress *exportProgress) error {
+	iter, ok := dict.(mdx.ResourceIterator)
+	if !ok {
+		return nil
+	}
+	return iter.IterateResources(func(path string, data []byte) error {
+		// 拒绝跳出导出目录的路径
+		clean := filepath.ToSlash(filepath.Clean(path))
+		if clean == "." || strings.HasPrefix(clean, "../") || clean == ".." {
+			return nil
+		}
+		progress.resource()
+		return writeZipFile(zw, prefix+clean, data)
+	})
+}
+
+// writeZipFile 向压缩包写入单个文件
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := createZipEntry(zw, name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// createZipEntry 在压缩包中创建文件（带修改时间）
+func createZipEntry(zw *zip.Writer, name string) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+}
+
+// linkTarget 解析 MDX 的 @@@LINK= 跳转记录
+func linkTarget(definition []byte) (string, bool) {
+	text := strings.TrimSpace(string(definition))
+	if !strings.HasPrefix(text, mdictLinkPrefix) {
+		return "", false
+	}
+	target := strings.TrimSpace(strings.TrimPrefix(text, mdictLinkPrefix))
+	return target, target != ""
+}
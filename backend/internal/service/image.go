@@ -0,0 +1,217 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dict-hub/internal/service/mdx"
+	"dict-hub/pkg/imaging"
+)
+
+// ImageVariant 缩放或转换格式后的图片
+type ImageVariant struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+}
+
+// DefaultImageCacheSize 图片缓存的默认容量上限，超出后删除最久未使用的缓存
+const DefaultImageCacheSize = 256 << 20
+
+// imagePruneInterval 两次清理缓存之间的最短间隔
+const imagePruneInterval = time.Minute
+
+// variantSizes 允许的输出宽高，请求的尺寸向上取整到其中之一，
+// 客户端无法通过任意改变 w/h 生成无限多的缓存文件
+var variantSizes = []int{32, 64, 128, 256, 384, 512, 768, 1024, 1536, 2048, 3072, imaging.MaxDimension}
+
+// snapSize 将尺寸向上取整到 variantSizes，0 表示不限制
+func snapSize(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	for _, size := range variantSizes {
+		if n <= size {
+			return size
+		}
+	}
+	return imaging.MaxDimension
+}
+
+// ImageService 字典图片缩放与格式转换服务，生成的图片缓存在磁盘上，
+// 按字典指纹分目录存放，总大小不超过 maxSize
+type ImageService struct {
+	mdxManager mdx.DictManager
+	cacheDir   string
+	maxSize    int64
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+	pruning   atomic.Bool // 是否正在清理
+}
+
+// NewImageService 创建图片服务
+func NewImageService(mdxManager mdx.DictManager, cacheDir string) *ImageService {
+	return &ImageService{
+		mdxManager: mdxManager,
+		cacheDir:   cacheDir,
+		maxSize:    DefaultImageCacheSize,
+	}
+}
+
+// Variant 获取字典图片资源的缩放/转换版本，dictID 为运行时 ID；宽高向上取整到 variantSizes
+// 缓存以字典指纹和资源路径为键，字典文件或资源包替换后自动失效，旧指纹的缓存由 prune 删除
+func (s *ImageService) Variant(dictID uint, resourcePath string, opts imaging.Options) (*ImageVariant, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts.Width, opts.Height = snapSize(opts.Width), snapSize(opts.Height)
+
+	fingerprint, err := s.mdxManager.Fingerprint(dictID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%s", resourcePath, opts.Width, opts.Height, opts.Format)))
+	name := hex.EncodeToString(key[:])
+	cacheBase := filepath.Join(s.cacheDir, fingerprint, name[:2], name)
+
+	// 命中缓存时不再读取字典资源；未指定格式时输出格式取决于原图，两种扩展名都要检查
+	formats := []string{opts.Format}
+	if opts.Format == "" {
+		formats = []string{imaging.FormatJPEG, imaging.FormatPNG}
+	}
+	for _, format := range formats {
+		cachePath := cacheBase + "." + format
+		if data, err := os.ReadFile(cachePath); err == nil {
+			variant := &ImageVariant{Data: data, ContentType: imaging.ContentType(format)}
+			if info, err := os.Stat(cachePath); err == nil {
+				variant.ModTime = info.ModTime()
+			}
+			// 访问时间记录在 atime 中不可靠，用 mtime 记录最近使用时间，供容量清理使用
+			now := time.Now()
+			os.Chtimes(cachePath, now, now)
+			return variant, nil
+		}
+	}
+
+	content, err := s.mdxManager.OpenResource(dictID, resourcePath)
+	if err != nil {
+		return nil, err
+	}
+	data, format, err := imaging.Transform(content.Data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 写入临时文件后重命名，避免并发请求读到不完整的缓存；写入失败不影响本次响应
+	s.writeCache(cacheBase+"."+format, data)
+	s.schedulePrune()
+
+	return &ImageVariant{
+		Data:        data,
+		ContentType: imaging.ContentType(format),
+		ModTime:     time.Now(),
+	}, nil
+}
+
+// schedulePrune 距上次清理超过 imagePruneInterval 时在后台清理缓存
+func (s *ImageService) schedulePrune() {
+	s.pruneMu.Lock()
+	due := time.Since(s.lastPrune) >= imagePruneInterval
+	if due {
+		s.lastPrune = time.Now()
+	}
+	s.pruneMu.Unlock()
+
+	if due && s.pruning.CompareAndSwap(false, true) {
+		go func() {
+			defer s.pruning.Store(false)
+			s.prune()
+		}()
+	}
+}
+
+// prune 删除不属于任何已加载字典当前指纹的缓存目录（字典被重新加载、替换或删除），
+// 总大小仍超过 maxSize 时按最近使用时间删除最旧的缓存文件
+func (s *ImageService) prune() {
+	current := make(map[string]bool)
+	for _, info := range s.mdxManager.ListLoaded() {
+		if fingerprint, err := s.mdxManager.Fingerprint(info.ID); err == nil {
+			current[fingerprint] = true
+		}
+	}
+
+	dirs, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cacheFile
+	var total int64
+	for _, dir := range dirs {
+		path := filepath.Join(s.cacheDir, dir.Name())
+		if !dir.IsDir() || !current[dir.Name()] {
+			os.RemoveAll(path)
+			continue
+		}
+		filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files = append(files, cacheFile{path: p, size: info.Size(), modTime: info.ModTime()})
+				total += info.Size()
+			}
+			return nil
+		})
+	}
+	if total <= s.maxSize {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= s.maxSize {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+}
+
+// writeCache 原子地写入缓存文件
+func (s *ImageService) writeCache(cachePath string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".variant-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cachePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,48 @@
+package mdx
+
+import (
+	"os"
+
+	"dict-hub/pkg/bgl"
+)
+
+// bglDictionary Babylon 词汇表（.bgl），打开时整体解码到内存，资源内嵌在文件中
+type bglDictionary struct {
+	*bgl.Dict
+}
+
+// openBGL 打开 BGL 文件
+func openBGL(path string) (Dictionary, error) {
+	dict, err := bgl.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &bglDictionary{Dict: dict}, nil
+}
+
+func (d *bglDictionary) Title() string {
+	if d.Dict.Title != "" {
+		return d.Dict.Title
+	}
+	return d.Name()
+}
+
+func (d *bglDictionary) Description() string {
+	return d.Dict.Description
+}
+
+func (d *bglDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
+
+func (d *bglDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Dict.Resource(path)
+	if os.IsNotExist(err) {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
+
+func (d *bglDictionary) Close() error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package mdx
+
+import "dict-hub/pkg/dictd"
+
+// dictdDictionary dictd 格式字典（.index + .dict[.dz]）
+type dictdDictionary struct {
+	*dictd.Dict
+}
+
+// openDictd 通过 .index 文件打开 dictd 字典
+func openDictd(path string) (Dictionary, error) {
+	dict, err := dictd.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &dictdDictionary{Dict: dict}, nil
+}
+
+func (d *dictdDictionary) Title() string {
+	if d.Dict.Title != "" {
+		return d.Dict.Title
+	}
+	return d.Name()
+}
+
+func (d *dictdDictionary) Description() string {
+	return d.Dict.Description
+}
+
+func (d *dictdDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
@@ -0,0 +1,54 @@
+package mdx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dict-hub/pkg/dsl"
+)
+
+// dslDictionary ABBYY Lingvo DSL 字典（.dsl/.dsl.dz），资源位于 .files.zip
+type dslDictionary struct {
+	*dsl.Dict
+}
+
+// openDSL 打开 DSL 字典并建立词头索引
+func openDSL(path string) (Dictionary, error) {
+	dict, err := dsl.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &dslDictionary{Dict: dict}, nil
+}
+
+func (d *dslDictionary) Name() string {
+	name := filepath.Base(d.Path())
+	if strings.HasSuffix(strings.ToLower(name), ".dz") {
+		name = name[:len(name)-3]
+	}
+	return trimExt(name)
+}
+
+func (d *dslDictionary) Title() string {
+	if d.Dict.Name != "" {
+		return d.Dict.Name
+	}
+	return d.Name()
+}
+
+func (d *dslDictionary) Description() string {
+	return d.Annotation
+}
+
+func (d *dslDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
+
+func (d *dslDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Dict.Resource(path)
+	if os.IsNotExist(err) {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,63 @@
+package mdx
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// quickFingerprintSampleSize 计算快速指纹时从文件头尾各读取的字节数
+const quickFingerprintSampleSize = 64 << 10
+
+// FileFingerprint 根据文件全部内容生成指纹（SHA-256 的前 16 字节），与文件路径和修改时间无关，
+// 内容相同的文件指纹相同，移动或重命名后保持不变。需要读取整个文件，大文件较慢
+func FileFingerprint(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// QuickFingerprint 根据文件大小、修改时间和头尾各 64KB 内容生成指纹，只用于缓存失效（资源 ETag、图片缓存），
+// 不能据此判断两个文件内容相同
+func QuickFingerprint(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, info.Size())
+	binary.Write(h, binary.BigEndian, info.ModTime().UnixNano())
+	if _, err := io.CopyN(h, f, quickFingerprintSampleSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > 2*quickFingerprintSampleSize {
+		if _, err := f.Seek(-quickFingerprintSampleSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	} else if info.Size() > quickFingerprintSampleSize {
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
@@ -0,0 +1,28 @@
+package mdx
+
+import "dict-hub/pkg/glossary"
+
+// glossaryDictionary 简单词汇表（CSV/TSV/JSON/JSONL），文件修改后自动重新加载
+type glossaryDictionary struct {
+	*glossary.Dict
+}
+
+// openGlossary 打开词汇表文件，列映射读取自同名 .glossary.json
+func openGlossary(path string) (Dictionary, error) {
+	dict, err := glossary.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &glossaryDictionary{Dict: dict}, nil
+}
+
+func (d *glossaryDictionary) Title() string {
+	if title := d.Dict.Title(); title != "" {
+		return title
+	}
+	return d.Name()
+}
+
+func (d *glossaryDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
@@ -2,62 +2,162 @@ package mdx
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
-	"dict-hub/pkg/mdict"
+	"dict-hub/pkg/glossary"
+	"dict-hub/pkg/yomitan"
 )
 
 var (
-	ErrDictNotFound     = errors.New("dictionary not found")
-	ErrResourceNotFound = errors.New("resource not found")
-	ErrNoMDD            = errors.New("no MDD resource file associated")
-	ErrWordNotFound     = errors.New("word not found")
+	ErrDictNotFound      = errors.New("dictionary not found")
+	ErrResourceNotFound  = errors.New("resource not found")
+	ErrNoMDD             = errors.New("no MDD resource file associated")
+	ErrWordNotFound      = errors.New("word not found")
+	ErrUnsupportedFormat = errors.New("unsupported dictionary format")
 )
 
+// closeDelay 热替换或卸载后延迟关闭旧字典，让已取出字典、正在进行的查询和资源读取完成
+const closeDelay = 30 * time.Second
+
 // dictEntry 内部字典条目
 type dictEntry struct {
-	id   uint
-	mdx  *mdict.Mdict
-	mdd  *mdict.Mdict // 可选的 MDD 资源文件
-	path string
+	id     uint
+	dict   Dictionary
+	format string
+	path   string
+	packs  []uint // 附加的资源包，字典自身找不到资源时依次查找
+
+	fingerprint  string // 入口文件指纹，虚拟字典为空
+	displayTitle string // 用户设置的显示标题，为空时使用字典自身的标题
+}
+
+// title 返回字典的显示标题
+func (e *dictEntry) title() string {
+	if e.displayTitle != "" {
+		return e.displayTitle
+	}
+	return e.dict.Title()
 }
 
 // manager DictManager 实现
 type manager struct {
-	mu     sync.RWMutex
-	dicts  map[uint]*dictEntry
-	nextID uint
+	mu      sync.RWMutex
+	dicts   map[uint]*dictEntry
+	nextID  uint
+	formats []Format
 }
 
-// NewManager 创建新的字典管理器
+// NewManager 创建新的字典管理器，内置 MDX、MDD 资源包、StarDict、DSL、BGL、XDXF、dictd、Yomitan、ZIM 和词汇表格式
 func NewManager() DictManager {
-	return &manager{
+	m := &manager{
 		dicts:  make(map[uint]*dictEntry),
 		nextID: 1,
 	}
+	m.RegisterFormat(Format{Name: "mdx", Extensions: []string{".mdx"}, Open: openMdict})
+	m.RegisterFormat(Format{Name: FormatResourcePack, Extensions: []string{".mdd"}, Detect: IsResourcePack, Open: openResourcePack})
+	m.RegisterFormat(Format{Name: "stardict", Extensions: []string{".ifo"}, Open: openStardict})
+	m.RegisterFormat(Format{Name: "dsl", Extensions: []string{".dsl", ".dsl.dz"}, Open: openDSL})
+	m.RegisterFormat(Format{Name: "bgl", Extensions: []string{".bgl"}, Open: openBGL})
+	m.RegisterFormat(Format{Name: "xdxf", Extensions: []string{".xdxf", ".xdx", ".xdxf.gz", ".xdx.gz", ".xdxf.dz", ".xdx.dz"}, Open: openXDXF})
+	m.RegisterFormat(Format{Name: "dictd", Extensions: []string{".index"}, Open: openDictd})
+	m.RegisterFormat(Format{Name: "yomitan", Extensions: []string{".zip"}, Detect: yomitan.IsArchive, Open: openYomitan})
+	m.RegisterFormat(Format{Name: "zim", Extensions: []string{".zim"}, Open: openZIM})
+	m.RegisterFormat(Format{Name: "glossary", Extensions: []string{".csv", ".tsv", ".json", ".jsonl"}, Detect: glossary.IsGlossary, Open: openGlossary})
+	return m
+}
+
+// RegisterFormat 注册字典格式
+func (m *manager) RegisterFormat(format Format) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.formats = append(m.formats, format)
+}
+
+// findFormat 根据文件后缀（及格式的 Detect）查找字典格式
+func (m *manager) findFormat(path string) (Format, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	name := strings.ToLower(path)
+	for _, format := range m.formats {
+		// 无后缀的格式（如数据库支持的虚拟字典）仅由 Detect 判断
+		if len(format.Extensions) == 0 && format.Detect != nil && format.Detect(path) {
+			return format, true
+		}
+		for _, ext := range format.Extensions {
+			if strings.HasSuffix(name, ext) && (format.Detect == nil || format.Detect(path)) {
+				return format, true
+			}
+		}
+	}
+	return Format{}, false
 }
 
-// LoadDict 加载单个 MDX 字典文件
+// IsSupported 判断文件是否为已注册格式的字典入口文件
+func (m *manager) IsSupported(path string) bool {
+	_, ok := m.findFormat(path)
+	return ok
+}
+
+// SupportedExtensions 返回已注册格式的入口文件后缀（按注册顺序，去重）
+func (m *manager) SupportedExtensions() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var exts []string
+	for _, format := range m.formats {
+		for _, ext := range format.Extensions {
+			if !slices.Contains(exts, ext) {
+				exts = append(exts, ext)
+			}
+		}
+	}
+	return exts
+}
+
+// openDict 打开字典文件，解析器遇到损坏文件时的 panic 转换为错误，
+// 后台加载（启动、目录监视）中一个损坏的字典不会导致整个服务退出
+func openDict(format Format, path string) (dict Dictionary, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			dict, err = nil, fmt.Errorf("%s: corrupt %s file: %v", filepath.Base(path), format.Name, r)
+		}
+	}()
+	return format.Open(path)
+}
+
+// LoadDict 加载单个字典文件
 func (m *manager) LoadDict(path string) (uint, error) {
+	format, ok := m.findFormat(path)
+	if !ok {
+		return 0, ErrUnsupportedFormat
+	}
+
 	// 验证文件存在
-	if _, err := os.Stat(path); err != nil {
-		return 0, err
+	if !IsVirtualPath(path) {
+		if _, err := os.Stat(path); err != nil {
+			return 0, err
+		}
 	}
 
-	// 创建 MDX 实例
-	mdx, err := mdict.New(path)
+	dict, err := openDict(format, path)
 	if err != nil {
 		return 0, err
 	}
 
-	// 构建索引
-	if err := mdx.BuildIndex(); err != nil {
-		return 0, err
+	var fingerprint string
+	if !IsVirtualPath(path) {
+		fingerprint, _ = QuickFingerprint(path)
 	}
 
 	m.mu.Lock()
@@ -66,28 +166,62 @@ func (m *manager) LoadDict(path string) (uint, error) {
 	id := m.nextID
 	m.nextID++
 
-	entry := &dictEntry{
-		id:   id,
-		mdx:  mdx,
-		path: path,
+	m.dicts[id] = &dictEntry{
+		id:          id,
+		dict:        dict,
+		format:      format.Name,
+		path:        path,
+		fingerprint: fingerprint,
 	}
+	return id, nil
+}
 
-	// 尝试加载同名 MDD 文件
-	mddPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mdd"
-	if _, err := os.Stat(mddPath); err == nil {
-		mdd, err := mdict.New(mddPath)
-		if err == nil {
-			if err := mdd.BuildIndex(); err == nil {
-				entry.mdd = mdd
-			}
-		}
+// Reload 重新打开字典文件并原子替换，运行时 ID 和附加的资源包保持不变；
+// 新文件无法打开（如仍在复制中）时返回错误并保留原字典
+func (m *manager) Reload(dictID uint) error {
+	m.mu.RLock()
+	entry, ok := m.dicts[dictID]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrDictNotFound
 	}
 
-	m.dicts[id] = entry
-	return id, nil
+	format, ok := m.findFormat(entry.path)
+	if !ok {
+		return ErrUnsupportedFormat
+	}
+	dict, err := openDict(format, entry.path)
+	if err != nil {
+		return err
+	}
+	var fingerprint string
+	if !IsVirtualPath(entry.path) {
+		fingerprint, _ = QuickFingerprint(entry.path)
+	}
+
+	m.mu.Lock()
+	if m.dicts[dictID] != entry {
+		// 打开期间字典已被卸载或替换
+		m.mu.Unlock()
+		dict.Close()
+		return ErrDictNotFound
+	}
+	m.dicts[dictID] = &dictEntry{
+		id:           dictID,
+		dict:         dict,
+		format:       format.Name,
+		path:         entry.path,
+		packs:        entry.packs,
+		fingerprint:  fingerprint,
+		displayTitle: entry.displayTitle,
+	}
+	m.mu.Unlock()
+
+	time.AfterFunc(closeDelay, func() { entry.dict.Close() })
+	return nil
 }
 
-// LoadAll 扫描目录加载所有 MDX 字典
+// LoadAll 扫描目录加载所有支持格式的字典
 func (m *manager) LoadAll(dir string) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -99,8 +233,8 @@ func (m *manager) LoadAll(dir string) error {
 		if entry.IsDir() {
 			continue
 		}
-		if strings.ToLower(filepath.Ext(entry.Name())) == ".mdx" {
-			fullPath := filepath.Join(dir, entry.Name())
+		fullPath := filepath.Join(dir, entry.Name())
+		if m.IsSupported(fullPath) {
 			if _, err := m.LoadDict(fullPath); err != nil {
 				loadErrors = append(loadErrors, err)
 			}
@@ -113,6 +247,18 @@ func (m *manager) LoadAll(dir string) error {
 	return nil
 }
 
+// GetDictionary 获取已加载的字典
+func (m *manager) GetDictionary(dictID uint) (Dictionary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.dicts[dictID]
+	if !ok {
+		return nil, ErrDictNotFound
+	}
+	return entry.dict, nil
+}
+
 // Lookup 在指定字典中查询单词
 func (m *manager) Lookup(dictID uint, word string) ([]byte, error) {
 	m.mu.RLock()
@@ -123,7 +269,7 @@ func (m *manager) Lookup(dictID uint, word string) ([]byte, error) {
 		return nil, ErrDictNotFound
 	}
 
-	result, err := entry.mdx.Lookup(word)
+	result, err := entry.dict.Lookup(word)
 	if err != nil {
 		return nil, ErrWordNotFound
 	}
@@ -131,6 +277,16 @@ func (m *manager) Lookup(dictID uint, word string) ([]byte, error) {
 	return result, nil
 }
 
+// loadedIDs 返回全部字典的运行时 ID（按加载顺序），调用方需持有读锁
+func (m *manager) loadedIDs() []uint {
+	ids := make([]uint, 0, len(m.dicts))
+	for id := range m.dicts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
 // Search 跨字典搜索单词
 func (m *manager) Search(word string, dictIDs ...uint) []SearchResult {
 	m.mu.RLock()
@@ -138,11 +294,9 @@ func (m *manager) Search(word string, dictIDs ...uint) []SearchResult {
 
 	var results []SearchResult
 
-	// 如果没有指定字典 ID，则搜索所有字典
+	// 如果没有指定字典 ID，则按加载顺序搜索所有字典
 	if len(dictIDs) == 0 {
-		for id := range m.dicts {
-			dictIDs = append(dictIDs, id)
-		}
+		dictIDs = m.loadedIDs()
 	}
 
 	for _, id := range dictIDs {
@@ -151,15 +305,15 @@ func (m *manager) Search(word string, dictIDs ...uint) []SearchResult {
 			continue
 		}
 
-		result, err := entry.mdx.Lookup(word)
+		result, err := entry.dict.Lookup(word)
 		if err != nil {
 			continue
 		}
 
 		results = append(results, SearchResult{
 			DictID:     id,
-			DictName:   entry.mdx.Name(),
-			DictTitle:  entry.mdx.Title(),
+			DictName:   entry.dict.Name(),
+			DictTitle:  entry.title(),
 			Word:       word,
 			Definition: string(result),
 		})
@@ -168,76 +322,138 @@ func (m *manager) Search(word string, dictIDs ...uint) []SearchResult {
 	return results
 }
 
-// Suggest 跨字典前缀搜索建议
-func (m *manager) Suggest(prefix string, limit int) []SuggestResult {
+// Suggest 前缀搜索建议，dictIDs 为空时查询全部字典
+func (m *manager) Suggest(prefix string, limit int, dictIDs ...uint) []SuggestResult {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	prefix = strings.ToLower(prefix)
 	var results []SuggestResult
 	seen := make(map[string]bool) // 去重
 
-	for _, entry := range m.dicts {
-		keywords := entry.mdx.GetKeyEntries()
-		if keywords == nil {
-			continue
+	// 如果没有指定字典 ID，则查询所有字典
+	entries := make([]*dictEntry, 0, len(m.dicts))
+	if len(dictIDs) == 0 {
+		dictIDs = m.loadedIDs()
+	}
+	for _, id := range dictIDs {
+		if entry, ok := m.dicts[id]; ok {
+			entries = append(entries, entry)
 		}
+	}
 
-		for _, kw := range keywords {
-			if strings.HasPrefix(strings.ToLower(kw.Keyword), prefix) {
-				// 去重：同一个词只返回一次
-				if seen[kw.Keyword] {
-					continue
-				}
-				seen[kw.Keyword] = true
-
-				results = append(results, SuggestResult{
-					Word:      kw.Keyword,
-					DictID:    entry.id,
-					DictTitle: entry.mdx.Title(),
-				})
-				if len(results) >= limit {
-					return results
-				}
+	for _, entry := range entries {
+		for _, word := range entry.dict.Suggest(prefix, limit) {
+			// 去重：同一个词只返回一次
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+
+			results = append(results, SuggestResult{
+				Word:      word,
+				DictID:    entry.id,
+				DictTitle: entry.title(),
+			})
+			if len(results) >= limit {
+				return results
 			}
 		}
 	}
 	return results
 }
 
-// GetResource 获取 MDD 资源文件
+// GetResource 获取字典资源文件，字典自身没有该资源时依次查找附加的资源包
 func (m *manager) GetResource(dictID uint, path string) (io.Reader, error) {
+	content, err := m.OpenResource(dictID, path)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(content.Data), nil
+}
+
+// OpenResource 获取资源内容及所在文件的修改时间
+func (m *manager) OpenResource(dictID uint, path string) (*ResourceContent, error) {
 	m.mu.RLock()
 	entry, ok := m.dicts[dictID]
+	var sources []*dictEntry
+	if ok {
+		sources = append(sources, entry)
+		for _, packID := range entry.packs {
+			if pack, ok := m.dicts[packID]; ok {
+				sources = append(sources, pack)
+			}
+		}
+	}
 	m.mu.RUnlock()
 
 	if !ok {
 		return nil, ErrDictNotFound
 	}
 
-	if entry.mdd == nil {
+	hasProvider := false
+	for _, source := range sources {
+		provider, ok := source.dict.(ResourceProvider)
+		if !ok || !provider.HasResources() {
+			continue
+		}
+		hasProvider = true
+		if data, err := provider.Resource(path); err == nil {
+			return &ResourceContent{Data: data, ModTime: resourceModTime(source)}, nil
+		}
+	}
+	if !hasProvider {
 		return nil, ErrNoMDD
 	}
+	return nil, ErrResourceNotFound
+}
 
-	// 标准化路径：移除前导斜杠
-	path = strings.TrimPrefix(path, "/")
-	path = strings.TrimPrefix(path, "\\")
+// Fingerprint 返回字典的指纹，由入口文件指纹、资源文件修改时间和附加资源包的指纹组成
+func (m *manager) Fingerprint(dictID uint) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	// 尝试多种路径格式查找（MDD 文件中的键可能有不同格式）
-	pathVariants := []string{
-		path,            // 原始路径
-		"\\" + path,     // Windows 风格前缀（MDD 常用格式）
-		"/" + path,      // Unix 风格前缀
+	entry, ok := m.dicts[dictID]
+	if !ok {
+		return "", ErrDictNotFound
 	}
 
-	for _, p := range pathVariants {
-		data, err := entry.mdd.Lookup(p)
-		if err == nil {
-			return bytes.NewReader(data), nil
+	h := sha256.New()
+	sources := []*dictEntry{entry}
+	for _, packID := range entry.packs {
+		if pack, ok := m.dicts[packID]; ok {
+			sources = append(sources, pack)
 		}
 	}
+	for _, source := range sources {
+		fmt.Fprintf(h, "%s|%s|%d\n", source.path, source.fingerprint, resourceModTime(source).UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
 
-	return nil, ErrResourceNotFound
+// SetResourcePacks 设置字典附加的资源包（按查找顺序），传入空列表时清除
+func (m *manager) SetResourcePacks(dictID uint, packIDs []uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.dicts[dictID]
+	if !ok {
+		return ErrDictNotFound
+	}
+	entry.packs = append([]uint(nil), packIDs...)
+	return nil
+}
+
+// SetDisplayTitle 设置字典的显示标题，传入空字符串时恢复字典自身的标题
+func (m *manager) SetDisplayTitle(dictID uint, title string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.dicts[dictID]
+	if !ok {
+		return ErrDictNotFound
+	}
+	entry.displayTitle = title
+	return nil
 }
 
 // ListLoaded 列出已加载的字典
@@ -249,12 +465,16 @@ func (m *manager) ListLoaded() []DictInfo {
 	for _, entry := range m.dicts {
 		info := DictInfo{
 			ID:          entry.id,
-			Name:        entry.mdx.Name(),
-			Title:       entry.mdx.Title(),
-			Description: entry.mdx.Description(),
+			Name:        entry.dict.Name(),
+			Title:       entry.title(),
+			Description: entry.dict.Description(),
 			Path:        entry.path,
-			HasMDD:      entry.mdd != nil,
-			WordCount:   entry.mdx.WordCount(),
+			Format:      entry.format,
+			HasMDD:      hasResources(entry.dict),
+			WordCount:   entry.dict.WordCount(),
+		}
+		if provider, ok := entry.dict.(ResourceVolumeProvider); ok {
+			info.MDDVolumes, info.ResourceSize = provider.ResourceVolumes()
 		}
 		infos = append(infos, info)
 	}
@@ -266,10 +486,46 @@ func (m *manager) Unload(dictID uint) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.dicts[dictID]; !ok {
+	entry, ok := m.dicts[dictID]
+	if !ok {
 		return ErrDictNotFound
 	}
 
+	// Lookup、OpenResource 在释放锁之后才使用字典，与 Reload 一样延迟关闭
 	delete(m.dicts, dictID)
+	time.AfterFunc(closeDelay, func() { entry.dict.Close() })
 	return nil
 }
+
+// hasResources 判断字典是否提供资源文件
+func hasResources(dict Dictionary) bool {
+	provider, ok := dict.(ResourceProvider)
+	return ok && provider.HasResources()
+}
+
+// resourceModTime 返回字典资源所在文件的修改时间
+func resourceModTime(entry *dictEntry) time.Time {
+	if provider, ok := entry.dict.(ResourceModTimeProvider); ok {
+		if t := provider.ResourceModTime(); !t.IsZero() {
+			return t
+		}
+	}
+	if IsVirtualPath(entry.path) {
+		return time.Time{}
+	}
+	if info, err := os.Stat(entry.path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// IsVirtualPath 判断路径是否指向非文件的虚拟字典（如 custom://）
+func IsVirtualPath(path string) bool {
+	return strings.Contains(path, "://")
+}
+
+// trimExt 返回不带扩展名的文件名
+func trimExt(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,203 @@
+package mdx
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"dict-hub/pkg/mdict"
+)
+
+// FormatResourcePack 独立 MDD 资源包的格式名称
+const FormatResourcePack = "mdd"
+
+// mddVolumeSuffix 匹配 MDD 分卷文件名中的编号部分（name.1.mdd）
+var mddVolumeSuffix = regexp.MustCompile(`^(.+)\.\d+$`)
+
+// mddSet 一组按顺序查找的 MDD 分卷，由 MDX 字典和独立资源包共用
+type mddSet struct {
+	mdds    []*mdict.Mdict // 按 name.mdd、name.1.mdd、name.2.mdd… 的顺序
+	mddSize int64          // 全部分卷的文件大小
+	modTime time.Time      // 最近修改的分卷的修改时间
+}
+
+// openMDDSet 打开 MDD 分卷，无法解析的分卷被跳过
+func openMDDSet(paths []string) mddSet {
+	var set mddSet
+	for _, mddPath := range paths {
+		mdd, err := mdict.New(mddPath)
+		if err != nil {
+			continue
+		}
+		if err := mdd.BuildIndex(); err != nil {
+			continue
+		}
+		set.mdds = append(set.mdds, mdd)
+		if info, err := os.Stat(mddPath); err == nil {
+			set.mddSize += info.Size()
+			if info.ModTime().After(set.modTime) {
+				set.modTime = info.ModTime()
+			}
+		}
+	}
+	return set
+}
+
+// Resource 按分卷顺序从 MDD 查找资源，尝试多种路径格式（MDD 文件中的键可能有不同格式）
+func (s *mddSet) Resource(path string) ([]byte, error) {
+	if len(s.mdds) == 0 {
+		return nil, ErrNoMDD
+	}
+
+	// 标准化路径：移除前导斜杠
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "\\")
+
+	pathVariants := []string{
+		path,        // 原始路径
+		"\\" + path, // Windows 风格前缀（MDD 常用格式）
+		"/" + path,  // Unix 风格前缀
+	}
+	// 子目录中的资源：MDD 键使用反斜杠分隔（\img\a.png）
+	if strings.Contains(path, "/") {
+		pathVariants = append(pathVariants, mdict.NormalizeResourceKey(path))
+	}
+
+	for _, mdd := range s.mdds {
+		for _, p := range pathVariants {
+			if data, err := mdd.Lookup(p); err == nil {
+				return data, nil
+			}
+		}
+	}
+	return nil, ErrResourceNotFound
+}
+
+// IterateResources 顺序遍历 MDD 的记录块，每个块只解压一次
+func (s *mddSet) IterateResources(fn func(path string, data []byte) error) error {
+	return s.ExtractResources(nil, fn)
+}
+
+// ListResources 根据 MDD 索引列出全部资源，不读取记录块；多个分卷中的同名资源以靠前的为准
+func (s *mddSet) ListResources() []ResourceInfo {
+	var infos []ResourceInfo
+	seen := make(map[string]bool)
+	for _, mdd := range s.mdds {
+		for _, r := range mdd.Resources() {
+			key := strings.ToLower(r.Path)
+			if r.Path == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			infos = append(infos, ResourceInfo{Path: r.Path, Size: r.Size})
+		}
+	}
+	return infos
+}
+
+// ExtractResources 依次读取各分卷中 match 接受的 MDD 资源，match 为 nil 时读取全部
+func (s *mddSet) ExtractResources(match func(path string) bool, fn func(path string, data []byte) error) error {
+	seen := make(map[string]bool)
+	for _, mdd := range s.mdds {
+		err := mdd.IterateMatching(func(key string) bool {
+			path := mdict.ResourcePath(key)
+			return path != "" && !seen[strings.ToLower(path)] && (match == nil || match(path))
+		}, func(key string, record []byte) error {
+			path := mdict.ResourcePath(key)
+			seen[strings.ToLower(path)] = true
+			return fn(path, record)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResourceVolumes 返回 MDD 分卷数量和总大小
+func (s *mddSet) ResourceVolumes() (int, int64) {
+	return len(s.mdds), s.mddSize
+}
+
+// ResourceModTime 返回 MDD 分卷中最新的修改时间
+func (s *mddSet) ResourceModTime() time.Time {
+	return s.modTime
+}
+
+func (s *mddSet) HasResources() bool {
+	return len(s.mdds) > 0
+}
+
+func (s *mddSet) Close() error {
+	for _, mdd := range s.mdds {
+		mdd.Close()
+	}
+	return nil
+}
+
+// IsResourcePack 判断 MDD 文件是否为独立资源包：
+// 没有同名 MDX，也不是其他 MDD 或 MDX 的分卷（name.1.mdd）
+func IsResourcePack(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".mdd") {
+		return false
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if fileExists(base + ".mdx") {
+		return false
+	}
+	if m := mddVolumeSuffix.FindStringSubmatch(base); m != nil {
+		if fileExists(m[1]+".mdx") || fileExists(m[1]+".mdd") {
+			return false
+		}
+	}
+	return true
+}
+
+// resourcePack 独立的 MDD 资源包（发音库、共享字体和样式等），没有词条，只提供资源
+type resourcePack struct {
+	name        string
+	title       string
+	description string
+	mddSet
+}
+
+// openResourcePack 打开独立 MDD 资源包及其分卷
+func openResourcePack(path string) (Dictionary, error) {
+	set := openMDDSet(MDDVolumes(path))
+	if !set.HasResources() {
+		return nil, ErrNoMDD
+	}
+
+	return &resourcePack{
+		name:        trimExt(path),
+		title:       set.mdds[0].Title(),
+		description: set.mdds[0].Description(),
+		mddSet:      set,
+	}, nil
+}
+
+func (p *resourcePack) Name() string        { return p.name }
+func (p *resourcePack) Title() string       { return p.title }
+func (p *resourcePack) Description() string { return p.description }
+func (p *resourcePack) WordCount() int64    { return 0 }
+
+// Lookup 资源包没有词条
+func (p *resourcePack) Lookup(word string) ([]byte, error) {
+	return nil, ErrWordNotFound
+}
+
+func (p *resourcePack) Suggest(prefix string, limit int) []string {
+	return nil
+}
+
+func (p *resourcePack) Iterate(fn func(key string, definition []byte) error) error {
+	return nil
+}
+
+// fileExists 判断文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
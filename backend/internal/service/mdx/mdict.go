@@ -0,0 +1,87 @@
+package mdx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dict-hub/pkg/mdict"
+)
+
+// mdictDictionary MDX 字典，附带可选的同名 MDD 资源文件（可分卷）
+type mdictDictionary struct {
+	mdx *mdict.Mdict
+	mddSet
+}
+
+// MDDVolumes 返回 MDX 对应的 MDD 分卷路径：name.mdd、name.1.mdd、name.2.mdd…，
+// 编号从 1 开始连续查找，遇到缺失的编号即停止
+func MDDVolumes(mdxPath string) []string {
+	base := strings.TrimSuffix(mdxPath, filepath.Ext(mdxPath))
+
+	var volumes []string
+	if _, err := os.Stat(base + ".mdd"); err == nil {
+		volumes = append(volumes, base+".mdd")
+	}
+	for i := 1; ; i++ {
+		path := fmt.Sprintf("%s.%d.mdd", base, i)
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		volumes = append(volumes, path)
+	}
+	return volumes
+}
+
+// openMdict 打开 MDX 字典并尝试加载同名 MDD 文件及其分卷
+func openMdict(path string) (Dictionary, error) {
+	mdx, err := mdict.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建索引
+	if err := mdx.BuildIndex(); err != nil {
+		return nil, err
+	}
+
+	return &mdictDictionary{mdx: mdx, mddSet: openMDDSet(MDDVolumes(path))}, nil
+}
+
+func (d *mdictDictionary) Name() string        { return d.mdx.Name() }
+func (d *mdictDictionary) Title() string       { return d.mdx.Title() }
+func (d *mdictDictionary) Description() string { return d.mdx.Description() }
+func (d *mdictDictionary) WordCount() int64    { return d.mdx.WordCount() }
+
+func (d *mdictDictionary) Lookup(word string) ([]byte, error) {
+	return d.mdx.Lookup(word)
+}
+
+// Suggest 顺序扫描全部词条，不依赖 MDX 文件的键排序规则
+func (d *mdictDictionary) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(prefix)
+	var results []string
+	seen := make(map[string]bool)
+
+	for _, kw := range d.mdx.GetKeyEntries() {
+		if !strings.HasPrefix(strings.ToLower(kw.Keyword), prefix) || seen[kw.Keyword] {
+			continue
+		}
+		seen[kw.Keyword] = true
+		results = append(results, kw.Keyword)
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+func (d *mdictDictionary) Iterate(fn func(key string, definition []byte) error) error {
+	return d.mdx.Iterate(fn)
+}
+
+func (d *mdictDictionary) Close() error {
+	d.mddSet.Close()
+	return d.mdx.Close()
+}
@@ -0,0 +1,48 @@
+package mdx
+
+import (
+	"os"
+
+	"dict-hub/pkg/stardict"
+)
+
+// stardictDictionary StarDict 字典（.ifo/.idx/.dict[.dz]/.syn），资源位于 res 目录
+type stardictDictionary struct {
+	*stardict.Dict
+}
+
+// openStardict 通过 .ifo 文件打开 StarDict 字典
+func openStardict(path string) (Dictionary, error) {
+	dict, err := stardict.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &stardictDictionary{Dict: dict}, nil
+}
+
+func (d *stardictDictionary) Name() string {
+	return trimExt(d.Path())
+}
+
+func (d *stardictDictionary) Title() string {
+	if d.BookName != "" {
+		return d.BookName
+	}
+	return d.Name()
+}
+
+func (d *stardictDictionary) Description() string {
+	return d.Info.Description
+}
+
+func (d *stardictDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
+
+func (d *stardictDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Dict.Resource(path)
+	if os.IsNotExist(err) {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
@@ -1,16 +1,22 @@
 package mdx
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // DictInfo 字典元信息
 type DictInfo struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Path        string `json:"path"`
-	HasMDD      bool   `json:"has_mdd"`
-	WordCount   int64  `json:"word_count"`
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Path         string `json:"path"`
+	Format       string `json:"format"`
+	HasMDD       bool   `json:"has_mdd"`
+	MDDVolumes   int    `json:"mdd_volumes"`
+	ResourceSize int64  `json:"resource_size"`
+	WordCount    int64  `json:"word_count"`
 }
 
 // SearchResult 搜索结果
@@ -29,27 +35,152 @@ type SuggestResult struct {
 	DictTitle string `json:"dict_title"`
 }
 
+// Dictionary 与格式无关的字典接口，每种字典格式提供一个实现
+type Dictionary interface {
+	// Name 字典名称（通常为文件名）
+	Name() string
+
+	// Title 字典标题
+	Title() string
+
+	// Description 字典描述
+	Description() string
+
+	// WordCount 词条数量
+	WordCount() int64
+
+	// Lookup 查询单词，返回 HTML 释义
+	Lookup(word string) ([]byte, error)
+
+	// Suggest 前缀搜索建议
+	Suggest(prefix string, limit int) []string
+
+	// Iterate 按顺序遍历所有词条
+	Iterate(fn func(key string, definition []byte) error) error
+
+	// Close 释放字典占用的资源
+	Close() error
+}
+
+// FrequencyProvider 可选接口，由带词频数据的字典实现（如 Yomitan 词频词典）
+type FrequencyProvider interface {
+	// Frequencies 返回单词到词频分值的映射，分值越大越常用
+	Frequencies() map[string]int64
+}
+
+// ResourceProvider 可选接口，由带资源文件（图片、音频、样式等）的字典实现
+type ResourceProvider interface {
+	// Resource 按路径读取资源
+	Resource(path string) ([]byte, error)
+
+	// HasResources 是否有可用的资源
+	HasResources() bool
+}
+
+// ResourceVolumeProvider 可选接口，由资源存放在独立文件（可分卷）中的字典实现（如 MDD）
+type ResourceVolumeProvider interface {
+	// ResourceVolumes 返回资源文件数量和总大小（字节）
+	ResourceVolumes() (count int, size int64)
+}
+
+// ResourceModTimeProvider 可选接口，由资源存放在独立文件中的字典实现（如 MDD），
+// 未实现时以字典入口文件的修改时间作为资源的修改时间
+type ResourceModTimeProvider interface {
+	// ResourceModTime 返回资源文件的最后修改时间
+	ResourceModTime() time.Time
+}
+
+// ResourceContent 资源内容
+type ResourceContent struct {
+	Data    []byte
+	ModTime time.Time // 资源所在文件的修改时间，虚拟字典为零值
+}
+
+// ResourceIterator 可选接口，由可以顺序遍历全部资源的字典实现（如 MDD）
+type ResourceIterator interface {
+	// IterateResources 按存储顺序遍历资源，path 使用 "/" 分隔且不带前导斜杠
+	IterateResources(fn func(path string, data []byte) error) error
+}
+
+// ResourceInfo 资源条目信息
+type ResourceInfo struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ResourceLister 可选接口，由可以列出全部资源的字典实现（如 MDD）
+type ResourceLister interface {
+	// ListResources 返回全部资源的路径和大小，路径格式同 IterateResources
+	ListResources() []ResourceInfo
+
+	// ExtractResources 顺序读取 match 接受的资源，不含匹配资源的记录块不会被解压
+	ExtractResources(match func(path string) bool, fn func(path string, data []byte) error) error
+}
+
+// Format 字典格式描述
+type Format struct {
+	// Name 格式名称，如 "mdx"、"stardict"
+	Name string
+
+	// Extensions 入口文件后缀（小写），如 ".mdx"、".ifo"
+	Extensions []string
+
+	// Detect 可选，后缀匹配后进一步检查文件内容，用于 ".zip" 这类通用后缀；
+	// 没有 Extensions 时单独由 Detect 判断（如 custom:// 虚拟字典）
+	Detect func(path string) bool
+
+	// Open 打开字典文件
+	Open func(path string) (Dictionary, error)
+}
+
 // DictManager 字典管理器接口
 type DictManager interface {
-	// LoadDict 加载单个 MDX 字典文件
+	// RegisterFormat 注册字典格式
+	RegisterFormat(format Format)
+
+	// IsSupported 判断文件是否为已注册格式的字典入口文件
+	IsSupported(path string) bool
+
+	// SupportedExtensions 返回已注册格式的入口文件后缀（按注册顺序）
+	SupportedExtensions() []string
+
+	// LoadDict 加载单个字典文件
 	LoadDict(path string) (uint, error)
 
-	// LoadAll 扫描目录加载所有 MDX 字典
+	// Reload 重新打开字典文件并原子替换（文件被覆盖后调用），运行时 ID 不变
+	Reload(dictID uint) error
+
+	// LoadAll 扫描目录加载所有支持格式的字典
 	LoadAll(dir string) error
 
+	// GetDictionary 获取已加载的字典
+	GetDictionary(dictID uint) (Dictionary, error)
+
 	// Lookup 在指定字典中查询单词
 	Lookup(dictID uint, word string) ([]byte, error)
 
 	// Search 跨字典搜索单词
 	Search(word string, dictIDs ...uint) []SearchResult
 
-	// Suggest 前缀搜索建议
-	Suggest(prefix string, limit int) []SuggestResult
+	// Suggest 前缀搜索建议，可以限定字典（按 dictIDs 的顺序查询）
+	Suggest(prefix string, limit int, dictIDs ...uint) []SuggestResult
 
-	// GetResource 获取 MDD 资源文件
+	// GetResource 获取字典资源文件（MDD 或资源目录），找不到时回退到附加的资源包
 	GetResource(dictID uint, path string) (io.Reader, error)
 
-	// ListLoaded 列出已加载的字典
+	// OpenResource 获取资源内容及所在文件的修改时间，查找规则同 GetResource
+	OpenResource(dictID uint, path string) (*ResourceContent, error)
+
+	// Fingerprint 返回字典指纹，字典文件、资源文件或附加的资源包变化后随之变化
+	Fingerprint(dictID uint) (string, error)
+
+	// SetResourcePacks 设置字典附加的资源包（独立 MDD），按顺序作为资源查找的回退
+	SetResourcePacks(dictID uint, packIDs []uint) error
+
+	// SetDisplayTitle 设置字典的显示标题（搜索结果、建议和字典列表中的标题），为空时使用字典自身的标题
+	SetDisplayTitle(dictID uint, title string) error
+
+	// ListLoaded 列出已加载的字典，Title 为显示标题
 	ListLoaded() []DictInfo
 
 	// Unload 卸载指定字典
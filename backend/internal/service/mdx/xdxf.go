@@ -0,0 +1,44 @@
+package mdx
+
+import (
+	"os"
+
+	"dict-hub/pkg/xdxf"
+)
+
+// xdxfDictionary XDXF 字典（.xdxf/.xdx，可 gzip 压缩），资源位于 res 目录
+type xdxfDictionary struct {
+	*xdxf.Dict
+}
+
+// openXDXF 打开 XDXF 字典并建立词条偏移索引
+func openXDXF(path string) (Dictionary, error) {
+	dict, err := xdxf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &xdxfDictionary{Dict: dict}, nil
+}
+
+func (d *xdxfDictionary) Title() string {
+	if d.Dict.Title != "" {
+		return d.Dict.Title
+	}
+	return d.Name()
+}
+
+func (d *xdxfDictionary) Description() string {
+	return d.Dict.Description
+}
+
+func (d *xdxfDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
+
+func (d *xdxfDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Dict.Resource(path)
+	if os.IsNotExist(err) {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
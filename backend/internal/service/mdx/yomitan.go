@@ -0,0 +1,44 @@
+package mdx
+
+import (
+	"os"
+
+	"dict-hub/pkg/yomitan"
+)
+
+// yomitanDictionary Yomitan/Yomichan 词典压缩包（.zip），词库在打开时载入内存
+type yomitanDictionary struct {
+	*yomitan.Dict
+}
+
+// openYomitan 打开 Yomitan 词典压缩包
+func openYomitan(path string) (Dictionary, error) {
+	dict, err := yomitan.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &yomitanDictionary{Dict: dict}, nil
+}
+
+func (d *yomitanDictionary) Title() string {
+	if d.Dict.Title != "" {
+		return d.Dict.Title
+	}
+	return d.Name()
+}
+
+func (d *yomitanDictionary) Description() string {
+	return d.Dict.Description
+}
+
+func (d *yomitanDictionary) WordCount() int64 {
+	return int64(d.Len())
+}
+
+func (d *yomitanDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Dict.Resource(path)
+	if os.IsNotExist(err) {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
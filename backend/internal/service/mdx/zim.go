@@ -0,0 +1,44 @@
+package mdx
+
+import "dict-hub/pkg/zim"
+
+// zimDictionary Kiwix ZIM 离线百科（.zim），词条为文章标题，图片等资源通过资源路由访问
+type zimDictionary struct {
+	*zim.Archive
+}
+
+// openZIM 打开 ZIM 文件
+func openZIM(path string) (Dictionary, error) {
+	archive, err := zim.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &zimDictionary{Archive: archive}, nil
+}
+
+func (d *zimDictionary) Title() string {
+	if title := d.Metadata("Title"); title != "" {
+		return title
+	}
+	return d.Name()
+}
+
+func (d *zimDictionary) Description() string {
+	return d.Metadata("Description")
+}
+
+func (d *zimDictionary) WordCount() int64 {
+	return d.ArticleCount()
+}
+
+func (d *zimDictionary) Resource(path string) ([]byte, error) {
+	data, err := d.Archive.Resource(path)
+	if err == zim.ErrNotFound {
+		return nil, ErrResourceNotFound
+	}
+	return data, err
+}
+
+func (d *zimDictionary) HasResources() bool {
+	return true
+}
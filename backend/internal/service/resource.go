@@ -0,0 +1,345 @@
+package service
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"dict-hub/internal/service/mdx"
+)
+
+var (
+	ErrDictNotLoaded           = errors.New("dictionary is not loaded")
+	ErrResourceListUnsupported = errors.New("dictionary has no browsable resources (MDD)")
+	ErrNoMatchingResources     = errors.New("no resources match the filter")
+	ErrInvalidResourcePattern  = errors.New("invalid resource pattern")
+)
+
+const (
+	defaultResourcePageSize = 100
+	maxResourcePageSize     = 1000
+)
+
+// resourceMIMETypes 词典资源中常见、但系统 MIME 表可能缺失的类型
+var resourceMIMETypes = map[string]string{
+	".mp3":   "audio/mpeg",
+	".wav":   "audio/wav",
+	".ogg":   "audio/ogg",
+	".oga":   "audio/ogg",
+	".spx":   "audio/ogg",
+	".opus":  "audio/opus",
+	".m4a":   "audio/mp4",
+	".aac":   "audio/aac",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+	".bmp":   "image/bmp",
+	".tif":   "image/tiff",
+	".tiff":  "image/tiff",
+	".ico":   "image/x-icon",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".txt":   "text/plain; charset=utf-8",
+}
+
+// ResourceFile 资源文件信息
+type ResourceFile struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	MIMEType string `json:"mime_type"`
+}
+
+// ResourceDir 资源目录信息（包含所有子目录中的文件）
+type ResourceDir struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	FileCount int    `json:"file_count"`
+	Size      int64  `json:"size"`
+}
+
+// ResourceListing 资源列表
+type ResourceListing struct {
+	Dir       string         `json:"dir"`
+	Prefix    string         `json:"prefix,omitempty"`
+	Dirs      []ResourceDir  `json:"dirs"`
+	Files     []ResourceFile `json:"files"`
+	Total     int            `json:"total"`      // 匹配的文件数（分页前）
+	TotalSize int64          `json:"total_size"` // 匹配文件的总大小
+	Page      int            `json:"page"`
+	PageSize  int            `json:"page_size"`
+}
+
+// ResourceListParams 资源列表参数
+// 指定 Prefix 时返回所有层级中路径以 Prefix 开头的文件；否则列出 Dir 下的子目录和文件
+type ResourceListParams struct {
+	Dir      string
+	Prefix   string
+	Page     int
+	PageSize int
+}
+
+// ResourceFilter 资源提取过滤条件，所有条件同时满足才会被提取，全部为空时提取全部资源
+type ResourceFilter struct {
+	Dir     string   // 目录（包含子目录）
+	Prefix  string   // 路径前缀（不区分大小写）
+	Pattern string   // 通配符，匹配完整路径或文件名，如 "*.png"
+	Exts    []string // 扩展名，如 ".mp3"
+}
+
+// Match 判断资源路径是否满足过滤条件
+func (f ResourceFilter) Match(p string) bool {
+	lower := strings.ToLower(p)
+	if dir := cleanResourceDir(f.Dir); dir != "" && !strings.HasPrefix(lower, strings.ToLower(dir)+"/") {
+		return false
+	}
+	if f.Prefix != "" && !strings.HasPrefix(lower, strings.ToLower(strings.TrimLeft(f.Prefix, "/"))) {
+		return false
+	}
+	if f.Pattern != "" {
+		pattern := strings.ToLower(f.Pattern)
+		full, _ := path.Match(pattern, lower)
+		base, _ := path.Match(pattern, path.Base(lower))
+		if !full && !base {
+			return false
+		}
+	}
+	if len(f.Exts) > 0 {
+		ext := path.Ext(lower)
+		matched := false
+		for _, e := range f.Exts {
+			e = strings.ToLower(strings.TrimSpace(e))
+			if e != "" && !strings.HasPrefix(e, ".") {
+				e = "." + e
+			}
+			if e == ext {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ResourceService 字典资源（MDD）浏览与提取服务
+type ResourceService struct {
+	mdxManager    mdx.DictManager
+	dictSourceSvc *DictSourceService
+}
+
+// NewResourceService 创建资源浏览服务
+func NewResourceService(mdxManager mdx.DictManager, dictSourceSvc *DictSourceService) *ResourceService {
+	return &ResourceService{
+		mdxManager:    mdxManager,
+		dictSourceSvc: dictSourceSvc,
+	}
+}
+
+// lister 获取字典的资源列表接口
+func (s *ResourceService) lister(dictSourceID uint) (mdx.ResourceLister, error) {
+	if _, err := s.dictSourceSvc.GetByID(dictSourceID); err != nil {
+		return nil, err
+	}
+	runtimeID, ok := s.dictSourceSvc.GetRuntimeID(dictSourceID)
+	if !ok {
+		return nil, ErrDictNotLoaded
+	}
+	dict, err := s.mdxManager.GetDictionary(runtimeID)
+	if err != nil {
+		return nil, ErrDictNotLoaded
+	}
+	lister, ok := dict.(mdx.ResourceLister)
+	if !ok {
+		return nil, ErrResourceListUnsupported
+	}
+	if provider, ok := dict.(mdx.ResourceProvider); ok && !provider.HasResources() {
+		return nil, ErrResourceListUnsupported
+	}
+	return lister, nil
+}
+
+// List 列出字典资源
+func (s *ResourceService) List(dictSourceID uint, params ResourceListParams) (*ResourceListing, error) {
+	lister, err := s.lister(dictSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = defaultResourcePageSize
+	}
+	if params.PageSize > maxResourcePageSize {
+		params.PageSize = maxResourcePageSize
+	}
+
+	listing := &ResourceListing{
+		Dir:      cleanResourceDir(params.Dir),
+		Prefix:   params.Prefix,
+		Dirs:     make([]ResourceDir, 0),
+		Page:     params.Page,
+		PageSize: params.PageSize,
+	}
+
+	var files []ResourceFile
+	if params.Prefix != "" {
+		// 前缀模式：所有层级的匹配文件
+		filter := ResourceFilter{Dir: listing.Dir, Prefix: params.Prefix}
+		for _, r := range lister.ListResources() {
+			if filter.Match(r.Path) {
+				files = append(files, newResourceFile(r))
+			}
+		}
+	} else {
+		// 目录模式：当前目录下的文件和直接子目录
+		dirPrefix := ""
+		if listing.Dir != "" {
+			dirPrefix = strings.ToLower(listing.Dir) + "/"
+		}
+		dirs := make(map[string]*ResourceDir)
+		for _, r := range lister.ListResources() {
+			if !strings.HasPrefix(strings.ToLower(r.Path), dirPrefix) {
+				continue
+			}
+			rest := r.Path[len(dirPrefix):]
+			if name, _, isDir := strings.Cut(rest, "/"); isDir {
+				key := strings.ToLower(name)
+				dir, ok := dirs[key]
+				if !ok {
+					dir = &ResourceDir{Name: name, Path: r.Path[:len(dirPrefix)+len(name)]}
+					dirs[key] = dir
+				}
+				dir.FileCount++
+				dir.Size += r.Size
+				continue
+			}
+			files = append(files, newResourceFile(r))
+		}
+		for _, dir := range dirs {
+			listing.Dirs = append(listing.Dirs, *dir)
+		}
+		sort.Slice(listing.Dirs, func(i, j int) bool {
+			return strings.ToLower(listing.Dirs[i].Name) < strings.ToLower(listing.Dirs[j].Name)
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return strings.ToLower(files[i].Path) < strings.ToLower(files[j].Path)
+	})
+	listing.Total = len(files)
+	for _, f := range files {
+		listing.TotalSize += f.Size
+	}
+
+	start := (params.Page - 1) * params.PageSize
+	if start > len(files) {
+		start = len(files)
+	}
+	end := start + params.PageSize
+	if end > len(files) {
+		end = len(files)
+	}
+	listing.Files = files[start:end]
+	return listing, nil
+}
+
+// CheckExtract 检查提取条件并返回匹配的资源数量，在开始写出压缩包之前调用
+func (s *ResourceService) CheckExtract(dictSourceID uint, filter ResourceFilter) (int, error) {
+	if filter.Pattern != "" {
+		if _, err := path.Match(filter.Pattern, ""); err != nil {
+			return 0, ErrInvalidResourcePattern
+		}
+	}
+	lister, err := s.lister(dictSourceID)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, r := range lister.ListResources() {
+		if filter.Match(r.Path) {
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, ErrNoMatchingResources
+	}
+	return count, nil
+}
+
+// Extract 将匹配的资源按原路径写入 zip 压缩包
+func (s *ResourceService) Extract(dictSourceID uint, filter ResourceFilter, w io.Writer) error {
+	lister, err := s.lister(dictSourceID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	err = lister.ExtractResources(filter.Match, func(p string, data []byte) error {
+		// 拒绝跳出压缩包根目录的路径
+		clean := path.Clean(p)
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     clean,
+			Method:   zip.Deflate,
+			Modified: now,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ResourceMIMEType 根据扩展名返回资源的 MIME 类型，未知时返回 application/octet-stream
+func ResourceMIMEType(p string) string {
+	ext := strings.ToLower(path.Ext(p))
+	if t, ok := resourceMIMETypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// newResourceFile 由资源条目生成文件信息
+func newResourceFile(r mdx.ResourceInfo) ResourceFile {
+	return ResourceFile{
+		Path:     r.Path,
+		Name:     path.Base(r.Path),
+		Size:     r.Size,
+		MIMEType: ResourceMIMEType(r.Path),
+	}
+}
+
+// cleanResourceDir 规范化目录参数：使用 "/" 分隔，去掉首尾斜杠
+func cleanResourceDir(dir string) string {
+	dir = strings.Trim(strings.ReplaceAll(dir, `\`, "/"), "/")
+	if dir == "" {
+		return ""
+	}
+	dir = path.Clean(dir)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
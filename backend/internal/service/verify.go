@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dict-hub/internal/model"
+	"dict-hub/internal/service/mdx"
+	"dict-hub/pkg/mdict"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVerifyReportNotFound = errors.New("verify report not found")
+	ErrVerifyInProgress     = errors.New("verification already in progress")
+	ErrVerifyUnsupported    = errors.New("verification only supports MDX/MDD dictionaries")
+)
+
+// VerifyService 字典完整性校验服务
+type VerifyService struct {
+	db            *gorm.DB
+	dictSourceSvc *DictSourceService
+	running       map[uint]bool // DictSource ID -> 是否正在校验
+	mu            sync.Mutex
+}
+
+// NewVerifyService 创建校验服务
+func NewVerifyService(db *gorm.DB, dictSourceSvc *DictSourceService) *VerifyService {
+	return &VerifyService{
+		db:            db,
+		dictSourceSvc: dictSourceSvc,
+		running:       make(map[uint]bool),
+	}
+}
+
+// StartVerify 启动异步校验任务
+func (s *VerifyService) StartVerify(dictSourceID uint) (*model.VerifyReport, error) {
+	source, err := s.dictSourceSvc.GetByID(dictSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(source.Path)); ext != ".mdx" && ext != ".mdd" {
+		return nil, ErrVerifyUnsupported
+	}
+	if _, err := os.Stat(source.Path); err != nil {
+		return nil, ErrDictFileNotFound
+	}
+
+	s.mu.Lock()
+	if s.running[dictSourceID] {
+		s.mu.Unlock()
+		return nil, ErrVerifyInProgress
+	}
+	s.running[dictSourceID] = true
+	s.mu.Unlock()
+
+	report := &model.VerifyReport{
+		DictSourceID: dictSourceID,
+		Status:       model.VerifyStatusPending,
+	}
+	if err := s.db.Create(report).Error; err != nil {
+		s.finish(dictSourceID)
+		return nil, err
+	}
+
+	// 启动后台校验
+	go s.verifyWorker(report.ID, dictSourceID, verifyFiles(source.Path))
+
+	return report, nil
+}
+
+// ListReports 获取字典的校验报告（最新的在前）
+func (s *VerifyService) ListReports(dictSourceID uint) ([]model.VerifyReport, error) {
+	var reports []model.VerifyReport
+	if err := s.db.Where("dict_source_id = ?", dictSourceID).Order("id DESC").Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetReport 获取指定校验报告
+func (s *VerifyService) GetReport(dictSourceID, reportID uint) (*model.VerifyReport, error) {
+	var report model.VerifyReport
+	if err := s.db.Where("dict_source_id = ?", dictSourceID).First(&report, reportID).Error; err != nil {
+		return nil, ErrVerifyReportNotFound
+	}
+	return &report, nil
+}
+
+// verifyWorker 后台校验工作协程
+func (s *VerifyService) verifyWorker(reportID, dictSourceID uint, files []string) {
+	defer s.finish(dictSourceID)
+
+	startedAt := time.Now()
+	s.db.Model(&model.VerifyReport{}).Where("id = ?", reportID).Updates(map[string]interface{}{
+		"status":     model.VerifyStatusRunning,
+		"started_at": startedAt,
+	})
+
+	results := make([]mdict.VerifyResult, 0, len(files))
+	issueCount := 0
+	lastProgress := 0
+
+	for i, path := range files {
+		result, err := verifyFile(path, mdict.VerifyOptions{
+			Progress: func(done, total int) {
+				if total <= 0 {
+					return
+				}
+				// 多个文件平均分配进度
+				progress := (i*100 + done*100/total) / len(files)
+				if progress >= lastProgress+5 {
+					s.db.Model(&model.VerifyReport{}).Where("id = ?", reportID).Update("progress", progress)
+					lastProgress = progress
+				}
+			},
+		})
+		if err != nil {
+			s.db.Model(&model.VerifyReport{}).Where("id = ?", reportID).Updates(map[string]interface{}{
+				"status":      model.VerifyStatusFailed,
+				"error_msg":   filepath.Base(path) + ": " + err.Error(),
+				"finished_at": time.Now(),
+			})
+			return
+		}
+		results = append(results, *result)
+		issueCount += len(result.Issues)
+	}
+
+	report := model.VerifyReport{
+		ID:         reportID,
+		Status:     model.VerifyStatusCompleted,
+		Progress:   100,
+		OK:         issueCount == 0,
+		IssueCount: issueCount,
+		Files:      results,
+	}
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+
+	s.db.Model(&report).Select("status", "progress", "ok", "issue_count", "files", "finished_at").Updates(&report)
+}
+
+// verifyFile 校验单个文件，损坏文件导致的解析器 panic 转换为错误，只让这一次校验失败
+func verifyFile(path string, opts mdict.VerifyOptions) (result *mdict.VerifyResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic while verifying: %v", r)
+		}
+	}()
+	return mdict.Verify(path, opts)
+}
+
+// finish 清除运行标记
+func (s *VerifyService) finish(dictSourceID uint) {
+	s.mu.Lock()
+	delete(s.running, dictSourceID)
+	s.mu.Unlock()
+}
+
+// verifyFiles 返回需要校验的文件：MDX 及同名 MDD 的全部分卷；单独的资源包只校验其全部分卷
+func verifyFiles(path string) []string {
+	if strings.EqualFold(filepath.Ext(path), ".mdd") {
+		return mdx.MDDVolumes(path)
+	}
+	return append([]string{path}, mdx.MDDVolumes(path)...)
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dict-hub/internal/model"
+	"dict-hub/pkg/mdict"
+	"dict-hub/pkg/wiktionary"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrImportNotFound     = errors.New("import task not found")
+	ErrImportInProgress   = errors.New("import already in progress")
+	ErrInvalidImportFile  = errors.New("invalid file format, only .jsonl/.json files (optionally gzipped) are supported")
+	ErrImportOutputExists = errors.New("a dictionary generated from this file already exists")
+)
+
+// wiktionarySubDir 生成的 Wiktionary 词典存放的子目录（位于字典源目录下）
+const wiktionarySubDir = "wiktionary"
+
+// wordFormBatchSize 词形表每批写入的行数
+const wordFormBatchSize = 500
+
+// WiktionaryService Wiktionary（kaikki.org JSONL）导入服务
+// 将 JSONL 转换为 MDX 词典并注册为 DictSource，同时导入词形表
+type WiktionaryService struct {
+	db            *gorm.DB
+	dictSourceSvc *DictSourceService
+	running       map[string]bool // 输出路径 -> 是否正在导入
+	mu            sync.Mutex
+}
+
+// NewWiktionaryService 创建 Wiktionary 导入服务
+func NewWiktionaryService(db *gorm.DB, dictSourceSvc *DictSourceService) *WiktionaryService {
+	return &WiktionaryService{
+		db:            db,
+		dictSourceSvc: dictSourceSvc,
+		running:       make(map[string]bool),
+	}
+}
+
+// StartImport 启动异步导入任务
+func (s *WiktionaryService) StartImport(path string) (*model.WiktionaryImport, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, ErrDictFileNotFound
+	}
+
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	if strings.EqualFold(filepath.Ext(base), ".gz") {
+		base = name
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if ext := strings.ToLower(filepath.Ext(base)); ext != ".jsonl" && ext != ".json" {
+		return nil, ErrInvalidImportFile
+	}
+
+	outputPath := filepath.Join(s.dictSourceSvc.GetSourceDir(), wiktionarySubDir, name+".mdx")
+	var existing model.DictSource
+	if err := s.db.Where("path = ?", outputPath).First(&existing).Error; err == nil {
+		return nil, ErrImportOutputExists
+	}
+
+	s.mu.Lock()
+	if s.running[outputPath] {
+		s.mu.Unlock()
+		return nil, ErrImportInProgress
+	}
+	s.running[outputPath] = true
+	s.mu.Unlock()
+
+	task := &model.WiktionaryImport{
+		SourcePath: path,
+		OutputPath: outputPath,
+		Status:     model.ImportStatusPending,
+	}
+	if err := s.db.Create(task).Error; err != nil {
+		s.finish(outputPath)
+		return nil, err
+	}
+
+	// 启动后台导入
+	go s.importWorker(task.ID, path, outputPath)
+
+	return task, nil
+}
+
+// GetImport 获取导入任务状态
+func (s *WiktionaryService) GetImport(id uint) (*model.WiktionaryImport, error) {
+	var task model.WiktionaryImport
+	if err := s.db.First(&task, id).Error; err != nil {
+		return nil, ErrImportNotFound
+	}
+	return &task, nil
+}
+
+// importWorker 后台导入工作协程
+func (s *WiktionaryService) importWorker(taskID uint, path, outputPath string) {
+	defer s.finish(outputPath)
+	// 生成和登记期间文件监视不处理输出文件，避免读到写了一半的字典
+	defer s.dictSourceSvc.reservePath(outputPath)()
+
+	startedAt := time.Now()
+	s.db.Model(&model.WiktionaryImport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"status":     model.ImportStatusRunning,
+		"started_at": startedAt,
+	})
+
+	fail := func(err error) {
+		s.db.Model(&model.WiktionaryImport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+			"status":      model.ImportStatusFailed,
+			"error_msg":   err.Error(),
+			"finished_at": time.Now(),
+		})
+	}
+
+	// 在后台协程中运行，转储文件内容异常导致的 panic 只让这一个导入任务失败
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic while importing: %v", r)
+			log.Printf("Wiktionary import %d failed: %v", taskID, err)
+			fail(err)
+		}
+	}()
+
+	reader, err := wiktionary.OpenFile(path)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer reader.Close()
+
+	// 同一单词的多个词性由 Writer 合并为一个词条，超出内存上限的部分落盘；
+	// 标题取自首个词条的语言，因此在读到首个词条时创建
+	var writer *mdict.Writer
+	defer func() {
+		if writer != nil {
+			writer.Close()
+		}
+	}()
+
+	// 词形在字典登记前没有所属字典 ID，先写入字典源目录之外的临时文件，登记后再分批入库
+	formFile, err := os.CreateTemp("", "wiktionary-forms-*.jsonl")
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer func() {
+		formFile.Close()
+		os.Remove(formFile.Name())
+	}()
+	formBuf := bufio.NewWriter(formFile)
+	formEnc := json.NewEncoder(formBuf)
+
+	var entryCount int64
+	lastProgress := 0
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		if entry.Word == "" {
+			continue
+		}
+		entryCount++
+		if writer == nil {
+			title := "Wiktionary"
+			if entry.Lang != "" {
+				title += " (" + entry.Lang + ")"
+			}
+			writer = mdict.NewWriter(mdict.DictTypeMDX, mdict.WriterOptions{
+				Title:       title,
+				Description: "Wiktionary data extracted by kaikki.org (wiktextract), licensed under CC BY-SA 4.0 and GFDL.",
+			})
+		}
+
+		if err := writer.Add(entry.Word, []byte(wiktionary.RenderHTML(entry))); err != nil {
+			fail(err)
+			return
+		}
+		for _, f := range entry.InflectedForms() {
+			if err := formEnc.Encode(f); err != nil {
+				fail(err)
+				return
+			}
+		}
+
+		// 读取阶段占 90% 进度
+		if done, total := reader.Progress(); total > 0 {
+			progress := int(done * 90 / total)
+			if progress >= lastProgress+5 {
+				s.db.Model(&model.WiktionaryImport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+					"progress":    progress,
+					"entry_count": entryCount,
+				})
+				lastProgress = progress
+			}
+		}
+	}
+
+	if writer == nil {
+		fail(mdict.ErrNoEntries)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		fail(err)
+		return
+	}
+	if err := writer.WriteFile(outputPath); err != nil {
+		fail(err)
+		return
+	}
+
+	if err := formBuf.Flush(); err != nil {
+		fail(err)
+		return
+	}
+	if _, err := formFile.Seek(0, io.SeekStart); err != nil {
+		fail(err)
+		return
+	}
+
+	source, err := s.dictSourceSvc.Add(outputPath)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// 词形在一个事务中写入；失败时撤销字典登记，不留下缺少词形的字典，重试时也不会因字典已存在被拒绝
+	var formCount int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		formCount, err = saveForms(tx, source.ID, formFile)
+		return err
+	})
+	if err != nil {
+		s.discardOutput(source.ID, outputPath)
+		fail(err)
+		return
+	}
+
+	finishedAt := time.Now()
+	s.db.Model(&model.WiktionaryImport{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"status":         model.ImportStatusCompleted,
+		"progress":       100,
+		"entry_count":    entryCount,
+		"word_count":     writer.Len(),
+		"form_count":     formCount,
+		"dict_source_id": source.ID,
+		"finished_at":    finishedAt,
+	})
+}
+
+// saveForms 从临时文件逐行读取词形，分批写入词形表
+func saveForms(tx *gorm.DB, dictSourceID uint, r io.Reader) (int64, error) {
+	var count int64
+	rows := make([]model.WordForm, 0, wordFormBatchSize)
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		count += int64(len(rows))
+		rows = rows[:0]
+		return nil
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var f wiktionary.InflectedForm
+		if err := dec.Decode(&f); err == io.EOF {
+			break
+		} else if err != nil {
+			return count, err
+		}
+		if len(f.Form) > 255 || len(f.Lemma) > 255 {
+			continue
+		}
+		tags := f.Tags
+		if len(tags) > 255 {
+			tags = tags[:255]
+		}
+		rows = append(rows, model.WordForm{
+			DictSourceID: dictSourceID,
+			Form:         f.Form,
+			Lemma:        f.Lemma,
+			Tags:         tags,
+		})
+		if len(rows) == wordFormBatchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// discardOutput 删除导入失败时已登记的字典及生成的文件。字典记录彻底删除，
+// 否则软删除的记录仍占用路径唯一索引，重新导入时无法再登记
+func (s *WiktionaryService) discardOutput(dictSourceID uint, outputPath string) {
+	if err := s.dictSourceSvc.Delete(dictSourceID); err != nil {
+		log.Printf("Failed to remove dictionary %d of failed wiktionary import: %v", dictSourceID, err)
+	}
+	s.db.Unscoped().Delete(&model.DictSource{}, dictSourceID)
+	os.Remove(outputPath)
+}
+
+// finish 清除运行标记
+func (s *WiktionaryService) finish(outputPath string) {
+	s.mu.Lock()
+	delete(s.running, outputPath)
+	s.mu.Unlock()
+}
@@ -114,6 +114,54 @@ func (s *WordFreqService) Import(reader io.Reader) (*ImportResult, error) {
 	return result, nil
 }
 
+// ImportScores 导入词典自带的词频分值（如 Yomitan 词频词典）
+// 已有记录取较大值，避免覆盖真实的搜索次数
+func (s *WordFreqService) ImportScores(scores map[string]int64) (*ImportResult, error) {
+	result := &ImportResult{
+		TotalLines: len(scores),
+		Errors:     make([]string, 0),
+	}
+
+	now := time.Now()
+	batch := make([]*model.WordFrequency, 0, 100)
+	flush := func() {
+		err := s.db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "word"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"search_count": gorm.Expr("MAX(search_count, excluded.search_count)"),
+				"updated_at":   now,
+			}),
+		}).Create(&batch).Error
+		if err != nil {
+			result.Errors = append(result.Errors, "batch insert error: "+err.Error())
+		} else {
+			result.ImportedCount += len(batch)
+		}
+		batch = batch[:0]
+	}
+
+	for word, score := range scores {
+		word = strings.TrimSpace(word)
+		if word == "" || score <= 0 {
+			result.SkippedCount++
+			continue
+		}
+		batch = append(batch, &model.WordFrequency{
+			Word:         word,
+			SearchCount:  score,
+			LastSearched: now,
+		})
+		if len(batch) >= 100 {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+
+	return result, nil
+}
+
 // batchUpsert 批量插入或更新
 func (s *WordFreqService) batchUpsert(items []*model.WordFrequency) error {
 	return s.db.Clauses(clause.OnConflict{
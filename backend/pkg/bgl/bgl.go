@@ -0,0 +1,520 @@
+// Package bgl reads Babylon glossary (.bgl) files.
+//
+// A BGL file starts with a 6 byte header: a 4 byte signature and the big
+// endian offset of a gzip stream. The decompressed stream is a sequence of
+// blocks, each starting with a byte whose low nibble is the block type and
+// whose high nibble encodes the block length. Entries, metadata and
+// embedded resources (images, sounds) are all stored as blocks.
+package bgl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a word is not in the glossary.
+	ErrNotFound = errors.New("bgl: word not found")
+	// ErrInvalidSignature is returned for files without a BGL signature.
+	ErrInvalidSignature = errors.New("bgl: invalid file signature")
+)
+
+// Block types
+const (
+	blockCharset  = 0
+	blockEntry    = 1
+	blockResource = 2
+	blockInfo     = 3
+	blockEnd      = 4
+	blockEntry10  = 10
+	blockEntry11  = 11
+)
+
+// Info property codes in type 3 blocks
+const (
+	infoTitle         = 0x01
+	infoAuthor        = 0x02
+	infoEmail         = 0x03
+	infoCopyright     = 0x04
+	infoDescription   = 0x09
+	infoFlags         = 0x11
+	infoSourceCharset = 0x1a
+	infoTargetCharset = 0x1b
+)
+
+const (
+	// flagUTF8Encoding in the info flags marks UTF-8 encoded text
+	flagUTF8Encoding = 0x8000
+	// defaultCharsetCode is the Babylon "Default" charset (Windows-1252)
+	defaultCharsetCode = 0x41
+)
+
+// entry is one glossary entry with its raw, still encoded definition.
+type entry struct {
+	word       string
+	definition []byte
+}
+
+// key is a sorted lookup key. Alternates point to the same entry as their
+// head word.
+type key struct {
+	word  string
+	lower string
+	entry int
+}
+
+// Dict is an opened BGL glossary. The whole file is decoded on open, BGL
+// files are not designed for random access. It is safe for concurrent use.
+type Dict struct {
+	path string
+
+	Title       string
+	Author      string
+	Email       string
+	Copyright   string
+	Description string
+
+	defaultCharset byte
+	sourceCharset  byte
+	targetCharset  byte
+	utf8           bool
+
+	entries   []entry
+	keys      []key
+	resources map[string][]byte // lower case name -> data
+}
+
+// rawBlock is a block read from the stream.
+type rawBlock struct {
+	typ  byte
+	data []byte
+}
+
+// Open reads and indexes a BGL file.
+func Open(path string) (*Dict, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	signature := binary.BigEndian.Uint32(header)
+	if signature != 0x12340001 && signature != 0x12340002 {
+		return nil, ErrInvalidSignature
+	}
+	gzOffset := int64(binary.BigEndian.Uint16(header[4:]))
+	if gzOffset < 6 {
+		return nil, ErrInvalidSignature
+	}
+	if _, err := file.Seek(gzOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("bgl: %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	d := &Dict{
+		path:      path,
+		resources: make(map[string][]byte),
+	}
+
+	// Entries are decoded after the info blocks because the charset
+	// blocks may come after the first entries.
+	var entryBlocks []rawBlock
+	r := bufio.NewReader(gz)
+	for {
+		block, err := readBlock(r)
+		if err != nil {
+			// Many glossaries have a truncated or corrupt gzip trailer,
+			// keep everything read so far.
+			if len(entryBlocks) == 0 {
+				return nil, fmt.Errorf("bgl: %s: %w", path, err)
+			}
+			break
+		}
+		if block.typ == blockEnd {
+			break
+		}
+
+		switch block.typ {
+		case blockCharset:
+			if len(block.data) >= 2 && block.data[0] == 8 {
+				d.defaultCharset = block.data[1]
+			}
+		case blockInfo:
+			d.readInfo(block.data)
+		case blockResource:
+			d.readResource(block.data)
+		case blockEntry, blockEntry10, blockEntry11:
+			entryBlocks = append(entryBlocks, block)
+		}
+	}
+
+	d.resolveCharsets()
+	for _, block := range entryBlocks {
+		d.readEntry(block)
+	}
+
+	sort.SliceStable(d.keys, func(i, j int) bool {
+		return d.keys[i].lower < d.keys[j].lower
+	})
+	return d, nil
+}
+
+// readBlock reads the next block. The high nibble of the first byte is
+// either the length itself (plus 4) or the number of length bytes minus one.
+func readBlock(r *bufio.Reader) (rawBlock, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return rawBlock{}, err
+	}
+	block := rawBlock{typ: b & 0x0f}
+
+	var length int
+	if sel := int(b >> 4); sel >= 4 {
+		length = sel - 4
+	} else {
+		lenBytes := make([]byte, sel+1)
+		if _, err := io.ReadFull(r, lenBytes); err != nil {
+			return rawBlock{}, err
+		}
+		for _, lb := range lenBytes {
+			length = length<<8 | int(lb)
+		}
+	}
+
+	// The length comes from up to four bytes of a gzip stream of unknown
+	// size, so grow the buffer as data arrives instead of trusting it
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return rawBlock{}, err
+	}
+	block.data = buf.Bytes()
+	return block, nil
+}
+
+// readInfo parses a metadata block.
+func (d *Dict) readInfo(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	code := binary.BigEndian.Uint16(data)
+	value := data[2:]
+
+	switch code {
+	case infoTitle:
+		d.Title = string(value)
+	case infoAuthor:
+		d.Author = string(value)
+	case infoEmail:
+		d.Email = string(value)
+	case infoCopyright:
+		d.Copyright = string(value)
+	case infoDescription:
+		d.Description = string(value)
+	case infoFlags:
+		if len(value) >= 4 {
+			d.utf8 = binary.BigEndian.Uint32(value)&flagUTF8Encoding != 0
+		}
+	case infoSourceCharset:
+		if len(value) > 0 {
+			d.sourceCharset = value[0]
+		}
+	case infoTargetCharset:
+		if len(value) > 0 {
+			d.targetCharset = value[0]
+		}
+	}
+}
+
+// readResource parses an embedded file block.
+func (d *Dict) readResource(data []byte) {
+	if len(data) < 1 {
+		return
+	}
+	nameLen := int(data[0])
+	if len(data) < 1+nameLen {
+		return
+	}
+	name := string(data[1 : 1+nameLen])
+	d.resources[strings.ToLower(name)] = data[1+nameLen:]
+}
+
+// readEntry parses an entry block and adds it to the index.
+func (d *Dict) readEntry(block rawBlock) {
+	var (
+		word, definition []byte
+		alternates       [][]byte
+		ok               bool
+	)
+	if block.typ == blockEntry11 {
+		word, definition, alternates, ok = parseEntry11(block.data)
+	} else {
+		word, definition, alternates, ok = parseEntry(block.data)
+	}
+	if !ok {
+		return
+	}
+
+	headword := cleanWord(decode(word, d.sourceCharset, d.utf8))
+	if headword == "" {
+		return
+	}
+
+	index := len(d.entries)
+	d.entries = append(d.entries, entry{word: headword, definition: definition})
+	d.keys = append(d.keys, key{word: headword, lower: strings.ToLower(headword), entry: index})
+
+	for _, alt := range alternates {
+		w := cleanWord(decode(alt, d.sourceCharset, d.utf8))
+		if w != "" && w != headword {
+			d.keys = append(d.keys, key{word: w, lower: strings.ToLower(w), entry: index})
+		}
+	}
+}
+
+// parseEntry parses entry blocks of type 1 and 10: a 1 byte word length,
+// a 2 byte definition length and 1 byte lengths for the alternates.
+func parseEntry(data []byte) ([]byte, []byte, [][]byte, bool) {
+	pos := 0
+	if len(data) < 1 {
+		return nil, nil, nil, false
+	}
+	wordLen := int(data[pos])
+	pos++
+	if len(data) < pos+wordLen+2 {
+		return nil, nil, nil, false
+	}
+	word := data[pos : pos+wordLen]
+	pos += wordLen
+
+	defLen := int(binary.BigEndian.Uint16(data[pos:]))
+	pos += 2
+	if len(data) < pos+defLen {
+		return nil, nil, nil, false
+	}
+	definition := data[pos : pos+defLen]
+	pos += defLen
+
+	var alternates [][]byte
+	for pos < len(data) {
+		altLen := int(data[pos])
+		pos++
+		if len(data) < pos+altLen {
+			break
+		}
+		alternates = append(alternates, data[pos:pos+altLen])
+		pos += altLen
+	}
+	return word, definition, alternates, true
+}
+
+// parseEntry11 parses entry blocks of type 11: a 5 byte word length, a
+// 4 byte alternate count with 4 byte lengths, and a 4 byte definition length.
+func parseEntry11(data []byte) ([]byte, []byte, [][]byte, bool) {
+	pos := 0
+	readLen := func(n int) (int, bool) {
+		if len(data) < pos+n {
+			return 0, false
+		}
+		v := 0
+		for _, b := range data[pos : pos+n] {
+			v = v<<8 | int(b)
+		}
+		pos += n
+		return v, true
+	}
+	readBytes := func(n int) ([]byte, bool) {
+		if n < 0 || len(data) < pos+n {
+			return nil, false
+		}
+		b := data[pos : pos+n]
+		pos += n
+		return b, true
+	}
+
+	wordLen, ok := readLen(5)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	word, ok := readBytes(wordLen)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	altCount, ok := readLen(4)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	var alternates [][]byte
+	for i := 0; i < altCount; i++ {
+		altLen, ok := readLen(4)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		alt, ok := readBytes(altLen)
+		if !ok {
+			return nil, nil, nil, false
+		}
+		alternates = append(alternates, alt)
+	}
+
+	defLen, ok := readLen(4)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	definition, ok := readBytes(defLen)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return word, definition, alternates, true
+}
+
+// resolveCharsets falls back to the default charset for source and target.
+func (d *Dict) resolveCharsets() {
+	if d.defaultCharset == 0 {
+		d.defaultCharset = defaultCharsetCode
+	}
+	if d.sourceCharset == 0 {
+		d.sourceCharset = d.defaultCharset
+	}
+	if d.targetCharset == 0 {
+		d.targetCharset = d.defaultCharset
+	}
+
+	d.Title = decode([]byte(d.Title), d.defaultCharset, d.utf8)
+	d.Author = decode([]byte(d.Author), d.defaultCharset, d.utf8)
+	d.Copyright = decode([]byte(d.Copyright), d.defaultCharset, d.utf8)
+	d.Description = decode([]byte(d.Description), d.defaultCharset, d.utf8)
+}
+
+// Name returns the file name without extension.
+func (d *Dict) Name() string {
+	name := filepath.Base(d.path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Path returns the file path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Len returns the number of entries.
+func (d *Dict) Len() int {
+	return len(d.entries)
+}
+
+// find returns the range of keys equal to word (case-insensitive).
+func (d *Dict) find(word string) (int, int) {
+	lower := strings.ToLower(strings.TrimSpace(word))
+	start := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= lower
+	})
+	end := start
+	for end < len(d.keys) && d.keys[end].lower == lower {
+		end++
+	}
+	return start, end
+}
+
+// Lookup returns the entries for word rendered as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	start, end := d.find(word)
+	if start == end {
+		return nil, ErrNotFound
+	}
+
+	var sb strings.Builder
+	seen := make(map[int]bool)
+	for _, k := range d.keys[start:end] {
+		if seen[k.entry] {
+			continue
+		}
+		seen[k.entry] = true
+		if sb.Len() > 0 {
+			sb.WriteString("<hr>")
+		}
+		sb.WriteString(d.renderDefinition(d.entries[k.entry].definition))
+	}
+	return []byte(sb.String()), nil
+}
+
+// Suggest returns up to limit words starting with prefix (case-insensitive).
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every entry in file order with the rendered HTML.
+// Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for _, e := range d.entries {
+		if err := fn(e.word, []byte(d.renderDefinition(e.definition))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasResources reports whether the glossary embeds any files.
+func (d *Dict) HasResources() bool {
+	return len(d.resources) > 0
+}
+
+// Resource returns an embedded file by name (case-insensitive).
+func (d *Dict) Resource(name string) ([]byte, error) {
+	name = strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/")
+	if data, ok := d.resources[strings.ToLower(name)]; ok {
+		return data, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// ResourceNames returns the names of the embedded files.
+func (d *Dict) ResourceNames() []string {
+	names := make([]string, 0, len(d.resources))
+	for name := range d.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,144 @@
+package bgl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bglWriter builds the block stream of a test glossary.
+type bglWriter struct {
+	buf bytes.Buffer
+}
+
+// block writes a block using a 2 byte length.
+func (w *bglWriter) block(typ byte, data []byte) {
+	w.buf.WriteByte(1<<4 | typ)
+	binary.Write(&w.buf, binary.BigEndian, uint16(len(data)))
+	w.buf.Write(data)
+}
+
+func (w *bglWriter) info(code uint16, value []byte) {
+	data := make([]byte, 2, 2+len(value))
+	binary.BigEndian.PutUint16(data, code)
+	w.block(blockInfo, append(data, value...))
+}
+
+func (w *bglWriter) entry(word, definition string, alternates ...string) {
+	var data bytes.Buffer
+	data.WriteByte(byte(len(word)))
+	data.WriteString(word)
+	binary.Write(&data, binary.BigEndian, uint16(len(definition)))
+	data.WriteString(definition)
+	for _, alt := range alternates {
+		data.WriteByte(byte(len(alt)))
+		data.WriteString(alt)
+	}
+	w.block(blockEntry, data.Bytes())
+}
+
+func (w *bglWriter) entry11(word, definition string, alternates ...string) {
+	var data bytes.Buffer
+	data.Write([]byte{0, 0, 0, 0, byte(len(word))})
+	data.WriteString(word)
+	binary.Write(&data, binary.BigEndian, uint32(len(alternates)))
+	for _, alt := range alternates {
+		binary.Write(&data, binary.BigEndian, uint32(len(alt)))
+		data.WriteString(alt)
+	}
+	binary.Write(&data, binary.BigEndian, uint32(len(definition)))
+	data.WriteString(definition)
+	w.block(blockEntry11, data.Bytes())
+}
+
+// writeFile writes the BGL header and the gzip compressed blocks.
+func (w *bglWriter) writeFile(t *testing.T, path string) {
+	t.Helper()
+	var out bytes.Buffer
+	out.Write([]byte{0x12, 0x34, 0x00, 0x01, 0x00, 0x06})
+	gz := gzip.NewWriter(&out)
+	gz.Write(w.buf.Bytes())
+	gz.Write([]byte{blockEnd})
+	gz.Close()
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	w := &bglWriter{}
+	w.block(blockCharset, []byte{8, 0x44}) // Cyrillic
+	w.info(infoTitle, []byte("Test Glossary"))
+	// "кот" in Windows-1251 with part of speech and transcription fields
+	w.entry("cat$1$", "\xea\xee\xf2<br>\x14\x02\x30\x14\x50\x01\x03kat", "cats")
+	w.entry11("dog", "<b>\xf1\xee\xe1\xe0\xea\xe0</b>", "hound")
+	w.entry("note", `<charset c=T>043A;043E;</charset><img src="pic.png">`)
+	w.block(blockResource, append([]byte{7}, []byte("pic.pngPNGDATA")...))
+
+	path := filepath.Join(t.TempDir(), "test.bgl")
+	w.writeFile(t, path)
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if d.Title != "Test Glossary" || d.Len() != 3 || d.Name() != "test" {
+		t.Errorf("unexpected dictionary: title=%q len=%d name=%q", d.Title, d.Len(), d.Name())
+	}
+
+	got, err := d.Lookup("CATS")
+	if err != nil {
+		t.Fatalf("Lookup(CATS) failed: %v", err)
+	}
+	for _, want := range []string{"кот<br>", `<i class="bgl-pos">n.</i>`, `[kat]`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(CATS) = %q, missing %q", got, want)
+		}
+	}
+
+	if got, err := d.Lookup("hound"); err != nil || string(got) != "<b>собака</b>" {
+		t.Errorf("Lookup(hound) = %q, %v", got, err)
+	}
+
+	if got, err := d.Lookup("note"); err != nil || !strings.HasPrefix(string(got), "&#1082;&#1086;") {
+		t.Errorf("Lookup(note) = %q, %v", got, err)
+	}
+
+	if data, err := d.Resource("/PIC.png"); err != nil || string(data) != "PNGDATA" {
+		t.Errorf("Resource = %q, %v", data, err)
+	}
+
+	if _, err := d.Lookup("bird"); err != ErrNotFound {
+		t.Errorf("Lookup(bird) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.bgl")
+	os.WriteFile(path, []byte("not a glossary"), 0644)
+	if _, err := Open(path); err != ErrInvalidSignature {
+		t.Errorf("Open error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestOversizedBlockLength(t *testing.T) {
+	var w bglWriter
+	w.info(infoTitle, []byte("Test"))
+	w.entry("cat", "a small animal")
+	// A block claiming almost 4GB of data followed by a few bytes
+	w.buf.Write([]byte{3<<4 | blockEntry, 0xff, 0xff, 0xff, 0xf0, 'x', 'y'})
+	path := filepath.Join(t.TempDir(), "big.bgl")
+	w.writeFile(t, path)
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := d.Lookup("cat"); err != nil {
+		t.Errorf("Lookup(cat) failed: %v", err)
+	}
+}
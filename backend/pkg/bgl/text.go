@@ -0,0 +1,144 @@
+package bgl
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// charsets maps Babylon charset codes to encodings.
+var charsets = map[byte]encoding.Encoding{
+	0x41: charmap.Windows1252, // Default
+	0x42: charmap.Windows1252, // Latin
+	0x43: charmap.Windows1250, // Eastern European
+	0x44: charmap.Windows1251, // Cyrillic
+	0x45: japanese.ShiftJIS,
+	0x46: traditionalchinese.Big5,
+	0x47: simplifiedchinese.GBK,
+	0x48: charmap.Windows1257, // Baltic
+	0x49: charmap.Windows1253, // Greek
+	0x4a: korean.EUCKR,
+	0x4b: charmap.Windows1254, // Turkish
+	0x4c: charmap.Windows1255, // Hebrew
+	0x4d: charmap.Windows1256, // Arabic
+	0x4e: charmap.Windows874,  // Thai
+}
+
+// decode converts text in the given Babylon charset to UTF-8.
+func decode(data []byte, charset byte, utf8Flag bool) string {
+	if utf8Flag && utf8.Valid(data) {
+		return string(data)
+	}
+	enc, ok := charsets[charset]
+	if !ok {
+		enc = charmap.Windows1252
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+var (
+	// wordSuffixPattern matches the "$123$" suffix that makes homographs unique.
+	wordSuffixPattern = regexp.MustCompile(`\$\d+\$$`)
+	// tagPattern matches HTML tags in headwords.
+	tagPattern = regexp.MustCompile(`<[^>]*>`)
+	// charsetTagPattern matches <charset c=T>...</charset>, whose content is
+	// a list of hexadecimal code points terminated by ';'.
+	charsetTagPattern = regexp.MustCompile(`(?i)<charset\s+c=["']?t["']?>([^<]*)</charset>`)
+)
+
+// cleanWord turns a raw headword into plain text.
+func cleanWord(word string) string {
+	word = wordSuffixPattern.ReplaceAllString(word, "")
+	word = tagPattern.ReplaceAllString(word, "")
+	return strings.TrimSpace(html.UnescapeString(word))
+}
+
+// partsOfSpeech maps part of speech codes in definition fields.
+var partsOfSpeech = map[byte]string{
+	0x30: "n.",
+	0x31: "adj.",
+	0x32: "v.",
+	0x33: "adv.",
+	0x34: "interj.",
+	0x35: "pron.",
+	0x36: "prep.",
+	0x37: "conj.",
+	0x38: "suff.",
+	0x39: "pref.",
+	0x3a: "art.",
+}
+
+// renderDefinition converts a raw definition to HTML. The definition text
+// is followed by optional binary fields, each starting with 0x14, holding
+// the part of speech and the transcription.
+func (d *Dict) renderDefinition(raw []byte) string {
+	text, fields := raw, []byte(nil)
+	if i := bytes.IndexByte(raw, 0x14); i >= 0 {
+		text, fields = raw[:i], raw[i:]
+	}
+
+	var (
+		pos           string
+		transcription string
+	)
+	for len(fields) >= 2 && fields[0] == 0x14 {
+		code := fields[1]
+		fields = fields[2:]
+		switch {
+		case code == 0x02 && len(fields) >= 1:
+			pos = partsOfSpeech[fields[0]]
+			fields = fields[1:]
+		case code == 0x06 && len(fields) >= 1:
+			fields = fields[1:]
+		case code == 0x18 && len(fields) >= 1 && len(fields) >= 1+int(fields[0]):
+			// Display form of the headword
+			fields = fields[1+int(fields[0]):]
+		case code == 0x50 && len(fields) >= 2 && len(fields) >= 2+int(fields[1]):
+			transcription = decode(fields[2:2+int(fields[1])], d.sourceCharset, d.utf8)
+			fields = fields[2+int(fields[1]):]
+		default:
+			// Unknown field, the rest cannot be parsed reliably
+			fields = nil
+		}
+	}
+
+	var sb strings.Builder
+	if pos != "" || transcription != "" {
+		sb.WriteString(`<div class="bgl-head">`)
+		if pos != "" {
+			sb.WriteString(`<i class="bgl-pos">` + pos + `</i> `)
+		}
+		if transcription != "" {
+			sb.WriteString(`<span class="phonetic">[` + html.EscapeString(transcription) + `]</span>`)
+		}
+		sb.WriteString("</div>")
+	}
+
+	body := decode(text, d.targetCharset, d.utf8)
+	body = charsetTagPattern.ReplaceAllStringFunc(body, func(m string) string {
+		var out strings.Builder
+		for _, code := range strings.Split(charsetTagPattern.FindStringSubmatch(m)[1], ";") {
+			if n, err := strconv.ParseUint(strings.TrimSpace(code), 16, 32); err == nil {
+				out.WriteString("&#" + strconv.FormatUint(n, 10) + ";")
+			}
+		}
+		return out.String()
+	})
+	body = strings.ReplaceAll(strings.TrimRight(body, "\r\n"), "\n", "<br>")
+	sb.WriteString(body)
+	return sb.String()
+}
@@ -0,0 +1,307 @@
+// Package dictd reads dictionaries in the dictd server format.
+//
+// A dictd dictionary consists of a tab separated .index file with one line
+// per headword ("word<TAB>offset<TAB>length", numbers in dictd's base64
+// notation) and a .dict data file, usually dictzip compressed (.dict.dz).
+// Entries named 00-database-* hold metadata and are not indexed as words.
+package dictd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dict-hub/pkg/dictzip"
+)
+
+var (
+	// ErrNotFound is returned when a word is not in the dictionary.
+	ErrNotFound = errors.New("dictd: word not found")
+	// ErrInvalidNumber is returned for malformed base64 numbers in the index.
+	ErrInvalidNumber = errors.New("dictd: invalid base64 number")
+	// ErrInvalidEntry is returned for index entries outside the data file.
+	ErrInvalidEntry = errors.New("dictd: entry outside the data file")
+)
+
+// b64Alphabet is the digit alphabet of dictd's base64 numbers.
+const b64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// maxNumberDigits is the number of base64 digits needed for any int64.
+const maxNumberDigits = 11
+
+// entry is one line of the .index file.
+type entry struct {
+	word   string
+	lower  string
+	offset int64
+	size   int64
+}
+
+// Dict is an opened dictd dictionary. It is safe for concurrent use.
+type Dict struct {
+	path string // .index path
+
+	Title       string
+	Description string
+	URL         string
+
+	entries []entry // sorted by lower case word
+
+	data     io.ReaderAt
+	dataSize int64 // uncompressed size, an upper bound for dictzip files
+	closer   io.Closer
+}
+
+// DecodeNumber decodes a number in dictd's base64 notation. Numbers that
+// do not fit in an int64 are rejected.
+func DecodeNumber(s string) (int64, error) {
+	if s == "" || len(s) > maxNumberDigits {
+		return 0, ErrInvalidNumber
+	}
+	var n int64
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(b64Alphabet, s[i])
+		if digit < 0 || n > math.MaxInt64>>6 {
+			return 0, ErrInvalidNumber
+		}
+		n = n<<6 | int64(digit)
+	}
+	return n, nil
+}
+
+// EncodeNumber encodes a number in dictd's base64 notation.
+func EncodeNumber(n int64) string {
+	if n == 0 {
+		return "A"
+	}
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = b64Alphabet[n&63]
+		n >>= 6
+	}
+	return string(buf[i:])
+}
+
+// Open opens the dictionary belonging to an .index file.
+func Open(indexPath string) (*Dict, error) {
+	d := &Dict{path: indexPath}
+	base := strings.TrimSuffix(indexPath, filepath.Ext(indexPath))
+
+	if file, err := os.Open(base + ".dict"); err == nil {
+		d.data, d.closer = file, file
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("dictd: failed to open data file: %w", err)
+		}
+		d.dataSize = info.Size()
+	} else {
+		r, err := dictzip.Open(base + ".dict.dz")
+		if err != nil {
+			return nil, fmt.Errorf("dictd: failed to open data file: %w", err)
+		}
+		d.data, d.dataSize, d.closer = r, r.Size(), r
+	}
+
+	if err := d.readIndex(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// readIndex parses the .index file and reads the metadata entries.
+func (d *Dict) readIndex() error {
+	file, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	meta := make(map[string]entry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		offset, err := DecodeNumber(fields[1])
+		if err != nil {
+			return fmt.Errorf("dictd: %s:%d: %w", d.path, line, err)
+		}
+		size, err := DecodeNumber(fields[2])
+		if err != nil {
+			return fmt.Errorf("dictd: %s:%d: %w", d.path, line, err)
+		}
+		if offset > d.dataSize || size > d.dataSize-offset {
+			return fmt.Errorf("dictd: %s:%d: %w", d.path, line, ErrInvalidEntry)
+		}
+
+		e := entry{word: fields[0], lower: strings.ToLower(fields[0]), offset: offset, size: size}
+		if isMetaWord(e.word) {
+			meta[strings.ReplaceAll(e.lower, "-", "")] = e
+			continue
+		}
+		d.entries = append(d.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// The index is sorted with dictd's collation, which ignores
+	// punctuation, so sort again for binary search.
+	sort.SliceStable(d.entries, func(i, j int) bool {
+		return d.entries[i].lower < d.entries[j].lower
+	})
+
+	d.Title = d.metaText(meta, "00databaseshort")
+	d.Description = d.metaText(meta, "00databaseinfo")
+	d.URL = d.metaText(meta, "00databaseurl")
+	return nil
+}
+
+// isMetaWord reports whether a headword is a metadata entry.
+func isMetaWord(word string) bool {
+	return strings.HasPrefix(word, "00-database-") || strings.HasPrefix(word, "00database")
+}
+
+// metaText returns the text of a metadata entry without its headword line.
+func (d *Dict) metaText(meta map[string]entry, name string) string {
+	e, ok := meta[name]
+	if !ok {
+		return ""
+	}
+	text, err := d.read(e)
+	if err != nil {
+		return ""
+	}
+	first, rest, _ := strings.Cut(text, "\n")
+	if isMetaWord(strings.TrimSpace(first)) {
+		text = rest
+	}
+	return strings.TrimSpace(text)
+}
+
+// read reads the raw text of an entry.
+func (d *Dict) read(e entry) (string, error) {
+	data := make([]byte, e.size)
+	if _, err := d.data.ReadAt(data, e.offset); err != nil && err != io.EOF {
+		return "", fmt.Errorf("dictd: failed to read %q: %w", e.word, err)
+	}
+	return string(data), nil
+}
+
+// Name returns the file name without extension.
+func (d *Dict) Name() string {
+	name := filepath.Base(d.path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Path returns the .index path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Len returns the number of indexed words.
+func (d *Dict) Len() int {
+	return len(d.entries)
+}
+
+// crossRefPattern matches {word} cross references.
+var crossRefPattern = regexp.MustCompile(`\{([^{}\n]+)\}`)
+
+// renderHTML renders a plain text entry. Indentation is preserved and
+// {word} cross references become internal links.
+func renderHTML(text string) string {
+	escaped := html.EscapeString(strings.TrimRight(text, "\n"))
+	escaped = crossRefPattern.ReplaceAllStringFunc(escaped, func(m string) string {
+		word := m[1 : len(m)-1]
+		target := strings.Join(strings.Fields(html.UnescapeString(word)), " ")
+		return `<a href="entry://` + html.EscapeString(target) + `">` + word + `</a>`
+	})
+	return `<div class="dictd" style="white-space:pre-wrap">` + escaped + `</div>`
+}
+
+// Lookup returns the entries for word rendered as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	lower := strings.ToLower(strings.TrimSpace(word))
+	start := sort.Search(len(d.entries), func(i int) bool {
+		return d.entries[i].lower >= lower
+	})
+
+	var sb strings.Builder
+	for i := start; i < len(d.entries) && d.entries[i].lower == lower; i++ {
+		text, err := d.read(d.entries[i])
+		if err != nil {
+			return nil, err
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("<hr>")
+		}
+		sb.WriteString(renderHTML(text))
+	}
+	if sb.Len() == 0 {
+		return nil, ErrNotFound
+	}
+	return []byte(sb.String()), nil
+}
+
+// Suggest returns up to limit words starting with prefix (case-insensitive).
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.entries), func(i int) bool {
+		return d.entries[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.entries) && len(results) < limit; i++ {
+		e := d.entries[i]
+		if !strings.HasPrefix(e.lower, prefix) {
+			break
+		}
+		if !seen[e.word] {
+			seen[e.word] = true
+			results = append(results, e.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every word in sorted order with the rendered HTML.
+// Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for _, e := range d.entries {
+		text, err := d.read(e)
+		if err != nil {
+			return err
+		}
+		if err := fn(e.word, []byte(renderHTML(text))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the data file.
+func (d *Dict) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package dictd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dict-hub/pkg/dictzip"
+)
+
+func TestNumbers(t *testing.T) {
+	cases := map[int64]string{0: "A", 1: "B", 63: "/", 64: "BA", 1234567: "EtaH"}
+	for n, s := range cases {
+		if got := EncodeNumber(n); got != s {
+			t.Errorf("EncodeNumber(%d) = %q, want %q", n, got, s)
+		}
+		if got, err := DecodeNumber(s); err != nil || got != n {
+			t.Errorf("DecodeNumber(%q) = %d, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"A*", "///////////", "BAAAAAAAAAAA"} {
+		if _, err := DecodeNumber(s); err != ErrInvalidNumber {
+			t.Errorf("DecodeNumber(%q) error = %v", s, err)
+		}
+	}
+	if got, err := DecodeNumber("H//////////"); err != nil || got != 1<<63-1 {
+		t.Errorf("DecodeNumber(max int64) = %d, %v", got, err)
+	}
+}
+
+// writeTestDict writes a dictd dictionary with the given entries.
+func writeTestDict(t *testing.T, dir string, entries [][2]string) string {
+	t.Helper()
+	var data, index strings.Builder
+	for _, e := range entries {
+		offset := data.Len()
+		data.WriteString(e[1])
+		fmt.Fprintf(&index, "%s\t%s\t%s\n", e[0], EncodeNumber(int64(offset)), EncodeNumber(int64(len(e[1]))))
+	}
+
+	base := filepath.Join(dir, "test")
+	if err := dictzip.WriteFile(base+".dict.dz", []byte(data.String())); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(base+".index", []byte(index.String()), 0644)
+	return base + ".index"
+}
+
+func TestOpenAndLookup(t *testing.T) {
+	path := writeTestDict(t, t.TempDir(), [][2]string{
+		{"00-database-short", "00-database-short\n     Test Dictionary\n"},
+		{"00-database-info", "This is a <test>.\n"},
+		{"apple", "apple\n  A fruit, see {pear}.\n"},
+		{"Apple", "Apple\n  A company.\n"},
+		{"pear", "pear\n  Another fruit.\n"},
+	})
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.Title != "Test Dictionary" || d.Description != "This is a <test>." {
+		t.Errorf("unexpected metadata: %q, %q", d.Title, d.Description)
+	}
+	if d.Len() != 3 || d.Name() != "test" {
+		t.Errorf("Len() = %d, Name() = %q", d.Len(), d.Name())
+	}
+
+	got, err := d.Lookup("APPLE")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	for _, want := range []string{"A fruit", "A company", `<a href="entry://pear">pear</a>`, "<hr>"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(APPLE) = %q, missing %q", got, want)
+		}
+	}
+
+	if _, err := d.Lookup("00-database-short"); err != ErrNotFound {
+		t.Errorf("metadata entries should not be indexed, got %v", err)
+	}
+	if got := d.Suggest("a", 10); strings.Join(got, ",") != "apple,Apple" {
+		t.Errorf("Suggest(a) = %v", got)
+	}
+}
+
+func TestOpenRejectsEntriesOutsideData(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestDict(t, dir, [][2]string{{"apple", "apple\n  A fruit.\n"}})
+	for _, line := range []string{"hello\tA\tBAAAAA\n", "hello\tBAAAAA\tB\n"} {
+		os.WriteFile(path, []byte(line), 0644)
+		if _, err := Open(path); !errors.Is(err, ErrInvalidEntry) {
+			t.Errorf("Open(%q) error = %v, want ErrInvalidEntry", line, err)
+		}
+	}
+}
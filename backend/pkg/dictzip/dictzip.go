@@ -0,0 +1,287 @@
+// Package dictzip provides random access to dictzip (.dz) files.
+//
+// A dictzip file is a gzip file whose deflate stream is split into
+// independently compressed chunks. The chunk table is stored in the "RA"
+// subfield of the gzip extra header, which makes it possible to decompress
+// only the chunks covering the requested range. Plain gzip files without a
+// chunk table are supported by decompressing them into memory once.
+package dictzip
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// gzip header flags
+const (
+	flagHCRC    = 1 << 1
+	flagExtra   = 1 << 2
+	flagName    = 1 << 3
+	flagComment = 1 << 4
+)
+
+// chunkCacheSize is the number of decompressed chunks kept in memory.
+const chunkCacheSize = 16
+
+var (
+	// ErrNotGzip is returned when a file does not start with a gzip header.
+	ErrNotGzip = errors.New("dictzip: not a gzip file")
+	// ErrUnsupportedVersion is returned for unknown chunk table versions.
+	ErrUnsupportedVersion = errors.New("dictzip: unsupported RA version")
+)
+
+// Reader provides io.ReaderAt access to the uncompressed content of a
+// dictzip or gzip file. It is safe for concurrent use.
+type Reader struct {
+	file *os.File
+
+	// dictzip chunk table
+	chunkLen     int
+	chunkOffsets []int64 // file offset of every chunk, plus the end offset
+
+	// plain gzip content, decompressed on open
+	data []byte
+
+	mu    sync.Mutex
+	cache map[int][]byte
+	order []int // chunk indexes in cache, oldest first
+}
+
+// Open opens a dictzip or gzip file.
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		file:  file,
+		cache: make(map[int][]byte),
+	}
+	if err := r.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// readHeader parses the gzip header and the optional RA chunk table.
+func (r *Reader) readHeader() error {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r.file, header); err != nil {
+		return ErrNotGzip
+	}
+	if header[0] != 0x1f || header[1] != 0x8b || header[2] != 8 {
+		return ErrNotGzip
+	}
+	flags := header[3]
+	pos := int64(10)
+
+	var chunkSizes []uint16
+	if flags&flagExtra != 0 {
+		var xlen uint16
+		if err := binary.Read(r.file, binary.LittleEndian, &xlen); err != nil {
+			return err
+		}
+		extra := make([]byte, xlen)
+		if _, err := io.ReadFull(r.file, extra); err != nil {
+			return err
+		}
+		pos += 2 + int64(xlen)
+
+		var err error
+		if r.chunkLen, chunkSizes, err = parseExtra(extra); err != nil {
+			return err
+		}
+	}
+
+	// Skip file name and comment (zero terminated) and header CRC
+	for _, flag := range []byte{flagName, flagComment} {
+		if flags&flag == 0 {
+			continue
+		}
+		n, err := skipString(r.file)
+		if err != nil {
+			return err
+		}
+		pos += n
+	}
+	if flags&flagHCRC != 0 {
+		pos += 2
+	}
+
+	if chunkSizes == nil {
+		// Plain gzip: decompress everything once
+		if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		gz, err := gzip.NewReader(r.file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		if r.data, err = io.ReadAll(gz); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+	r.chunkOffsets = make([]int64, len(chunkSizes)+1)
+	r.chunkOffsets[0] = pos
+	for i, size := range chunkSizes {
+		r.chunkOffsets[i+1] = r.chunkOffsets[i] + int64(size)
+	}
+	if end := r.chunkOffsets[len(chunkSizes)]; end > info.Size() {
+		return fmt.Errorf("dictzip: chunk table ends at %d, beyond file size %d", end, info.Size())
+	}
+	return nil
+}
+
+// parseExtra finds the RA subfield in the gzip extra field.
+func parseExtra(extra []byte) (int, []uint16, error) {
+	for len(extra) >= 4 {
+		si1, si2 := extra[0], extra[1]
+		length := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if 4+length > len(extra) {
+			break
+		}
+		field := extra[4 : 4+length]
+		extra = extra[4+length:]
+
+		if si1 != 'R' || si2 != 'A' || len(field) < 6 {
+			continue
+		}
+		if version := binary.LittleEndian.Uint16(field[0:2]); version != 1 {
+			return 0, nil, ErrUnsupportedVersion
+		}
+		chunkLen := int(binary.LittleEndian.Uint16(field[2:4]))
+		if chunkLen == 0 {
+			return 0, nil, fmt.Errorf("dictzip: invalid chunk length 0")
+		}
+		count := int(binary.LittleEndian.Uint16(field[4:6]))
+		if len(field) < 6+count*2 {
+			return 0, nil, fmt.Errorf("dictzip: truncated chunk table")
+		}
+		sizes := make([]uint16, count)
+		for i := range sizes {
+			sizes[i] = binary.LittleEndian.Uint16(field[6+i*2:])
+		}
+		return chunkLen, sizes, nil
+	}
+	return 0, nil, nil
+}
+
+// skipString skips a zero-terminated string and returns its length including the terminator.
+func skipString(rd io.Reader) (int64, error) {
+	var n int64
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return n, err
+		}
+		n++
+		if b[0] == 0 {
+			return n, nil
+		}
+	}
+}
+
+// Size returns the uncompressed size, which is only known exactly for plain
+// gzip files. For dictzip files an upper bound is returned.
+func (r *Reader) Size() int64 {
+	if r.data != nil {
+		return int64(len(r.data))
+	}
+	return int64(len(r.chunkOffsets)-1) * int64(r.chunkLen)
+}
+
+// ReadAt reads len(p) bytes of uncompressed data starting at off.
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("dictzip: negative offset")
+	}
+
+	if r.data != nil {
+		if off >= int64(len(r.data)) {
+			return 0, io.EOF
+		}
+		n := copy(p, r.data[off:])
+		if n < len(p) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		index := int(pos / int64(r.chunkLen))
+		if index >= len(r.chunkOffsets)-1 {
+			return n, io.EOF
+		}
+
+		chunk, err := r.chunk(index)
+		if err != nil {
+			return n, err
+		}
+
+		inChunk := int(pos % int64(r.chunkLen))
+		if inChunk >= len(chunk) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], chunk[inChunk:])
+	}
+	return n, nil
+}
+
+// chunk returns a decompressed chunk, using the cache when possible.
+func (r *Reader) chunk(index int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if data, ok := r.cache[index]; ok {
+		return data, nil
+	}
+
+	start, end := r.chunkOffsets[index], r.chunkOffsets[index+1]
+	compressed := make([]byte, end-start)
+	if _, err := r.file.ReadAt(compressed, start); err != nil {
+		return nil, fmt.Errorf("dictzip: failed to read chunk %d: %w", index, err)
+	}
+
+	// Chunks end with a full flush rather than a final block, so the
+	// decompressor reports an unexpected EOF after the chunk data.
+	fr := flate.NewReader(bytes.NewReader(compressed))
+	defer fr.Close()
+	data := make([]byte, r.chunkLen)
+	n, err := io.ReadFull(fr, data)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("dictzip: failed to decompress chunk %d: %w", index, err)
+	}
+	data = data[:n]
+
+	if len(r.order) >= chunkCacheSize {
+		delete(r.cache, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.cache[index] = data
+	r.order = append(r.order, index)
+
+	return data, nil
+}
+
+// Close closes the underlying file.
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,128 @@
+package dictzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testData(n int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"alpha ", "beta ", "gamma ", "delta\n", "词典 "}
+	var buf bytes.Buffer
+	for buf.Len() < n {
+		buf.WriteString(words[rng.Intn(len(words))])
+	}
+	return buf.Bytes()[:n]
+}
+
+func TestReadAtDictzip(t *testing.T) {
+	data := testData(3*DefaultChunkLen + 1234)
+	path := filepath.Join(t.TempDir(), "test.dict.dz")
+	if err := WriteFile(path, data); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	// The result must also be a valid gzip file
+	f, _ := os.Open(path)
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	all, err := io.ReadAll(gz)
+	f.Close()
+	if err != nil || !bytes.Equal(all, data) {
+		t.Fatalf("gzip round trip failed: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+	if r.data != nil {
+		t.Fatal("expected chunked access, got plain gzip fallback")
+	}
+
+	ranges := [][2]int{{0, 10}, {DefaultChunkLen - 5, 10}, {2*DefaultChunkLen - 100, DefaultChunkLen + 200}, {len(data) - 7, 7}}
+	for _, rg := range ranges {
+		p := make([]byte, rg[1])
+		if _, err := r.ReadAt(p, int64(rg[0])); err != nil {
+			t.Fatalf("ReadAt(%d, %d) failed: %v", rg[0], rg[1], err)
+		}
+		if !bytes.Equal(p, data[rg[0]:rg[0]+rg[1]]) {
+			t.Errorf("ReadAt(%d, %d) returned wrong data", rg[0], rg[1])
+		}
+	}
+
+	p := make([]byte, 10)
+	if n, err := r.ReadAt(p, int64(len(data)-3)); n != 3 || err != io.EOF {
+		t.Errorf("ReadAt past end = %d, %v", n, err)
+	}
+}
+
+func TestReadAtPlainGzip(t *testing.T) {
+	data := testData(5000)
+	path := filepath.Join(t.TempDir(), "plain.gz")
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Name = "plain"
+	gz.Write(data)
+	gz.Close()
+	os.WriteFile(path, buf.Bytes(), 0644)
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	p := make([]byte, 100)
+	if _, err := r.ReadAt(p, 1000); err != nil || !bytes.Equal(p, data[1000:1100]) {
+		t.Errorf("ReadAt failed: %v", err)
+	}
+}
+
+func TestOpenRejectsCorruptChunkTable(t *testing.T) {
+	cases := map[string]func(header []byte){
+		"zero chunk length": func(header []byte) { binary.LittleEndian.PutUint16(header[18:], 0) },
+		"chunk past end":    func(header []byte) { binary.LittleEndian.PutUint16(header[22:], 0xffff) },
+	}
+	for name, corrupt := range cases {
+		var buf bytes.Buffer
+		if err := Write(&buf, testData(100)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		content := buf.Bytes()
+		corrupt(content)
+		path := filepath.Join(t.TempDir(), "corrupt.dict.dz")
+		os.WriteFile(path, content, 0644)
+
+		if r, err := Open(path); err == nil {
+			r.Close()
+			t.Errorf("%s: Open should fail", name)
+		}
+	}
+}
+
+func TestWriteReaderMatchesWrite(t *testing.T) {
+	for _, size := range []int{0, 100, 2 * DefaultChunkLen, 2*DefaultChunkLen + 17} {
+		data := testData(size)
+		var want, got bytes.Buffer
+		if err := Write(&want, data); err != nil {
+			t.Fatalf("Write(%d bytes) failed: %v", size, err)
+		}
+		if err := WriteReader(&got, bytes.NewReader(data), t.TempDir()); err != nil {
+			t.Fatalf("WriteReader(%d bytes) failed: %v", size, err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Errorf("WriteReader(%d bytes) output differs from Write", size)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package dictzip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// DefaultChunkLen is the uncompressed chunk size used by dictzip. It is small
+// enough that a compressed chunk always fits the 16-bit chunk size field.
+const DefaultChunkLen = 58315
+
+// maxChunks is the number of chunk sizes that fit into the gzip extra field.
+const maxChunks = (0xffff - 10) / 2
+
+// Write compresses data into dictzip format. Every chunk is compressed with
+// its own deflate stream so that it can be decompressed independently.
+func Write(w io.Writer, data []byte) error {
+	var body bytes.Buffer
+	sizes, crc, length, err := compressChunks(&body, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return writeGzip(w, sizes, &body, crc, length)
+}
+
+// WriteReader compresses the content of r into dictzip format without
+// holding it in memory. The chunk table precedes the compressed data, so the
+// chunks are compressed into a temporary file in tempDir (the system default
+// when empty) first.
+func WriteReader(w io.Writer, r io.Reader, tempDir string) error {
+	body, err := os.CreateTemp(tempDir, "dictzip-*")
+	if err != nil {
+		return fmt.Errorf("dictzip: failed to create temporary file: %w", err)
+	}
+	defer func() {
+		body.Close()
+		os.Remove(body.Name())
+	}()
+
+	buffered := bufio.NewWriter(body)
+	sizes, crc, length, err := compressChunks(buffered, r)
+	if err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return writeGzip(w, sizes, body, crc, length)
+}
+
+// compressChunks compresses r into independently decompressible chunks
+// written to body. It returns the compressed chunk sizes and the CRC32 and
+// length of the uncompressed data.
+func compressChunks(body io.Writer, r io.Reader) ([]uint16, uint32, int64, error) {
+	var (
+		sizes  []uint16
+		crc    = crc32.NewIEEE()
+		length int64
+		out    = &countingWriter{w: body}
+	)
+
+	// The next chunk is read ahead to know whether the current one is the
+	// last, which terminates the deflate stream
+	current := make([]byte, DefaultChunkLen)
+	next := make([]byte, DefaultChunkLen)
+	n, err := io.ReadFull(r, current)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, 0, 0, err
+	}
+	for {
+		m := 0
+		if n == DefaultChunkLen {
+			if m, err = io.ReadFull(r, next); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, 0, 0, err
+			}
+		}
+		last := m == 0
+		if len(sizes) == maxChunks {
+			return nil, 0, 0, fmt.Errorf("dictzip: data too large (more than %d bytes)", int64(maxChunks)*DefaultChunkLen)
+		}
+
+		before := out.n
+		fw, err := flate.NewWriter(out, flate.BestCompression)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if _, err := fw.Write(current[:n]); err != nil {
+			return nil, 0, 0, err
+		}
+		// The last chunk terminates the deflate stream, the others are
+		// flushed to a byte boundary without a final block.
+		if last {
+			err = fw.Close()
+		} else {
+			err = fw.Flush()
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		sizes = append(sizes, uint16(out.n-before))
+		crc.Write(current[:n])
+		length += int64(n)
+
+		if last {
+			return sizes, crc.Sum32(), length, nil
+		}
+		current, next, n = next, current, m
+	}
+}
+
+// writeGzip writes the gzip header with the chunk table, the compressed
+// chunks from body and the gzip trailer.
+func writeGzip(w io.Writer, sizes []uint16, body io.Reader, crc uint32, length int64) error {
+	// RA subfield: version, chunk length, chunk count, chunk sizes
+	var ra bytes.Buffer
+	binary.Write(&ra, binary.LittleEndian, uint16(1))
+	binary.Write(&ra, binary.LittleEndian, uint16(DefaultChunkLen))
+	binary.Write(&ra, binary.LittleEndian, uint16(len(sizes)))
+	binary.Write(&ra, binary.LittleEndian, sizes)
+
+	var header bytes.Buffer
+	header.Write([]byte{0x1f, 0x8b, 8, flagExtra, 0, 0, 0, 0, 2, 3})
+	binary.Write(&header, binary.LittleEndian, uint16(4+ra.Len()))
+	header.Write([]byte{'R', 'A'})
+	binary.Write(&header, binary.LittleEndian, uint16(ra.Len()))
+	header.Write(ra.Bytes())
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		return err
+	}
+
+	// gzip trailer: CRC32 and size of the uncompressed data
+	trailer := make([]byte, 8)
+	binary.LittleEndian.PutUint32(trailer[0:], crc)
+	binary.LittleEndian.PutUint32(trailer[4:], uint32(length))
+	_, err := w.Write(trailer)
+	return err
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteFile compresses data into a dictzip file at path.
+func WriteFile(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
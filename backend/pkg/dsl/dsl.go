@@ -0,0 +1,405 @@
+// Package dsl reads ABBYY Lingvo DSL dictionaries.
+//
+// A DSL dictionary is a text file (.dsl, or dictzip compressed .dsl.dz),
+// usually encoded as UTF-16. Header lines start with '#', headword lines
+// start at the first column and the article body lines following them are
+// indented. Media files referenced with [s] tags are stored in a zip
+// archive (<name>.dsl.files.zip) or directory next to the dictionary.
+package dsl
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dict-hub/pkg/dictzip"
+)
+
+// ErrNotFound is returned when a word is not in the dictionary.
+var ErrNotFound = errors.New("dsl: word not found")
+
+// article is one dictionary article. The body is stored as a byte range of
+// the (uncompressed) file so that it can be read on demand.
+type article struct {
+	headword string // first headword, used for '~' substitution
+	offset   int64
+	size     int64
+}
+
+// key is a sorted lookup key.
+type key struct {
+	word    string
+	lower   string
+	article int
+}
+
+// Dict is an opened DSL dictionary. It is safe for concurrent use.
+type Dict struct {
+	path string
+
+	// Header values
+	Name             string
+	IndexLanguage    string
+	ContentsLanguage string
+	Annotation       string
+
+	enc      *encoding
+	articles []article
+	keys     []key
+
+	data   io.ReaderAt
+	closer io.Closer
+
+	resZip *zip.ReadCloser
+	resDir string
+	resMap map[string]*zip.File // lower case name -> file
+}
+
+// Open opens a .dsl or .dsl.dz file and builds the headword index.
+func Open(path string) (*Dict, error) {
+	d := &Dict{path: path}
+
+	if err := d.buildIndex(); err != nil {
+		return nil, fmt.Errorf("dsl: %s: %w", path, err)
+	}
+
+	if strings.HasSuffix(strings.ToLower(path), ".dz") {
+		r, err := dictzip.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		d.data, d.closer = r, r
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		d.data, d.closer = file, file
+	}
+
+	d.openResources()
+	d.readAnnotation()
+
+	sort.SliceStable(d.keys, func(i, j int) bool {
+		return d.keys[i].lower < d.keys[j].lower
+	})
+	return d, nil
+}
+
+// basePath returns the path without the .dsl or .dsl.dz extension.
+func (d *Dict) basePath() string {
+	path := d.path
+	if strings.HasSuffix(strings.ToLower(path), ".dz") {
+		path = path[:len(path)-3]
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// openStream opens the uncompressed content for sequential reading.
+func (d *Dict) openStream() (io.ReadCloser, error) {
+	file, err := os.Open(d.path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(d.path), ".dz") {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, file}, nil
+}
+
+// buildIndex reads the file once, parsing the header and recording the
+// headwords and body range of every article.
+func (d *Dict) buildIndex() error {
+	stream, err := d.openStream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	br := bufio.NewReaderSize(stream, 64*1024)
+	head, _ := br.Peek(4)
+	enc, bomSize := detectEncoding(head)
+	if _, err := br.Discard(bomSize); err != nil {
+		return err
+	}
+
+	lr := &lineReader{r: br, enc: enc, offset: int64(bomSize)}
+	var (
+		headwords []string // headwords waiting for their body
+		bodyStart int64 = -1
+		bodyEnd   int64
+		inHeader  = true
+	)
+
+	finish := func() {
+		if len(headwords) == 0 || bodyStart < 0 {
+			return
+		}
+		index := len(d.articles)
+		first := ""
+		for _, hw := range headwords {
+			display, variants := parseHeadword(hw)
+			if first == "" {
+				first = display
+			}
+			for _, v := range variants {
+				d.keys = append(d.keys, key{word: v, lower: strings.ToLower(v), article: index})
+			}
+		}
+		d.articles = append(d.articles, article{headword: first, offset: bodyStart, size: bodyEnd - bodyStart})
+		headwords, bodyStart = nil, -1
+	}
+
+	for {
+		raw, start, err := lr.next()
+		if len(raw) > 0 {
+			switch {
+			case enc.isBlank(raw):
+				// Empty lines separate articles but belong to no one
+			case enc.isIndented(raw):
+				if len(headwords) > 0 {
+					if bodyStart < 0 {
+						bodyStart = start
+					}
+					bodyEnd = start + int64(len(raw))
+				}
+			default:
+				line := strings.TrimRight(enc.decode(raw), "\r\n")
+				if inHeader && strings.HasPrefix(line, "#") {
+					d.parseHeaderLine(line, enc)
+					break
+				}
+				inHeader = false
+				// A headword after a body starts a new article
+				if bodyStart >= 0 {
+					finish()
+				}
+				if hw := strings.TrimSpace(line); hw != "" {
+					headwords = append(headwords, hw)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	finish()
+
+	d.enc = enc
+	return nil
+}
+
+// parseHeaderLine parses a "#NAME "value"" header line. A source code page
+// header switches single-byte files to the right charset.
+func (d *Dict) parseHeaderLine(line string, enc *encoding) {
+	name, value, _ := strings.Cut(strings.TrimPrefix(line, "#"), " ")
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	switch strings.ToUpper(name) {
+	case "NAME":
+		d.Name = value
+	case "INDEX_LANGUAGE":
+		d.IndexLanguage = value
+	case "CONTENTS_LANGUAGE":
+		d.ContentsLanguage = value
+	case "SOURCE_CODE_PAGE":
+		enc.setCodePage(value)
+	}
+}
+
+// readAnnotation loads the optional .ann file.
+func (d *Dict) readAnnotation() {
+	data, err := os.ReadFile(d.basePath() + ".ann")
+	if err != nil {
+		return
+	}
+	enc, bomSize := detectEncoding(data)
+	d.Annotation = strings.TrimSpace(enc.decode(data[bomSize:]))
+}
+
+// Path returns the dictionary file path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Len returns the number of articles.
+func (d *Dict) Len() int {
+	return len(d.articles)
+}
+
+// readArticle reads and renders the article at index.
+func (d *Dict) readArticle(index int) (string, error) {
+	a := d.articles[index]
+	raw := make([]byte, a.size)
+	if _, err := d.data.ReadAt(raw, a.offset); err != nil && err != io.EOF {
+		return "", fmt.Errorf("dsl: failed to read article %q: %w", a.headword, err)
+	}
+	return RenderHTML(d.enc.decode(raw), a.headword), nil
+}
+
+// find returns the range of keys equal to word (case-insensitive).
+func (d *Dict) find(word string) (int, int) {
+	lower := strings.ToLower(strings.TrimSpace(word))
+	start := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= lower
+	})
+	end := start
+	for end < len(d.keys) && d.keys[end].lower == lower {
+		end++
+	}
+	return start, end
+}
+
+// Lookup returns the articles for word rendered as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	start, end := d.find(word)
+	if start == end {
+		return nil, ErrNotFound
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[int]bool)
+	for _, k := range d.keys[start:end] {
+		if seen[k.article] {
+			continue
+		}
+		seen[k.article] = true
+
+		html, err := d.readArticle(k.article)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("<hr>")
+		}
+		buf.WriteString(html)
+	}
+	return buf.Bytes(), nil
+}
+
+// Suggest returns up to limit headwords starting with prefix (case-insensitive).
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every article in file order with its first headword
+// and the rendered HTML. Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for i, a := range d.articles {
+		html, err := d.readArticle(i)
+		if err != nil {
+			return err
+		}
+		if err := fn(a.headword, []byte(html)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openResources looks for a resource archive or directory.
+func (d *Dict) openResources() {
+	base := d.basePath()
+	for _, name := range []string{base + ".dsl.files.zip", base + ".files.zip"} {
+		zr, err := zip.OpenReader(name)
+		if err != nil {
+			continue
+		}
+		d.resZip = zr
+		d.resMap = make(map[string]*zip.File, len(zr.File))
+		for _, f := range zr.File {
+			d.resMap[strings.ToLower(filepath.Base(f.Name))] = f
+			d.resMap[strings.ToLower(f.Name)] = f
+		}
+		return
+	}
+	for _, name := range []string{base + ".dsl.files", base + ".files"} {
+		if info, err := os.Stat(name); err == nil && info.IsDir() {
+			d.resDir = name
+			return
+		}
+	}
+}
+
+// HasResources reports whether a resource archive or directory was found.
+func (d *Dict) HasResources() bool {
+	return d.resZip != nil || d.resDir != ""
+}
+
+// Resource reads a media file referenced by the dictionary.
+func (d *Dict) Resource(name string) ([]byte, error) {
+	name = strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/")
+
+	if d.resZip != nil {
+		f, ok := d.resMap[strings.ToLower(name)]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	if d.resDir != "" {
+		path := filepath.Join(d.resDir, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(d.resDir, path); err != nil || strings.HasPrefix(rel, "..") {
+			return nil, os.ErrNotExist
+		}
+		return os.ReadFile(path)
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// Close closes the dictionary and resource files.
+func (d *Dict) Close() error {
+	if d.resZip != nil {
+		d.resZip.Close()
+	}
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package dsl
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"dict-hub/pkg/dictzip"
+)
+
+const testDSL = `#NAME "Test Dictionary"
+#INDEX_LANGUAGE "English"
+#CONTENTS_LANGUAGE "Russian"
+
+apple
+	[m1][b]1.[/b] [trn]яблоко[/trn][/m]
+	[m2][ex][*]an ~ a day[/*][/ex][/m]
+	[m1][s]apple.png[/s] [s]apple.wav[/s][/m]
+colo(u)r
+	[m1][c red]цвет[/c] {{comment}}see [ref]hue[/ref] or <<tint>>[/m]
+hue
+tint
+	[m1][i]оттенок[/i] \[1\][/m]
+`
+
+func encodeUTF16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := []byte{0xff, 0xfe}
+	for _, u := range units {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+func TestOpenUTF16(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.dsl")
+	os.WriteFile(path, encodeUTF16LE(testDSL), 0644)
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.Name != "Test Dictionary" || d.IndexLanguage != "English" {
+		t.Errorf("unexpected header: %q %q", d.Name, d.IndexLanguage)
+	}
+	if d.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", d.Len())
+	}
+
+	got, err := d.Lookup("Apple")
+	if err != nil {
+		t.Fatalf("Lookup(Apple) failed: %v", err)
+	}
+	for _, want := range []string{
+		`<b>1.</b>`,
+		`<span class="dsl-trn">яблоко</span>`,
+		`padding-left:2em`,
+		`an apple a day`,
+		`<img src="apple.png">`,
+		`href="sound://apple.wav"`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(Apple) = %q, missing %q", got, want)
+		}
+	}
+
+	// Optional parts are indexed both ways
+	for _, word := range []string{"color", "colour"} {
+		got, err := d.Lookup(word)
+		if err != nil {
+			t.Fatalf("Lookup(%s) failed: %v", word, err)
+		}
+		for _, want := range []string{`<span style="color:red">цвет</span>`, `href="entry://hue"`, `href="entry://tint"`} {
+			if !strings.Contains(string(got), want) {
+				t.Errorf("Lookup(%s) = %q, missing %q", word, got, want)
+			}
+		}
+		if strings.Contains(string(got), "comment") {
+			t.Errorf("Lookup(%s) contains comment: %q", word, got)
+		}
+	}
+
+	// Consecutive headwords share one article
+	hue, _ := d.Lookup("hue")
+	tint, _ := d.Lookup("tint")
+	if string(hue) != string(tint) || !strings.Contains(string(hue), "</i> [1]") {
+		t.Errorf("Lookup(hue) = %q, Lookup(tint) = %q", hue, tint)
+	}
+
+	if got := d.Suggest("col", 10); len(got) != 2 {
+		t.Errorf("Suggest(col) = %v", got)
+	}
+}
+
+func TestOpenDictzipWithResources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.dsl.dz")
+	if err := dictzip.WriteFile(path, []byte(testDSL)); err != nil {
+		t.Fatalf("dictzip.WriteFile failed: %v", err)
+	}
+
+	zf, _ := os.Create(filepath.Join(dir, "test.dsl.files.zip"))
+	zw := zip.NewWriter(zf)
+	w, _ := zw.Create("apple.png")
+	w.Write([]byte("PNG"))
+	zw.Close()
+	zf.Close()
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if got, err := d.Lookup("tint"); err != nil || !strings.Contains(string(got), "оттенок") {
+		t.Errorf("Lookup(tint) = %q, %v", got, err)
+	}
+	if !d.HasResources() {
+		t.Fatal("HasResources() = false")
+	}
+	if data, err := d.Resource("/Apple.png"); err != nil || string(data) != "PNG" {
+		t.Errorf("Resource = %q, %v", data, err)
+	}
+}
+
+func TestParseHeadword(t *testing.T) {
+	cases := []struct {
+		raw     string
+		display string
+		keys    []string
+	}{
+		{"simple", "simple", []string{"simple"}},
+		{`a\(b\)`, "a(b)", []string{"a(b)"}},
+		{"{to }go", "to go", []string{"go"}},
+		{"colo(u)r", "colo(u)r", []string{"colour", "color"}},
+	}
+	for _, c := range cases {
+		display, keys := parseHeadword(c.raw)
+		if display != c.display || strings.Join(keys, "|") != strings.Join(c.keys, "|") {
+			t.Errorf("parseHeadword(%q) = %q, %v; want %q, %v", c.raw, display, keys, c.display, c.keys)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package dsl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// encoding describes the text encoding of a DSL file.
+type encoding struct {
+	unit      int  // code unit size: 1 for UTF-8 and code pages, 2 for UTF-16
+	bigEndian bool // UTF-16 byte order
+	charmap   *charmap.Charmap
+}
+
+// codePages maps #SOURCE_CODE_PAGE values to charsets.
+var codePages = map[string]*charmap.Charmap{
+	"latin":           charmap.Windows1252,
+	"cyrillic":        charmap.Windows1251,
+	"easterneuropean": charmap.Windows1250,
+	"greek":           charmap.Windows1253,
+	"turkish":         charmap.Windows1254,
+	"baltic":          charmap.Windows1257,
+}
+
+// detectEncoding detects the encoding from the first bytes of a file and
+// returns it together with the size of the byte order mark.
+func detectEncoding(head []byte) (*encoding, int) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		return &encoding{unit: 2}, 2
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		return &encoding{unit: 2, bigEndian: true}, 2
+	case bytes.HasPrefix(head, []byte{0xef, 0xbb, 0xbf}):
+		return &encoding{unit: 1}, 3
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		return &encoding{unit: 2}, 0
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		return &encoding{unit: 2, bigEndian: true}, 0
+	}
+	return &encoding{unit: 1}, 0
+}
+
+// setCodePage selects the charset for files that are not Unicode.
+func (e *encoding) setCodePage(name string) {
+	if e.unit != 1 {
+		return
+	}
+	if cm, ok := codePages[strings.ToLower(name)]; ok {
+		e.charmap = cm
+	}
+}
+
+// decode converts raw bytes to a UTF-8 string.
+func (e *encoding) decode(raw []byte) string {
+	if e.unit == 2 {
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			if e.bigEndian {
+				units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+			} else {
+				units[i] = uint16(raw[2*i]) | uint16(raw[2*i+1])<<8
+			}
+		}
+		return string(utf16.Decode(units))
+	}
+
+	if e.charmap == nil && utf8.Valid(raw) {
+		return string(raw)
+	}
+	cm := e.charmap
+	if cm == nil {
+		cm = charmap.Windows1252
+	}
+	out, err := cm.NewDecoder().Bytes(raw)
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
+
+// firstUnit returns the first code unit of a raw line.
+func (e *encoding) firstUnit(raw []byte) uint16 {
+	if e.unit == 2 {
+		if len(raw) < 2 {
+			return 0
+		}
+		if e.bigEndian {
+			return uint16(raw[0])<<8 | uint16(raw[1])
+		}
+		return uint16(raw[0]) | uint16(raw[1])<<8
+	}
+	if len(raw) == 0 {
+		return 0
+	}
+	return uint16(raw[0])
+}
+
+// isIndented reports whether a raw line starts with a space or tab, which
+// marks an article body line.
+func (e *encoding) isIndented(raw []byte) bool {
+	u := e.firstUnit(raw)
+	return u == ' ' || u == '\t'
+}
+
+// isBlank reports whether a raw line only contains a line break.
+func (e *encoding) isBlank(raw []byte) bool {
+	for i := 0; i+e.unit <= len(raw); i += e.unit {
+		var u uint16
+		if e.unit == 2 {
+			if e.bigEndian {
+				u = uint16(raw[i])<<8 | uint16(raw[i+1])
+			} else {
+				u = uint16(raw[i]) | uint16(raw[i+1])<<8
+			}
+		} else {
+			u = uint16(raw[i])
+		}
+		if u != '\r' && u != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// lineReader splits raw encoded content into lines and tracks offsets.
+type lineReader struct {
+	r      *bufio.Reader
+	enc    *encoding
+	offset int64
+}
+
+// next returns the next raw line including the line break and its offset.
+// For UTF-16 a '\n' byte only ends the line when it forms a whole code unit.
+func (lr *lineReader) next() ([]byte, int64, error) {
+	start := lr.offset
+	var line []byte
+	for {
+		chunk, err := lr.r.ReadBytes('\n')
+		line = append(line, chunk...)
+		if err != nil {
+			lr.offset += int64(len(line))
+			return line, start, err
+		}
+		if lr.enc.unit == 1 {
+			break
+		}
+
+		if lr.enc.bigEndian {
+			// 0x00 0x0a at an even position
+			if len(line)%2 == 0 && line[len(line)-2] == 0 {
+				break
+			}
+			continue
+		}
+
+		// Little endian: 0x0a 0x00 with the 0x0a at an even position
+		if len(line)%2 == 1 {
+			b, err := lr.r.ReadByte()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				lr.offset += int64(len(line))
+				return line, start, err
+			}
+			line = append(line, b)
+			if b == 0 {
+				break
+			}
+		}
+	}
+	lr.offset += int64(len(line))
+	return line, start, nil
+}
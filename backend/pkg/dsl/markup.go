@@ -0,0 +1,357 @@
+package dsl
+
+import (
+	"html"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxOptionalParts limits the number of optional headword parts that are
+// expanded into index variants.
+const maxOptionalParts = 4
+
+// parseHeadword returns the display form of a headword line and the index
+// keys it expands to. Escapes are resolved, {unsorted} parts are shown but
+// not indexed, and (optional) parts are indexed both with and without.
+func parseHeadword(raw string) (string, []string) {
+	type segment struct {
+		text     string
+		optional bool
+	}
+
+	raw = stripComments(raw)
+
+	var (
+		display  strings.Builder
+		segments []segment
+		current  strings.Builder
+		braces   int
+		optional bool
+	)
+	flush := func() {
+		if current.Len() > 0 || optional {
+			segments = append(segments, segment{text: current.String(), optional: optional})
+			current.Reset()
+		}
+	}
+
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			display.WriteRune(runes[i])
+			if braces == 0 {
+				current.WriteRune(runes[i])
+			}
+		case r == '{':
+			braces++
+		case r == '}':
+			if braces > 0 {
+				braces--
+			}
+		case braces > 0:
+			display.WriteRune(r)
+		case r == '(' && !optional:
+			flush()
+			optional = true
+			display.WriteRune(r)
+		case r == ')' && optional:
+			flush()
+			optional = false
+			display.WriteRune(r)
+		default:
+			display.WriteRune(r)
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	var optionals []int
+	for i, s := range segments {
+		if s.optional {
+			optionals = append(optionals, i)
+		}
+	}
+	if len(optionals) > maxOptionalParts {
+		// Too many combinations, keep the optional parts as plain text
+		for _, i := range optionals {
+			segments[i].optional = false
+		}
+		optionals = nil
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for mask := 0; mask < 1<<len(optionals); mask++ {
+		var sb strings.Builder
+		n := 0
+		for _, s := range segments {
+			if s.optional {
+				include := mask&(1<<n) == 0
+				n++
+				if !include {
+					continue
+				}
+			}
+			sb.WriteString(s.text)
+		}
+		key := strings.Join(strings.Fields(sb.String()), " ")
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	return strings.Join(strings.Fields(display.String()), " "), keys
+}
+
+// stripComments removes {{...}} comments.
+func stripComments(s string) string {
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start+2:], "}}")
+		if end < 0 {
+			return s[:start]
+		}
+		s = s[:start] + s[start+2+end+2:]
+	}
+}
+
+// inlineTag is an open inline tag with its HTML.
+type inlineTag struct {
+	name  string
+	open  string
+	close string
+}
+
+// tagHTML returns the HTML for an inline DSL tag, or false for tags that
+// are dropped.
+func tagHTML(name, arg string) (string, string, bool) {
+	switch name {
+	case "b", "i", "u", "sub", "sup":
+		return "<" + name + ">", "</" + name + ">", true
+	case "c":
+		color := "green"
+		if arg != "" && isSafeColor(arg) {
+			color = arg
+		}
+		return `<span style="color:` + color + `">`, "</span>", true
+	case "ex":
+		return `<span class="dsl-ex">`, "</span>", true
+	case "com":
+		return `<span class="dsl-com">`, "</span>", true
+	case "trn", "trn1", "!trs", "trs":
+		return `<span class="dsl-trn">`, "</span>", true
+	case "*":
+		return `<span class="dsl-sec">`, "</span>", true
+	case "p":
+		return `<i class="dsl-p">`, "</i>", true
+	case "t":
+		return `<span class="dsl-t">`, "</span>", true
+	case "'":
+		return `<span class="dsl-stress">`, "</span>", true
+	case "lang":
+		return "<span>", "</span>", true
+	}
+	return "", "", false
+}
+
+// isSafeColor reports whether a color name can be put into a style attribute.
+func isSafeColor(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '#') {
+			return false
+		}
+	}
+	return true
+}
+
+// mediaHTML renders an [s] tag.
+func mediaHTML(name string) string {
+	escaped := html.EscapeString(name)
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".svg", ".webp", ".tif", ".tiff":
+		return `<img src="` + escaped + `">`
+	case ".wav", ".mp3", ".ogg", ".oga", ".spx", ".opus", ".m4a":
+		return `<a class="sound" href="sound://` + escaped + `">&#128266;</a>`
+	}
+	return `<a href="` + escaped + `">` + escaped + `</a>`
+}
+
+// linkHTML renders a link to another headword.
+func linkHTML(word string) string {
+	word = strings.TrimSpace(word)
+	return `<a href="entry://` + html.EscapeString(word) + `">` + html.EscapeString(word) + `</a>`
+}
+
+// RenderHTML converts the body of a DSL article to HTML. headword replaces
+// the '~' placeholder. Every body line becomes a block; [mN] sets its
+// indentation. Inline tags left open at the end of a line are closed and
+// reopened on the next line so that the output stays well formed.
+func RenderHTML(body, headword string) string {
+	var (
+		out   strings.Builder
+		stack []inlineTag
+	)
+
+	body = stripComments(body)
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.TrimRight(line, "\r"))
+		if line == "" {
+			continue
+		}
+
+		// Leading [m] or [mN] sets the margin of the line
+		margin := 0
+		if strings.HasPrefix(line, "[m") {
+			if end := strings.IndexByte(line, ']'); end > 0 {
+				level := line[2:end]
+				if n, err := strconv.Atoi(level); err == nil && n >= 0 && n <= 9 {
+					margin = n
+					line = line[end+1:]
+				} else if level == "" {
+					line = line[end+1:]
+				}
+			}
+		}
+
+		out.WriteString(`<div class="dsl-m` + strconv.Itoa(margin) + `" style="padding-left:` + strconv.Itoa(margin) + `em">`)
+		for _, t := range stack {
+			out.WriteString(t.open)
+		}
+		stack = renderLine(&out, line, headword, stack)
+		for i := len(stack) - 1; i >= 0; i-- {
+			out.WriteString(stack[i].close)
+		}
+		out.WriteString("</div>")
+	}
+	return out.String()
+}
+
+// renderLine renders the inline markup of one line and returns the tags
+// that are still open.
+func renderLine(out *strings.Builder, line, headword string, stack []inlineTag) []inlineTag {
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			out.WriteString(html.EscapeString(string(runes[i])))
+
+		case r == '~':
+			out.WriteString(html.EscapeString(headword))
+
+		case r == '<' && i+1 < len(runes) && runes[i+1] == '<':
+			end := indexRunes(runes, i+2, ">>")
+			if end < 0 {
+				out.WriteString("&lt;")
+				continue
+			}
+			out.WriteString(linkHTML(string(runes[i+2 : end])))
+			i = end + 1
+
+		case r == '[':
+			end := indexRunes(runes, i+1, "]")
+			if end < 0 {
+				out.WriteString("[")
+				continue
+			}
+			tag := string(runes[i+1 : end])
+			i = end
+
+			closing := strings.HasPrefix(tag, "/")
+			name, arg, _ := strings.Cut(strings.TrimPrefix(tag, "/"), " ")
+			if closing {
+				stack = closeTag(out, stack, name)
+				continue
+			}
+
+			// Tags whose content is a name rather than text
+			switch name {
+			case "s", "ref", "url", "video":
+				closeAt := indexRunes(runes, i+1, "[/"+name+"]")
+				if closeAt < 0 {
+					closeAt = len(runes)
+				}
+				content := unescape(string(runes[i+1 : closeAt]))
+				switch name {
+				case "s", "video":
+					out.WriteString(mediaHTML(strings.TrimSpace(content)))
+				case "ref":
+					out.WriteString(linkHTML(content))
+				case "url":
+					u := html.EscapeString(strings.TrimSpace(content))
+					out.WriteString(`<a href="` + u + `">` + u + `</a>`)
+				}
+				i = closeAt + len(name) + 2
+				continue
+			}
+
+			if open, close, ok := tagHTML(name, strings.TrimSpace(arg)); ok {
+				out.WriteString(open)
+				stack = append(stack, inlineTag{name: name, open: open, close: close})
+			}
+
+		default:
+			out.WriteString(html.EscapeString(string(r)))
+		}
+	}
+	return stack
+}
+
+// closeTag closes the innermost open tag with the given name, together with
+// any tags opened after it.
+func closeTag(out *strings.Builder, stack []inlineTag, name string) []inlineTag {
+	if name == "m" {
+		return stack
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].name != name {
+			continue
+		}
+		for j := len(stack) - 1; j >= i; j-- {
+			out.WriteString(stack[j].close)
+		}
+		// Reopen tags that were closed only because of nesting
+		for _, t := range stack[i+1:] {
+			out.WriteString(t.open)
+		}
+		return append(stack[:i], stack[i+1:]...)
+	}
+	return stack
+}
+
+// indexRunes finds sub in runes starting at from.
+func indexRunes(runes []rune, from int, sub string) int {
+	if from > len(runes) {
+		return -1
+	}
+	idx := strings.Index(string(runes[from:]), sub)
+	if idx < 0 {
+		return -1
+	}
+	return from + len([]rune(string(runes[from:])[:idx]))
+}
+
+// unescape resolves backslash escapes.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+		}
+		sb.WriteRune(runes[i])
+	}
+	return sb.String()
+}
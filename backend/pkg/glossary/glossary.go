@@ -0,0 +1,451 @@
+// Package glossary loads simple glossaries kept in spreadsheets or JSON:
+// CSV, TSV, a JSON array of objects or JSON Lines. Columns are mapped to
+// headword, definition, aliases and tags through an optional mapping file
+// next to the glossary (see Mapping). Definitions may be Markdown, HTML or
+// plain text. The file is reloaded when it changes on disk.
+package glossary
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+var (
+	// ErrNotFound is returned when a word is not in the glossary.
+	ErrNotFound = errors.New("glossary: word not found")
+	// ErrUnsupportedFile is returned for files that are not CSV, TSV, JSON
+	// or JSON Lines.
+	ErrUnsupportedFile = errors.New("glossary: unsupported file type")
+)
+
+// reloadCheckInterval limits how often the file is checked for changes.
+const reloadCheckInterval = 2 * time.Second
+
+// Entry is a glossary entry.
+type Entry struct {
+	Headword   string
+	Definition string // as written in the file
+	Aliases    []string
+	Tags       []string
+
+	html string // rendered definition
+}
+
+// key is a sorted lookup key pointing to an entry.
+type key struct {
+	word  string
+	lower string
+	index int
+}
+
+// Dict is a loaded glossary. It is safe for concurrent use.
+type Dict struct {
+	path string
+
+	mu        sync.RWMutex
+	mapping   Mapping
+	entries   []Entry
+	keys      []key
+	modTime   time.Time
+	mapTime   time.Time
+	lastCheck time.Time
+}
+
+// IsGlossary reports whether path looks like a glossary file. JSON files
+// must hold an array and JSON Lines files objects, which excludes mapping
+// files and other JSON documents.
+func IsGlossary(path string) bool {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, MappingSuffix) {
+		return false
+	}
+	switch filepath.Ext(lower) {
+	case ".csv", ".tsv":
+		return true
+	case ".json":
+		return firstByte(path) == '['
+	case ".jsonl":
+		return firstByte(path) == '{'
+	}
+	return false
+}
+
+// firstByte returns the first non-space byte of a file.
+func firstByte(path string) byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0
+		}
+		// Skip whitespace and a UTF-8 BOM
+		if b < 0x80 && strings.IndexByte(" \t\r\n", b) < 0 {
+			return b
+		}
+	}
+}
+
+// Open loads a glossary and its mapping file.
+func Open(path string) (*Dict, error) {
+	d := &Dict{path: path}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// load reads the mapping and the glossary file.
+func (d *Dict) load() error {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+	var mapTime time.Time
+	if info, err := os.Stat(MappingPath(d.path)); err == nil {
+		mapTime = info.ModTime()
+	}
+
+	mapping, err := LoadMapping(d.path)
+	if err != nil {
+		return fmt.Errorf("glossary: %s: %w", MappingPath(d.path), err)
+	}
+	mapping = mapping.normalize()
+
+	entries, err := readEntries(d.path, mapping)
+	if err != nil {
+		return fmt.Errorf("glossary: %s: %w", d.path, err)
+	}
+
+	keys := make([]key, 0, len(entries))
+	for i := range entries {
+		entries[i].html = renderBody(entries[i].Definition, mapping.BodyFormat)
+		keys = append(keys, key{word: entries[i].Headword, lower: strings.ToLower(entries[i].Headword), index: i})
+		for _, alias := range entries[i].Aliases {
+			keys = append(keys, key{word: alias, lower: strings.ToLower(alias), index: i})
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].lower < keys[j].lower
+	})
+
+	d.mu.Lock()
+	d.mapping = mapping
+	d.entries = entries
+	d.keys = keys
+	d.modTime = info.ModTime()
+	d.mapTime = mapTime
+	d.lastCheck = time.Now()
+	d.mu.Unlock()
+	return nil
+}
+
+// reloadIfChanged reloads the glossary when the file or its mapping changed.
+// A failed reload (e.g. a file still being written) keeps the old content
+// and is retried on the next check.
+func (d *Dict) reloadIfChanged() {
+	d.mu.Lock()
+	if time.Since(d.lastCheck) < reloadCheckInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastCheck = time.Now()
+	modTime, mapTime := d.modTime, d.mapTime
+	d.mu.Unlock()
+
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return
+	}
+	var newMapTime time.Time
+	if info, err := os.Stat(MappingPath(d.path)); err == nil {
+		newMapTime = info.ModTime()
+	}
+	if info.ModTime().Equal(modTime) && newMapTime.Equal(mapTime) {
+		return
+	}
+	d.load()
+}
+
+// Reload forces the glossary to be read again.
+func (d *Dict) Reload() error {
+	return d.load()
+}
+
+// readEntries parses the glossary file.
+func readEntries(path string, m Mapping) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return readCSV(data, ',', m)
+	case ".tsv":
+		return readCSV(data, '\t', m)
+	case ".json":
+		var objects []map[string]any
+		if err := json.Unmarshal(data, &objects); err != nil {
+			return nil, err
+		}
+		return objectEntries(objects, m), nil
+	case ".jsonl":
+		var objects []map[string]any
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var obj map[string]any
+			if err := dec.Decode(&obj); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			objects = append(objects, obj)
+		}
+		return objectEntries(objects, m), nil
+	}
+	return nil, ErrUnsupportedFile
+}
+
+// readCSV parses CSV or TSV rows.
+func readCSV(data []byte, comma rune, m Mapping) ([]Entry, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	if m.Delimiter != "" {
+		r.Comma = []rune(m.Delimiter)[0]
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var header []string
+	if !m.NoHeader {
+		header, rows = rows[0], rows[1:]
+	}
+	cols := m.resolveColumns(header)
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		e := Entry{
+			Headword:   cell(row, cols.headword),
+			Definition: cell(row, cols.definition),
+			Aliases:    split(cell(row, cols.aliases), m.AliasSeparator),
+			Tags:       split(cell(row, cols.tags), m.TagSeparator),
+		}
+		if e.Headword != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// objectEntries maps JSON objects to entries.
+func objectEntries(objects []map[string]any, m Mapping) []Entry {
+	entries := make([]Entry, 0, len(objects))
+	for _, obj := range objects {
+		e := Entry{
+			Headword:   strings.TrimSpace(field(obj, m.Headword, headwordColumns, "")),
+			Definition: strings.TrimSpace(field(obj, m.Definition, definitionColumns, "\n\n")),
+			Aliases:    split(field(obj, m.Aliases, aliasColumns, m.AliasSeparator), m.AliasSeparator),
+			Tags:       split(field(obj, m.Tags, tagColumns, m.TagSeparator), m.TagSeparator),
+		}
+		if e.Headword != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// split splits a multi-valued cell and drops empty values.
+func split(s, sep string) []string {
+	var values []string
+	for _, v := range strings.Split(s, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// renderBody converts a definition to HTML.
+func renderBody(body, format string) string {
+	switch format {
+	case BodyHTML:
+		return body
+	case BodyText:
+		return strings.ReplaceAll(html.EscapeString(body), "\n", "<br>")
+	}
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(body), &buf); err != nil {
+		return html.EscapeString(body)
+	}
+	return buf.String()
+}
+
+// renderEntry renders an entry as HTML.
+func renderEntry(sb *strings.Builder, e *Entry) {
+	sb.WriteString(`<div class="glossary-entry"><div class="glossary-head"><b>` + html.EscapeString(e.Headword) + "</b>")
+	for _, tag := range e.Tags {
+		sb.WriteString(` <span class="tag">` + html.EscapeString(tag) + "</span>")
+	}
+	sb.WriteString(`</div><div class="glossary-body">` + e.html + "</div>")
+	if len(e.Aliases) > 0 {
+		aliases := make([]string, len(e.Aliases))
+		for i, a := range e.Aliases {
+			aliases[i] = html.EscapeString(a)
+		}
+		sb.WriteString(`<div class="glossary-aliases">` + strings.Join(aliases, ", ") + "</div>")
+	}
+	sb.WriteString("</div>")
+}
+
+// Name returns the file name without extension.
+func (d *Dict) Name() string {
+	return trimExt(filepath.Base(d.path))
+}
+
+// Path returns the glossary path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Title returns the title from the mapping file.
+func (d *Dict) Title() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.mapping.Title
+}
+
+// Description returns the description from the mapping file.
+func (d *Dict) Description() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.mapping.Description
+}
+
+// Len returns the number of entries.
+func (d *Dict) Len() int {
+	d.reloadIfChanged()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.entries)
+}
+
+// Entries returns a copy of all entries in file order.
+func (d *Dict) Entries() []Entry {
+	d.reloadIfChanged()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Entry(nil), d.entries...)
+}
+
+// Lookup finds entries by headword or alias, case-insensitively, and
+// renders them as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	d.reloadIfChanged()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	lower := strings.ToLower(strings.TrimSpace(word))
+	i := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= lower
+	})
+
+	var sb strings.Builder
+	seen := make(map[int]bool)
+	for ; i < len(d.keys) && d.keys[i].lower == lower; i++ {
+		index := d.keys[i].index
+		if seen[index] {
+			continue
+		}
+		if len(seen) > 0 {
+			sb.WriteString("<hr>")
+		}
+		seen[index] = true
+		renderEntry(&sb, &d.entries[index])
+	}
+	if len(seen) == 0 {
+		return nil, ErrNotFound
+	}
+	return []byte(sb.String()), nil
+}
+
+// Suggest returns up to limit headwords and aliases starting with prefix.
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	d.reloadIfChanged()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every entry in file order with the rendered HTML.
+// Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for _, e := range d.Entries() {
+		var sb strings.Builder
+		renderEntry(&sb, &e)
+		if err := fn(e.Headword, []byte(sb.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close is a no-op; the file is read into memory on load.
+func (d *Dict) Close() error {
+	return nil
+}
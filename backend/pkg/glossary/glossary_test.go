@@ -0,0 +1,118 @@
+package glossary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSVDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terms.csv")
+	writeFile(t, path, "\ufeffTerm,Definition,Aliases,Tags\n"+
+		"API,\"An **application** programming interface, see [REST](entry://REST).\",Application Programming Interface|apis,\"web, core\"\n"+
+		"REST,Representational state transfer,,\n"+
+		",missing headword,,\n")
+
+	if !IsGlossary(path) {
+		t.Fatal("IsGlossary = false")
+	}
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if d.Len() != 2 || d.Name() != "terms" {
+		t.Errorf("Len() = %d, Name() = %q", d.Len(), d.Name())
+	}
+
+	got, err := d.Lookup("apis")
+	if err != nil {
+		t.Fatalf("Lookup(apis) failed: %v", err)
+	}
+	for _, want := range []string{"<b>API</b>", "<strong>application</strong>", `<a href="entry://REST">REST</a>`, `<span class="tag">web</span>`, "Application Programming Interface"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(apis) = %s, missing %q", got, want)
+		}
+	}
+	if _, err := d.Lookup("SOAP"); err != ErrNotFound {
+		t.Errorf("Lookup(SOAP) error = %v", err)
+	}
+
+	if s := d.Suggest("ap", 10); len(s) != 3 || s[0] != "API" {
+		t.Errorf("Suggest(ap) = %v", s)
+	}
+}
+
+func TestMappingAndReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "glossary.tsv")
+	writeFile(t, path, "a\t<i>first</i>\tx\n")
+	writeFile(t, filepath.Join(dir, "glossary"+MappingSuffix), `{"title":"Team Glossary","headword":"0","definition":"1","no_header":true,"body_format":"html"}`)
+
+	if IsGlossary(filepath.Join(dir, "glossary"+MappingSuffix)) {
+		t.Error("mapping file detected as glossary")
+	}
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if d.Title() != "Team Glossary" {
+		t.Errorf("Title() = %q", d.Title())
+	}
+	if got, err := d.Lookup("A"); err != nil || !strings.Contains(string(got), "<i>first</i>") {
+		t.Errorf("Lookup(A) = %s, %v", got, err)
+	}
+
+	writeFile(t, path, "a\tchanged\nb\tsecond\n")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	d.lastCheck = time.Time{}
+
+	if got, err := d.Lookup("b"); err != nil || !strings.Contains(string(got), "second") {
+		t.Errorf("Lookup(b) after reload = %s, %v", got, err)
+	}
+}
+
+func TestJSONFormats(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "words.json")
+	writeFile(t, jsonPath, `[{"word":"cat","meaning":"A *small* animal.","synonyms":["kitty","puss"]}]`)
+	jsonlPath := filepath.Join(dir, "words2.jsonl")
+	writeFile(t, jsonlPath, "{\"headword\":\"dog\",\"definition\":\"Woof\"}\n{\"headword\":\"cow\",\"definition\":\"Moo\"}\n")
+	other := filepath.Join(dir, "config.json")
+	writeFile(t, other, `{"not":"a glossary"}`)
+
+	if !IsGlossary(jsonPath) || !IsGlossary(jsonlPath) || IsGlossary(other) {
+		t.Error("unexpected IsGlossary results")
+	}
+
+	d, err := Open(jsonPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got, err := d.Lookup("kitty"); err != nil || !strings.Contains(string(got), "<em>small</em>") {
+		t.Errorf("Lookup(kitty) = %s, %v", got, err)
+	}
+
+	d, err = Open(jsonlPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	var words []string
+	d.Iterate(func(word string, definition []byte) error {
+		words = append(words, word)
+		return nil
+	})
+	if strings.Join(words, ",") != "dog,cow" {
+		t.Errorf("Iterate visited %v", words)
+	}
+}
@@ -0,0 +1,168 @@
+package glossary
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MappingSuffix is appended to the glossary name (without extension) to
+// form the mapping file name, e.g. "terms.csv" uses "terms.glossary.json".
+const MappingSuffix = ".glossary.json"
+
+// Body formats of the definition column.
+const (
+	BodyMarkdown = "markdown"
+	BodyHTML     = "html"
+	BodyText     = "text"
+)
+
+// Mapping describes how the columns or fields of a glossary file map to
+// dictionary entries. Columns are referenced by header name (case
+// insensitive) or by zero based index. Empty fields fall back to common
+// column names.
+type Mapping struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+
+	Headword   string `json:"headword"`
+	Definition string `json:"definition"`
+	Aliases    string `json:"aliases"`
+	Tags       string `json:"tags"`
+
+	// BodyFormat is "markdown" (default), "html" or "text".
+	BodyFormat string `json:"body_format"`
+
+	// Separators for multi-valued cells, "|" and "," by default.
+	AliasSeparator string `json:"alias_separator"`
+	TagSeparator   string `json:"tag_separator"`
+
+	// Delimiter overrides the CSV field delimiter (default "," for .csv
+	// and tab for .tsv).
+	Delimiter string `json:"delimiter"`
+	// NoHeader marks CSV/TSV files without a header row.
+	NoHeader bool `json:"no_header"`
+}
+
+// Default column names tried when the mapping does not name a column.
+var (
+	headwordColumns   = []string{"headword", "word", "term", "title", "name", "key"}
+	definitionColumns = []string{"definition", "meaning", "description", "body", "content", "translation", "gloss"}
+	aliasColumns      = []string{"aliases", "alias", "synonyms", "variants"}
+	tagColumns        = []string{"tags", "tag", "category", "categories"}
+)
+
+// MappingPath returns the mapping file path of a glossary.
+func MappingPath(path string) string {
+	return trimExt(path) + MappingSuffix
+}
+
+// trimExt removes the file extension.
+func trimExt(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+// LoadMapping reads the mapping file of a glossary. A missing file yields
+// the default mapping.
+func LoadMapping(path string) (Mapping, error) {
+	var m Mapping
+	data, err := os.ReadFile(MappingPath(path))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// normalize fills in defaults.
+func (m Mapping) normalize() Mapping {
+	if m.BodyFormat == "" {
+		m.BodyFormat = BodyMarkdown
+	}
+	if m.AliasSeparator == "" {
+		m.AliasSeparator = "|"
+	}
+	if m.TagSeparator == "" {
+		m.TagSeparator = ","
+	}
+	return m
+}
+
+// columns maps the mapped fields to column indexes of a header row, -1 when
+// a field is not present.
+type columns struct {
+	headword, definition, aliases, tags int
+}
+
+// resolveColumns finds the columns of a CSV header. Without a header,
+// columns can only be referenced by index and default to 0 and 1.
+func (m Mapping) resolveColumns(header []string) columns {
+	find := func(name string, defaults []string, fallback int) int {
+		if name != "" {
+			if i, err := strconv.Atoi(name); err == nil {
+				return i
+			}
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), name) {
+					return i
+				}
+			}
+			return -1
+		}
+		for _, d := range defaults {
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), d) {
+					return i
+				}
+			}
+		}
+		return fallback
+	}
+	return columns{
+		headword:   find(m.Headword, headwordColumns, 0),
+		definition: find(m.Definition, definitionColumns, 1),
+		aliases:    find(m.Aliases, aliasColumns, -1),
+		tags:       find(m.Tags, tagColumns, -1),
+	}
+}
+
+// field returns the value of a JSON object field, trying the mapped name
+// and then the defaults. Arrays are joined with sep.
+func field(obj map[string]any, name string, defaults []string, sep string) string {
+	names := defaults
+	if name != "" {
+		names = []string{name}
+	}
+	for _, n := range names {
+		for key, value := range obj {
+			if !strings.EqualFold(key, n) {
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				return v
+			case []any:
+				parts := make([]string, 0, len(v))
+				for _, item := range v {
+					if s, ok := item.(string); ok {
+						parts = append(parts, s)
+					}
+				}
+				return strings.Join(parts, sep)
+			case nil:
+				return ""
+			default:
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+		}
+	}
+	return ""
+}
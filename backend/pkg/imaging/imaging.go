@@ -0,0 +1,164 @@
+// Package imaging resizes and re-encodes dictionary illustrations.
+//
+// Images are decoded from PNG, JPEG, GIF, BMP or WebP, scaled down to fit a
+// bounding box while keeping the aspect ratio, and encoded as PNG or JPEG.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	_ "golang.org/x/image/bmp" // register BMP decoder
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// MaxDimension is the largest accepted width or height.
+const MaxDimension = 4096
+
+// MaxSourcePixels limits the size of decoded images to guard against
+// decompression bombs.
+const MaxSourcePixels = 64 << 20
+
+// Output formats.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// DefaultJPEGQuality is used when encoding JPEG output.
+const DefaultJPEGQuality = 85
+
+var (
+	// ErrUnsupportedImage is returned when the data is not a decodable image.
+	ErrUnsupportedImage = errors.New("imaging: unsupported image")
+	// ErrInvalidSize is returned for negative or too large dimensions.
+	ErrInvalidSize = errors.New("imaging: invalid size")
+	// ErrInvalidFormat is returned for unknown output formats.
+	ErrInvalidFormat = errors.New("imaging: invalid format")
+	// ErrImageTooLarge is returned when the source exceeds MaxSourcePixels.
+	ErrImageTooLarge = errors.New("imaging: image too large")
+)
+
+// Options describes a transformation. Width and Height are the bounding box
+// (0 means unconstrained); images are never upscaled. Format is the output
+// format, empty keeps PNG and JPEG sources and converts others to PNG.
+type Options struct {
+	Width  int
+	Height int
+	Format string
+}
+
+// Validate checks the options and normalizes the format name.
+func (o *Options) Validate() error {
+	if o.Width < 0 || o.Height < 0 || o.Width > MaxDimension || o.Height > MaxDimension {
+		return ErrInvalidSize
+	}
+	switch f := strings.ToLower(o.Format); f {
+	case "":
+	case "jpg", FormatJPEG:
+		o.Format = FormatJPEG
+	case FormatPNG:
+		o.Format = FormatPNG
+	default:
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	if format == FormatJPEG {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Transform decodes data, scales it to fit the bounding box and encodes it.
+// It returns the encoded image and its format.
+func Transform(data []byte, opts Options) ([]byte, string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxSourcePixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	src, srcFormat, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatPNG
+		if srcFormat == FormatJPEG {
+			format = FormatJPEG
+		}
+	}
+
+	img := src
+	b := src.Bounds()
+	if w, h := FitSize(b.Dx(), b.Dy(), opts.Width, opts.Height); w != b.Dx() || h != b.Dy() {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+		img = dst
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: DefaultJPEGQuality})
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), format, nil
+}
+
+// FitSize returns the size of a w×h image scaled down to fit maxW×maxH
+// (0 means unconstrained), keeping the aspect ratio. The result is never
+// larger than the original and at least 1×1.
+func FitSize(w, h, maxW, maxH int) (int, int) {
+	if w <= 0 || h <= 0 {
+		return w, h
+	}
+	scale := 1.0
+	if maxW > 0 && w > maxW {
+		scale = float64(maxW) / float64(w)
+	}
+	if maxH > 0 && h > maxH {
+		if s := float64(maxH) / float64(h); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1 {
+		return w, h
+	}
+	return max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5))
+}
+
+// flatten composites transparent images onto a white background, since JPEG
+// has no alpha channel.
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,90 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 0, 128})
+		}
+	}
+	return img
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{400, 200, 100, 0, 100, 50},
+		{400, 200, 0, 50, 100, 50},
+		{400, 200, 100, 10, 20, 10},
+		{400, 200, 800, 800, 400, 200}, // never upscale
+		{400, 200, 0, 0, 400, 200},
+		{1000, 1, 10, 0, 10, 1},
+	}
+	for _, tt := range tests {
+		w, h := FitSize(tt.w, tt.h, tt.maxW, tt.maxH)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("FitSize(%d, %d, %d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.maxW, tt.maxH, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestTransformBMPToJPEG(t *testing.T) {
+	var src bytes.Buffer
+	if err := bmp.Encode(&src, testImage(200, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, format, err := Transform(src.Bytes(), Options{Width: 50, Format: "jpg"})
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if format != FormatJPEG {
+		t.Errorf("format = %q, want jpeg", format)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("output is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 50 || b.Dy() != 25 {
+		t.Errorf("size = %dx%d, want 50x25", b.Dx(), b.Dy())
+	}
+}
+
+func TestTransformDefaultsToPNG(t *testing.T) {
+	var src bytes.Buffer
+	if err := bmp.Encode(&src, testImage(10, 10)); err != nil {
+		t.Fatal(err)
+	}
+	out, format, err := Transform(src.Bytes(), Options{})
+	if err != nil || format != FormatPNG {
+		t.Fatalf("Transform = %q, %v", format, err)
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("output is not a PNG: %v", err)
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	if _, _, err := Transform([]byte("<svg/>"), Options{}); err != ErrUnsupportedImage {
+		t.Errorf("svg: err = %v, want ErrUnsupportedImage", err)
+	}
+	if _, _, err := Transform(nil, Options{Width: MaxDimension + 1}); err != ErrInvalidSize {
+		t.Errorf("size: err = %v, want ErrInvalidSize", err)
+	}
+	if _, _, err := Transform(nil, Options{Format: "gif"}); err != ErrInvalidFormat {
+		t.Errorf("format: err = %v, want ErrInvalidFormat", err)
+	}
+}
@@ -29,6 +29,9 @@ func DecompressZlib(data []byte) ([]byte, error) {
 	return result, nil
 }
 
+// maxLZOHint bounds the output buffer preallocated for LZO decompression.
+const maxLZOHint = 64 << 20
+
 // DecompressLZO decompresses LZO-compressed data.
 // expectedSize is the expected decompressed size (required for LZO).
 func DecompressLZO(data []byte, expectedSize int) ([]byte, error) {
@@ -36,6 +39,11 @@ func DecompressLZO(data []byte, expectedSize int) ([]byte, error) {
 		return nil, fmt.Errorf("empty data for LZO decompression")
 	}
 	
+	// The expected size is only a capacity hint, ignore implausible values
+	// from corrupt block info
+	if expectedSize < 0 || expectedSize > maxLZOHint {
+		expectedSize = 0
+	}
 	reader := bytes.NewReader(data)
 	result, err := lzo.Decompress1X(reader, 0, expectedSize)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"hash/adler32"
+	"html"
 	"io"
 	"os"
 	"regexp"
@@ -111,20 +112,21 @@ func parseHeaderXML(header *Header) error {
 }
 
 // extractAttr extracts an attribute value from XML-like string.
-// Handles both attribute="value" and attribute=value formats.
+// Handles both attribute="value" and attribute=value formats. Entities such
+// as &amp; and &lt; are unescaped, so values round-trip with Writer.
 func extractAttr(xml, attrName string) string {
 	// Try attribute="value" format
 	pattern := regexp.MustCompile(attrName + `="([^"]*)"`)
 	matches := pattern.FindStringSubmatch(xml)
 	if len(matches) >= 2 {
-		return matches[1]
+		return html.UnescapeString(matches[1])
 	}
 	
 	// Try attribute='value' format
 	pattern = regexp.MustCompile(attrName + `='([^']*)'`)
 	matches = pattern.FindStringSubmatch(xml)
 	if len(matches) >= 2 {
-		return matches[1]
+		return html.UnescapeString(matches[1])
 	}
 	
 	return ""
@@ -201,6 +203,15 @@ func parseEncoding(encoding string) Encoding {
 
 // ReadFileSection reads a section of the file from a specific position.
 func ReadFileSection(file *os.File, offset int64, length int64) ([]byte, error) {
+	// Sizes come from the file itself, check them before allocating
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || length < 0 || offset > info.Size() || length > info.Size()-offset {
+		return nil, fmt.Errorf("section of %d bytes at position %d exceeds file size %d", length, offset, info.Size())
+	}
+
 	if _, err := file.Seek(offset, io.SeekStart); err != nil {
 		return nil, fmt.Errorf("failed to seek to position %d: %w", offset, err)
 	}
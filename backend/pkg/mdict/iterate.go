@@ -0,0 +1,103 @@
+package mdict
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrStopIteration can be returned by an IterateFunc to stop the iteration
+// early. Iterate then returns nil.
+var ErrStopIteration = errors.New("mdict: stop iteration")
+
+// IterateFunc is called for each entry during iteration. For MDX files the
+// record is decoded to UTF-8, for MDD files it is the raw resource data.
+// The record slice is only valid until the function returns.
+type IterateFunc func(key string, record []byte) error
+
+// Iterate calls fn for every entry in key order. Each record block is read
+// and decompressed once, which makes this much cheaper than calling Lookup
+// for every key.
+func (m *Mdict) Iterate(fn IterateFunc) error {
+	return m.IterateFrom("", fn)
+}
+
+// IterateFrom is like Iterate but starts at the first entry whose key is
+// greater than or equal to startKey (case-insensitive). It can be used to
+// resume an interrupted iteration.
+func (m *Mdict) IterateFrom(startKey string, fn IterateFunc) error {
+	if len(m.KeyEntries) == 0 {
+		return fmt.Errorf("dictionary index not built, call BuildIndex() first")
+	}
+
+	start := 0
+	if startKey = strings.ToLower(strings.TrimSpace(startKey)); startKey != "" {
+		start = sort.Search(len(m.KeyEntries), func(i int) bool {
+			return strings.ToLower(m.KeyEntries[i].Keyword) >= startKey
+		})
+	}
+
+	return m.iterate(m.KeyEntries[start:], nil, fn)
+}
+
+// IterateMatching calls fn for every entry whose key is accepted by match.
+// Record blocks without any matching entry are skipped, so extracting a
+// small subset of a large MDD only decompresses the blocks it needs.
+func (m *Mdict) IterateMatching(match func(key string) bool, fn IterateFunc) error {
+	if len(m.KeyEntries) == 0 {
+		return fmt.Errorf("dictionary index not built, call BuildIndex() first")
+	}
+	return m.iterate(m.KeyEntries, match, fn)
+}
+
+// iterate visits entries in order, reading each record block at most once.
+// A nil match accepts every entry.
+func (m *Mdict) iterate(entries []*KeyEntry, match func(key string) bool, fn IterateFunc) error {
+	file, err := os.Open(m.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		blockInfo *RecordBlockInfo
+		block     []byte
+	)
+
+	for _, entry := range entries {
+		if match != nil && !match(entry.Keyword) {
+			continue
+		}
+
+		// Records are stored in key order, so a new block is only read when
+		// the entry moves past the current one.
+		if blockInfo == nil ||
+			entry.RecordStartOffset < blockInfo.DecompressedOffset ||
+			entry.RecordStartOffset >= blockInfo.DecompressedOffset+blockInfo.DecompressedSize {
+			blockInfo = FindRecordBlockForOffset(m.RecordBlockInfos, entry.RecordStartOffset)
+			if blockInfo == nil {
+				return fmt.Errorf("could not find record block for offset %d", entry.RecordStartOffset)
+			}
+			block, err = ReadRecordBlock(file, m.Header, blockInfo, m.RecordBlockDataStartPos)
+			if err != nil {
+				return err
+			}
+		}
+
+		data, err := ExtractRecord(block, entry, blockInfo)
+		if err != nil {
+			return fmt.Errorf("failed to extract record for %q: %w", entry.Keyword, err)
+		}
+
+		if err := fn(entry.Keyword, m.decodeRecord(data)); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
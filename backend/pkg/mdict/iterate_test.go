@@ -0,0 +1,64 @@
+package mdict
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIterate(t *testing.T) {
+	entries := make(map[string][]byte)
+	for i := 0; i < 300; i++ {
+		entries[fmt.Sprintf("key%03d", i)] = []byte(fmt.Sprintf("record %d", i))
+	}
+	m := writeTestDict(t, "iter.mdx", DictTypeMDX, WriterOptions{RecordBlockSize: 256}, entries)
+
+	var keys []string
+	err := m.Iterate(func(key string, record []byte) error {
+		if want := entries[key]; !bytes.Equal(record, want) {
+			t.Errorf("record for %q = %q, want %q", key, record, want)
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Iterate failed: %v", err)
+	}
+	if len(keys) != len(entries) {
+		t.Fatalf("Iterate visited %d entries, want %d", len(keys), len(entries))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] > keys[i] {
+			t.Fatalf("keys out of order: %q before %q", keys[i-1], keys[i])
+		}
+	}
+}
+
+func TestIterateFromAndStop(t *testing.T) {
+	entries := make(map[string][]byte)
+	for i := 0; i < 100; i++ {
+		entries[fmt.Sprintf("key%03d", i)] = []byte("x")
+	}
+	m := writeTestDict(t, "resume.mdx", DictTypeMDX, WriterOptions{RecordBlockSize: 64}, entries)
+
+	var keys []string
+	err := m.IterateFrom("KEY050", func(key string, record []byte) error {
+		keys = append(keys, key)
+		if len(keys) == 5 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterateFrom failed: %v", err)
+	}
+	if len(keys) != 5 || keys[0] != "key050" || keys[4] != "key054" {
+		t.Errorf("IterateFrom visited %v", keys)
+	}
+
+	errBoom := errors.New("boom")
+	if err := m.Iterate(func(string, []byte) error { return errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("Iterate error = %v, want %v", err, errBoom)
+	}
+}
@@ -110,11 +110,22 @@ func ReadKeyBlockInfo(file *os.File, header *Header, meta *KeyBlockMeta) ([]*Key
 
 // parseKeyBlockInfoEntries parses the decompressed key block info into individual entries.
 func parseKeyBlockInfoEntries(data []byte, header *Header, meta *KeyBlockMeta) ([]*KeyBlockInfo, error) {
-	infos := make([]*KeyBlockInfo, 0, meta.KeyBlockNum)
-	
 	offset := 0
 	width := header.NumberWidth
 	
+	// Each entry holds at least three numbers and two key sizes; the block
+	// count comes from the file and is only trusted as far as data allows
+	if meta.KeyBlockNum < 0 || meta.KeyBlockNum > int64(len(data)/(3*width+2)) {
+		return nil, fmt.Errorf("invalid key block count %d for %d bytes of key block info", meta.KeyBlockNum, len(data))
+	}
+	infos := make([]*KeyBlockInfo, 0, meta.KeyBlockNum)
+	truncated := func(need int) error {
+		if offset+need > len(data) {
+			return fmt.Errorf("truncated key block info at entry %d", len(infos))
+		}
+		return nil
+	}
+	
 	// Determine terminator size based on version
 	textTermSize := 0
 	if header.Version >= 2.0 {
@@ -130,6 +141,9 @@ func parseKeyBlockInfoEntries(data []byte, header *Header, meta *KeyBlockMeta) (
 		info := &KeyBlockInfo{}
 		
 		// Read number of entries in this block (not used directly but must be read)
+		if err := truncated(width + 2); err != nil {
+			return nil, err
+		}
 		_ = ReadNumber(data[offset:], width)
 		offset += width
 		
@@ -152,6 +166,9 @@ func parseKeyBlockInfoEntries(data []byte, header *Header, meta *KeyBlockMeta) (
 		}
 		
 		// Read first key
+		if err := truncated(firstKeyByteSize + 2); err != nil {
+			return nil, err
+		}
 		if isUTF16 {
 			info.FirstKey = DecodeUTF16LEToString(data, offset, firstKeyByteSize-textTermSize*2)
 		} else {
@@ -178,6 +195,9 @@ func parseKeyBlockInfoEntries(data []byte, header *Header, meta *KeyBlockMeta) (
 		}
 		
 		// Read last key
+		if err := truncated(lastKeyByteSize + 2*width); err != nil {
+			return nil, err
+		}
 		if isUTF16 {
 			info.LastKey = DecodeUTF16LEToString(data, offset, lastKeyByteSize-textTermSize*2)
 		} else {
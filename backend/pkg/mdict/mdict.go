@@ -142,26 +142,36 @@ func (m *Mdict) LookupByEntry(entry *KeyEntry) ([]byte, error) {
 		return nil, err
 	}
 	
+	return m.decodeRecord(data), nil
+}
+
+// decodeRecord converts a raw record to UTF-8. MDD records are returned as is.
+//
+// MDX text records, both from MdxBuilder and from Writer, end with a null
+// terminator (two bytes for UTF-16) that is part of the record data; it is
+// stripped so callers get the plain definition.
+func (m *Mdict) decodeRecord(data []byte) []byte {
 	// For MDD files, return raw data
 	if m.DictType == DictTypeMDD {
-		return data, nil
+		return data
 	}
 	
 	// For MDX files with UTF-16 encoding, decode to UTF-8
 	if m.Header.Encoding == EncodingUTF16 {
 		str, err := DecodeUTF16LE(data)
 		if err != nil {
-			return data, nil // Return raw data on decode error
+			return data // Return raw data on decode error
 		}
-		return []byte(str), nil
+		return []byte(strings.TrimRight(str, "\x00"))
 	}
 	
 	// For other encodings, decode appropriately
 	str, err := DecodeByEncoding(data, m.Header.Encoding)
 	if err != nil {
-		return data, nil
+		return data
 	}
-	return []byte(str), nil
+	// MDX text records are null terminated
+	return []byte(strings.TrimRight(str, "\x00"))
 }
 
 // Name returns the dictionary name (filename without extension).
@@ -172,3 +172,22 @@ func BenchmarkLookup(b *testing.B) {
 		_, _ = mdict.Lookup("hello")
 	}
 }
+
+func TestDecodeRecordStripsTerminator(t *testing.T) {
+	cases := []struct {
+		dictType DictType
+		encoding Encoding
+		data     []byte
+		want     string
+	}{
+		{DictTypeMDX, EncodingUTF8, []byte("<b>hi</b>\x00"), "<b>hi</b>"},
+		{DictTypeMDX, EncodingUTF16, []byte{'h', 0, 'i', 0, 0, 0}, "hi"},
+		{DictTypeMDD, EncodingUTF8, []byte("png\x00"), "png\x00"},
+	}
+	for _, c := range cases {
+		m := &Mdict{DictType: c.dictType, Header: &Header{Encoding: c.encoding}}
+		if got := string(m.decodeRecord(c.data)); got != c.want {
+			t.Errorf("decodeRecord(%q) = %q, want %q", c.data, got, c.want)
+		}
+	}
+}
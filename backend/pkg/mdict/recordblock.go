@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/adler32"
 	"os"
+	"sort"
 )
 
 // ReadRecordBlockMeta reads the record block metadata section.
@@ -59,10 +60,14 @@ func ReadRecordBlockInfo(file *os.File, header *Header, meta *RecordBlockMeta) (
 		return nil, fmt.Errorf("failed to read record block info: %w", err)
 	}
 	
-	infos := make([]*RecordBlockInfo, 0, meta.RecordBlockNum)
-	
 	offset := 0
 	width := header.NumberWidth
+	
+	// Each entry is two numbers
+	if meta.RecordBlockNum < 0 || meta.RecordBlockNum > int64(len(data)/(2*width)) {
+		return nil, fmt.Errorf("invalid record block count %d for %d bytes of record block info", meta.RecordBlockNum, len(data))
+	}
+	infos := make([]*RecordBlockInfo, 0, meta.RecordBlockNum)
 	var compAccum, decompAccum int64
 	
 	for i := int64(0); i < meta.RecordBlockNum; i++ {
@@ -103,34 +108,37 @@ func ReadRecordBlockInfo(file *os.File, header *Header, meta *RecordBlockMeta) (
 // LookupRecord looks up a word definition from the record blocks.
 func LookupRecord(file *os.File, header *Header, entry *KeyEntry, infos []*RecordBlockInfo, recordBlockDataStartPos int64) ([]byte, error) {
 	// Find the record block containing this entry
-	var targetInfo *RecordBlockInfo
-	for _, info := range infos {
-		if entry.RecordStartOffset >= info.DecompressedOffset &&
-			entry.RecordStartOffset < info.DecompressedOffset+info.DecompressedSize {
-			targetInfo = info
-			break
-		}
-	}
-	
+	targetInfo := FindRecordBlockForOffset(infos, entry.RecordStartOffset)
 	if targetInfo == nil {
 		return nil, fmt.Errorf("could not find record block for offset %d", entry.RecordStartOffset)
 	}
 	
+	decompressedBlock, err := ReadRecordBlock(file, header, targetInfo, recordBlockDataStartPos)
+	if err != nil {
+		return nil, err
+	}
+	
+	return ExtractRecord(decompressedBlock, entry, targetInfo)
+}
+
+// ReadRecordBlock reads, decrypts and decompresses a single record block and
+// verifies its checksum.
+func ReadRecordBlock(file *os.File, header *Header, info *RecordBlockInfo, recordBlockDataStartPos int64) ([]byte, error) {
 	// Read the compressed record block
 	blockData, err := ReadFileSection(file,
-		recordBlockDataStartPos+targetInfo.CompressedOffset,
-		targetInfo.CompressedSize)
+		recordBlockDataStartPos+info.CompressedOffset,
+		info.CompressedSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read record block: %w", err)
 	}
 	
 	// Decrypt if needed (type 1 encryption)
 	if header.EncryptType == EncryptRecord {
-		blockData = DecryptRecordBlock(blockData, targetInfo.CompressedSize)
+		blockData = DecryptRecordBlock(blockData, info.CompressedSize)
 	}
 	
 	// Decompress the record block
-	decompressedBlock, _, err := DecompressBlock(blockData, targetInfo.DecompressedSize)
+	decompressedBlock, _, err := DecompressBlock(blockData, info.DecompressedSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decompress record block: %w", err)
 	}
@@ -143,11 +151,16 @@ func LookupRecord(file *os.File, header *Header, entry *KeyEntry, infos []*Recor
 			expectedChecksum, actualChecksum)
 	}
 	
+	return decompressedBlock, nil
+}
+
+// ExtractRecord extracts the record of an entry from its decompressed record block.
+func ExtractRecord(decompressedBlock []byte, entry *KeyEntry, info *RecordBlockInfo) ([]byte, error) {
 	// Calculate offsets within the decompressed block
-	startOffset := entry.RecordStartOffset - targetInfo.DecompressedOffset
+	startOffset := entry.RecordStartOffset - info.DecompressedOffset
 	var endOffset int64
 	if entry.RecordEndOffset > 0 {
-		endOffset = entry.RecordEndOffset - targetInfo.DecompressedOffset
+		endOffset = entry.RecordEndOffset - info.DecompressedOffset
 	} else {
 		endOffset = int64(len(decompressedBlock))
 	}
@@ -162,17 +175,17 @@ func LookupRecord(file *os.File, header *Header, entry *KeyEntry, infos []*Recor
 	}
 	
 	// Extract the definition data
-	definitionData := decompressedBlock[startOffset:endOffset]
-	
-	return definitionData, nil
+	return decompressedBlock[startOffset:endOffset], nil
 }
 
 // FindRecordBlockForOffset finds the record block info containing the given decompressed offset.
+// Record block infos are ordered by offset, so a binary search is used.
 func FindRecordBlockForOffset(infos []*RecordBlockInfo, offset int64) *RecordBlockInfo {
-	for _, info := range infos {
-		if offset >= info.DecompressedOffset && offset < info.DecompressedOffset+info.DecompressedSize {
-			return info
-		}
+	idx := sort.Search(len(infos), func(i int) bool {
+		return infos[i].DecompressedOffset+infos[i].DecompressedSize > offset
+	})
+	if idx < len(infos) && offset >= infos[idx].DecompressedOffset {
+		return infos[idx]
 	}
 	return nil
 }
@@ -0,0 +1,44 @@
+package mdict
+
+import "strings"
+
+// ResourceInfo describes a single MDD resource.
+type ResourceInfo struct {
+	Key  string // Raw MDD key, e.g. `\img\a.png`
+	Path string // Slash separated path without leading slash, e.g. "img/a.png"
+	Size int64  // Decompressed size in bytes
+}
+
+// ResourcePath converts an MDD key into a slash separated path without a
+// leading slash. It is the inverse of NormalizeResourceKey.
+func ResourcePath(key string) string {
+	return strings.TrimLeft(strings.ReplaceAll(key, `\`, "/"), "/")
+}
+
+// Resources returns the key, path and size of every entry in key order.
+// Sizes are derived from the index, no record block is read.
+func (m *Mdict) Resources() []ResourceInfo {
+	var total int64
+	if n := len(m.RecordBlockInfos); n > 0 {
+		last := m.RecordBlockInfos[n-1]
+		total = last.DecompressedOffset + last.DecompressedSize
+	}
+
+	infos := make([]ResourceInfo, 0, len(m.KeyEntries))
+	for _, entry := range m.KeyEntries {
+		end := entry.RecordEndOffset
+		if end <= 0 {
+			end = total
+		}
+		size := end - entry.RecordStartOffset
+		if size < 0 {
+			size = 0
+		}
+		infos = append(infos, ResourceInfo{
+			Key:  entry.Keyword,
+			Path: ResourcePath(entry.Keyword),
+			Size: size,
+		})
+	}
+	return infos
+}
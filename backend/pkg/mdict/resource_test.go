@@ -0,0 +1,47 @@
+package mdict
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResources(t *testing.T) {
+	entries := map[string][]byte{
+		NormalizeResourceKey("img/a.png"):   []byte("png data"),
+		NormalizeResourceKey("img/b.jpg"):   []byte("jpeg"),
+		NormalizeResourceKey("snd/a.mp3"):   []byte("mp3 audio data"),
+		NormalizeResourceKey("style.css"):   []byte("b{}"),
+		NormalizeResourceKey("img/sub/c.g"): []byte("gif!"),
+	}
+	m := writeTestDict(t, "res.mdd", DictTypeMDD, WriterOptions{RecordBlockSize: 16}, entries)
+
+	infos := m.Resources()
+	if len(infos) != len(entries) {
+		t.Fatalf("Resources returned %d entries, want %d", len(infos), len(entries))
+	}
+	for _, info := range infos {
+		if info.Path != ResourcePath(info.Key) || strings.HasPrefix(info.Path, "/") {
+			t.Errorf("bad path %q for key %q", info.Path, info.Key)
+		}
+		if want := int64(len(entries[info.Key])); info.Size != want {
+			t.Errorf("Size(%s) = %d, want %d", info.Path, info.Size, want)
+		}
+	}
+
+	var got []string
+	err := m.IterateMatching(func(key string) bool {
+		return strings.HasPrefix(ResourcePath(key), "img/")
+	}, func(key string, record []byte) error {
+		if string(record) != string(entries[key]) {
+			t.Errorf("record for %q = %q", key, record)
+		}
+		got = append(got, ResourcePath(key))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("IterateMatching failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("IterateMatching visited %v", got)
+	}
+}
@@ -0,0 +1,376 @@
+package mdict
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+	"math/bits"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxVerifyIssues limits the number of issues kept in a VerifyResult.
+const DefaultMaxVerifyIssues = 200
+
+// Verify issue sections.
+const (
+	SectionHeader       = "header"
+	SectionKeyBlockMeta = "key_block_meta"
+	SectionKeyBlockInfo = "key_block_info"
+	SectionKeyBlock     = "key_block"
+	SectionRecordMeta   = "record_block_meta"
+	SectionRecordBlock  = "record_block"
+	SectionRecord       = "record"
+)
+
+// VerifyIssue describes a single problem found during verification.
+type VerifyIssue struct {
+	Section string `json:"section"`
+	Block   int    `json:"block"` // Block index, -1 if not applicable
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+// VerifyResult contains the result of verifying a dictionary file.
+type VerifyResult struct {
+	Path     string  `json:"path"`
+	IsMDD    bool    `json:"is_mdd"`
+	Version  float64 `json:"version"`
+	Encoding string  `json:"encoding"`
+
+	KeyBlocks       int   `json:"key_blocks"`
+	BadKeyBlocks    int   `json:"bad_key_blocks"`
+	RecordBlocks    int   `json:"record_blocks"`
+	BadRecordBlocks int   `json:"bad_record_blocks"`
+	DeclaredEntries int64 `json:"declared_entries"`
+	Entries         int64 `json:"entries"`
+
+	DuplicateKeys int `json:"duplicate_keys"` // Keys (case-insensitive) occurring more than once
+	OrphanKeys    int `json:"orphan_keys"`    // Keys pointing outside the record data
+	DecodeErrors  int `json:"decode_errors"`  // Keys or records not decodable in the declared encoding
+
+	Issues          []VerifyIssue `json:"issues"`
+	IssuesTruncated bool          `json:"issues_truncated"`
+}
+
+// OK reports whether the verification found no problems. Duplicate keys are
+// common in real dictionaries (homographs) and are only counted.
+func (r *VerifyResult) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// VerifyOptions controls a verification run.
+type VerifyOptions struct {
+	// MaxIssues limits the number of issues kept, defaults to DefaultMaxVerifyIssues.
+	MaxIssues int
+	// Progress is called after each key or record block with the number of
+	// blocks processed and the total number of blocks.
+	Progress func(done, total int)
+}
+
+// verifier holds the state of a verification run.
+type verifier struct {
+	m      *Mdict
+	file   *os.File
+	opts   VerifyOptions
+	result *VerifyResult
+	done   int
+	total  int
+}
+
+// Verify walks every key block and record block of an MDX/MDD file, checking
+// checksums and sizes, decoding keys and records in the declared encoding and
+// counting duplicate and orphan keys.
+//
+// Unlike BuildIndex, Verify does not stop at the first broken block. An error
+// is only returned when the file cannot be opened or its header is unreadable;
+// all other problems are reported as issues.
+func Verify(path string, opts VerifyOptions) (*VerifyResult, error) {
+	if opts.MaxIssues <= 0 {
+		opts.MaxIssues = DefaultMaxVerifyIssues
+	}
+
+	m, err := New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	v := &verifier{
+		m:    m,
+		file: file,
+		opts: opts,
+		result: &VerifyResult{
+			Path:            path,
+			IsMDD:           m.IsMDD(),
+			Version:         m.Header.Version,
+			Encoding:        EncodingName(m.Header.Encoding),
+			DeclaredEntries: m.KeyBlockMeta.EntriesNum,
+			Issues:          make([]VerifyIssue, 0),
+		},
+	}
+
+	// Total number of blocks for progress reporting
+	v.total = int(m.KeyBlockMeta.KeyBlockNum)
+	if recordMeta, err := v.readRecordBlockMeta(); err == nil {
+		v.total += int(recordMeta.RecordBlockNum)
+	}
+
+	v.verifyHeader()
+	entries := v.verifyKeyBlocks()
+	v.verifyRecordBlocks(entries)
+
+	return v.result, nil
+}
+
+// addIssue records an issue, respecting the issue limit.
+func (v *verifier) addIssue(section string, block int, key, format string, args ...interface{}) {
+	if len(v.result.Issues) >= v.opts.MaxIssues {
+		v.result.IssuesTruncated = true
+		return
+	}
+	v.result.Issues = append(v.result.Issues, VerifyIssue{
+		Section: section,
+		Block:   block,
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// step reports progress after a block has been processed.
+func (v *verifier) step() {
+	v.done++
+	if v.opts.Progress != nil {
+		v.opts.Progress(v.done, v.total)
+	}
+}
+
+// verifyHeader checks the header and key block metadata checksums.
+func (v *verifier) verifyHeader() {
+	header := v.m.Header
+
+	// The header checksum is little-endian in the specification, but
+	// ReadHeader reads it big-endian, so accept both byte orders.
+	checksum := adler32.Checksum(header.HeaderBytes)
+	if checksum != header.Adler32 && checksum != bits.ReverseBytes32(header.Adler32) {
+		v.addIssue(SectionHeader, -1, "", "header checksum mismatch: stored %d, calculated %d",
+			header.Adler32, checksum)
+	}
+
+	if header.Version >= 2.0 {
+		data, err := ReadFileSection(v.file, header.HeaderEndPos, 44)
+		if err != nil {
+			v.addIssue(SectionKeyBlockMeta, -1, "", "%v", err)
+			return
+		}
+		if stored, calculated := binary.BigEndian.Uint32(data[40:]), adler32.Checksum(data[:40]); stored != calculated {
+			v.addIssue(SectionKeyBlockMeta, -1, "", "key block metadata checksum mismatch: stored %d, calculated %d",
+				stored, calculated)
+		}
+	}
+}
+
+// verifyKeyBlocks checks every key block and returns all keys that could be read.
+func (v *verifier) verifyKeyBlocks() []*KeyEntry {
+	m := v.m
+
+	infos, err := ReadKeyBlockInfo(v.file, m.Header, m.KeyBlockMeta)
+	if err != nil {
+		v.addIssue(SectionKeyBlockInfo, -1, "", "%v", err)
+		return nil
+	}
+	v.result.KeyBlocks = len(infos)
+
+	keyBlockDataStartPos := m.KeyBlockMeta.KeyBlockInfoStartPos + m.KeyBlockMeta.KeyBlockInfoCompSize
+	// EntriesNum is verified below and may be corrupt, so no capacity hint
+	var entries []*KeyEntry
+
+	for i, info := range infos {
+		blockEntries, err := v.readKeyBlock(keyBlockDataStartPos, info)
+		if err != nil {
+			v.result.BadKeyBlocks++
+			v.addIssue(SectionKeyBlock, i, info.FirstKey, "%v", err)
+			v.step()
+			continue
+		}
+
+		for _, entry := range blockEntries {
+			if !isValidKey(entry.Keyword, m.Header.Encoding) {
+				v.result.DecodeErrors++
+				v.addIssue(SectionKeyBlock, i, entry.Keyword, "key is not valid %s", v.result.Encoding)
+			}
+		}
+		entries = append(entries, blockEntries...)
+		v.step()
+	}
+
+	for i := 0; i < len(entries)-1; i++ {
+		entries[i].RecordEndOffset = entries[i+1].RecordStartOffset
+	}
+
+	v.result.Entries = int64(len(entries))
+	if v.result.Entries != m.KeyBlockMeta.EntriesNum {
+		v.addIssue(SectionKeyBlock, -1, "", "entry count mismatch: declared %d, found %d",
+			m.KeyBlockMeta.EntriesNum, v.result.Entries)
+	}
+
+	// Count duplicate keys (case-insensitive, as used by Lookup)
+	seen := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		key := strings.ToLower(entry.Keyword)
+		seen[key]++
+		if seen[key] == 2 {
+			v.result.DuplicateKeys++
+		}
+	}
+
+	return entries
+}
+
+// readKeyBlock reads, decompresses and verifies a single key block.
+func (v *verifier) readKeyBlock(keyBlockDataStartPos int64, info *KeyBlockInfo) ([]*KeyEntry, error) {
+	blockData, err := ReadFileSection(v.file, keyBlockDataStartPos+info.CompressedOffset, info.CompressedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decompressed, _, err := DecompressBlock(blockData, info.DecompressedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress key block: %w", err)
+	}
+	if int64(len(decompressed)) != info.DecompressedSize {
+		return nil, fmt.Errorf("decompressed size mismatch: expected %d, got %d",
+			info.DecompressedSize, len(decompressed))
+	}
+	if expected, actual := GetBlockChecksum(blockData), adler32.Checksum(decompressed); expected != actual {
+		return nil, fmt.Errorf("key block checksum mismatch: expected %d, got %d", expected, actual)
+	}
+
+	return parseKeyBlockEntries(decompressed, v.m.Header), nil
+}
+
+// readRecordBlockMeta reads the record block metadata, which follows the key blocks.
+func (v *verifier) readRecordBlockMeta() (*RecordBlockMeta, error) {
+	meta := v.m.KeyBlockMeta
+	start := meta.KeyBlockInfoStartPos + meta.KeyBlockInfoCompSize + meta.KeyBlocksTotalSize
+	return ReadRecordBlockMeta(v.file, v.m.Header, start)
+}
+
+// verifyRecordBlocks checks every record block and the records of all keys.
+func (v *verifier) verifyRecordBlocks(entries []*KeyEntry) {
+	m := v.m
+
+	recordMeta, err := v.readRecordBlockMeta()
+	if err != nil {
+		v.addIssue(SectionRecordMeta, -1, "", "%v", err)
+		return
+	}
+	if recordMeta.EntriesNum != m.KeyBlockMeta.EntriesNum {
+		v.addIssue(SectionRecordMeta, -1, "", "entry count mismatch: key blocks have %d, record blocks have %d",
+			m.KeyBlockMeta.EntriesNum, recordMeta.EntriesNum)
+	}
+
+	infos, err := ReadRecordBlockInfo(v.file, m.Header, recordMeta)
+	if err != nil {
+		v.addIssue(SectionRecordMeta, -1, "", "%v", err)
+		return
+	}
+	v.result.RecordBlocks = len(infos)
+	recordDataStartPos := recordMeta.RecordBlockMetaEndPos + recordMeta.RecordBlockInfoSize
+
+	// Group keys by record block; keys outside every block are orphans
+	blockIndex := make(map[*RecordBlockInfo]int, len(infos))
+	for i, info := range infos {
+		blockIndex[info] = i
+	}
+	entriesByBlock := make([][]*KeyEntry, len(infos))
+	for _, entry := range entries {
+		info := FindRecordBlockForOffset(infos, entry.RecordStartOffset)
+		if info == nil {
+			v.result.OrphanKeys++
+			v.addIssue(SectionRecord, -1, entry.Keyword, "record offset %d is outside the record data",
+				entry.RecordStartOffset)
+			continue
+		}
+		i := blockIndex[info]
+		entriesByBlock[i] = append(entriesByBlock[i], entry)
+	}
+
+	for i, info := range infos {
+		block, err := ReadRecordBlock(v.file, m.Header, info, recordDataStartPos)
+		if err == nil && int64(len(block)) != info.DecompressedSize {
+			err = fmt.Errorf("decompressed size mismatch: expected %d, got %d", info.DecompressedSize, len(block))
+		}
+		if err != nil {
+			v.result.BadRecordBlocks++
+			v.addIssue(SectionRecordBlock, i, "", "%v", err)
+			v.step()
+			continue
+		}
+
+		// Resources in MDD files are binary, only MDX records are text
+		if !m.IsMDD() {
+			for _, entry := range entriesByBlock[i] {
+				data, err := ExtractRecord(block, entry, info)
+				if err != nil {
+					v.addIssue(SectionRecord, i, entry.Keyword, "%v", err)
+					continue
+				}
+				if !isValidText(data, m.Header.Encoding) {
+					v.result.DecodeErrors++
+					v.addIssue(SectionRecord, i, entry.Keyword, "record is not valid %s", v.result.Encoding)
+				}
+			}
+		}
+		v.step()
+	}
+}
+
+// isValidKey reports whether a decoded key contains no replacement characters.
+func isValidKey(key string, enc Encoding) bool {
+	if enc == EncodingUTF8 {
+		return utf8.ValidString(key)
+	}
+	return !strings.ContainsRune(key, utf8.RuneError)
+}
+
+// isValidText reports whether raw record data is decodable in the given
+// encoding. Decoders replace invalid sequences with U+FFFD, so its presence
+// is treated as a decode error for non UTF-8 encodings.
+func isValidText(data []byte, enc Encoding) bool {
+	if enc == EncodingUTF8 {
+		return utf8.Valid(data)
+	}
+	if enc == EncodingUTF16 && len(data)%2 != 0 {
+		return false
+	}
+	str, err := DecodeByEncoding(data, enc)
+	if err != nil {
+		return false
+	}
+	return !strings.ContainsRune(str, utf8.RuneError)
+}
+
+// EncodingName returns the name of an encoding.
+func EncodingName(enc Encoding) string {
+	switch enc {
+	case EncodingUTF16:
+		return "UTF-16"
+	case EncodingGBK:
+		return "GBK"
+	case EncodingGB2312:
+		return "GB2312"
+	case EncodingGB18030:
+		return "GB18030"
+	case EncodingBig5:
+		return "Big5"
+	default:
+		return "UTF-8"
+	}
+}
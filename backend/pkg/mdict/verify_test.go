@@ -0,0 +1,108 @@
+package mdict
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	w := NewWriter(DictTypeMDX, WriterOptions{RecordBlockSize: 128})
+	for i := 0; i < 50; i++ {
+		w.Add(fmt.Sprintf("word%02d", i), []byte(fmt.Sprintf("definition of word %d", i)))
+	}
+	w.Add("WORD01", []byte("duplicate"))
+
+	path := filepath.Join(t.TempDir(), "verify.mdx")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var progressCalls, progressTotal int
+	result, err := Verify(path, VerifyOptions{Progress: func(done, total int) {
+		progressCalls++
+		progressTotal = total
+	}})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("unexpected issues: %+v", result.Issues)
+	}
+	if result.DuplicateKeys != 1 || result.Entries != 51 || result.OrphanKeys != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if progressCalls != progressTotal || progressTotal != result.KeyBlocks+result.RecordBlocks {
+		t.Errorf("progress called %d times with total %d", progressCalls, progressTotal)
+	}
+
+	// Corrupt the last byte of the file, which belongs to the last record block
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = Verify(path, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if result.BadRecordBlocks != 1 || result.OK() {
+		t.Errorf("expected one bad record block, got %+v", result)
+	}
+}
+
+func TestVerifyCorruptSizes(t *testing.T) {
+	w := NewWriter(DictTypeMDX, WriterOptions{RecordBlockSize: 128})
+	for i := 0; i < 50; i++ {
+		w.Add(fmt.Sprintf("word%02d", i), []byte(fmt.Sprintf("definition of word %d", i)))
+	}
+	path := filepath.Join(t.TempDir(), "corrupt.mdx")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key block info size is the fourth number of the key block
+	// metadata; setting its high byte makes it far larger than the file
+	data := append([]byte(nil), original...)
+	data[m.Header.HeaderEndPos+24] = 0x7f
+	os.WriteFile(path, data, 0644)
+	result, err := Verify(path, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	found := false
+	for _, issue := range result.Issues {
+		found = found || issue.Section == SectionKeyBlockInfo
+	}
+	if !found {
+		t.Errorf("expected a key block info issue, got %+v", result.Issues)
+	}
+
+	// No single corrupt byte may crash verification
+	for i := range original {
+		data := append([]byte(nil), original...)
+		data[i] ^= 0xff
+		os.WriteFile(path, data, 0644)
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Verify panicked with byte %d corrupted: %v", i, r)
+				}
+			}()
+			Verify(path, VerifyOptions{})
+		}()
+	}
+}
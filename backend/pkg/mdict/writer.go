@@ -0,0 +1,636 @@
+package mdict
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultKeyBlockSize is the target decompressed size of a key block.
+	DefaultKeyBlockSize = 32 * 1024
+	// DefaultRecordBlockSize is the target decompressed size of a record block.
+	DefaultRecordBlockSize = 64 * 1024
+	// DefaultMemoryLimit is the amount of record data buffered in memory
+	// before records are spilled to a temporary file.
+	DefaultMemoryLimit = 32 * 1024 * 1024
+)
+
+var (
+	// ErrEmptyKey is returned when adding an entry with an empty key.
+	ErrEmptyKey = errors.New("mdict: empty key")
+	// ErrNoEntries is returned when writing a dictionary without entries.
+	ErrNoEntries = errors.New("mdict: no entries to write")
+	// ErrInvalidUTF8 is returned when a key or MDX record is not valid UTF-8.
+	ErrInvalidUTF8 = errors.New("mdict: invalid UTF-8")
+)
+
+// WriterOptions contains the metadata and layout settings used by Writer.
+type WriterOptions struct {
+	Title        string
+	Description  string
+	StyleSheet   string
+	CreationDate string // Defaults to the current date (YYYY-MM-DD)
+
+	// Target decompressed block sizes. A single record larger than the
+	// record block size gets a block of its own.
+	KeyBlockSize    int
+	RecordBlockSize int
+
+	// MemoryLimit is the number of record bytes kept in memory, defaults to
+	// DefaultMemoryLimit. Beyond it records are spilled to a temporary file
+	// in TempDir (default os.TempDir()); only keys stay in memory.
+	MemoryLimit int64
+	TempDir     string
+}
+
+// writerEntry is an added (key, record) pair. The record is either held in
+// memory or stored at offset in the spill file.
+type writerEntry struct {
+	key    string
+	sortBy string
+	record []byte
+	offset int64
+	size   int
+}
+
+// writerKeyBlock is an encoded key block together with its info fields.
+type writerKeyBlock struct {
+	entries  int
+	firstKey string
+	lastKey  string
+	data     []byte // compressed block, including type and checksum
+	rawSize  int
+}
+
+// Writer builds MDX (UTF-8, zlib, v2.0) and MDD files from a stream of
+// (key, record) pairs.
+//
+// Entries may be added in any order and are sorted case-insensitively on
+// write, which matches the ordering expected by Lookup and Suggest. MDX
+// records added under the same key are joined into one in the order they
+// were added; for MDD files the last record added under a key replaces the
+// earlier ones.
+// Records beyond MemoryLimit are spilled to disk, so dictionaries much larger
+// than memory can be built; call Close to remove the temporary files.
+type Writer struct {
+	dictType DictType
+	opts     WriterOptions
+	entries  []writerEntry
+
+	buffered  int64    // record bytes held in memory
+	spill     *os.File // records beyond MemoryLimit, nil until needed
+	spillSize int64
+}
+
+// NewWriter creates a new Writer for the given dictionary type.
+func NewWriter(dictType DictType, opts WriterOptions) *Writer {
+	if opts.KeyBlockSize <= 0 {
+		opts.KeyBlockSize = DefaultKeyBlockSize
+	}
+	if opts.RecordBlockSize <= 0 {
+		opts.RecordBlockSize = DefaultRecordBlockSize
+	}
+	if opts.CreationDate == "" {
+		opts.CreationDate = time.Now().Format("2006-01-02")
+	}
+	if opts.MemoryLimit <= 0 {
+		opts.MemoryLimit = DefaultMemoryLimit
+	}
+	return &Writer{
+		dictType: dictType,
+		opts:     opts,
+	}
+}
+
+// Add appends an entry. For MDD files the key is a resource path; forward
+// slashes are converted to backslashes and a leading backslash is added, as
+// in files produced by MdxBuilder. The record is retained until it is written
+// or spilled and must not be modified by the caller.
+func (w *Writer) Add(key string, record []byte) error {
+	if w.dictType == DictTypeMDD {
+		key = NormalizeResourceKey(key)
+	}
+	if strings.TrimSpace(key) == "" || key == `\` {
+		return ErrEmptyKey
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("%w in key %q", ErrInvalidUTF8, key)
+	}
+	if w.dictType == DictTypeMDX && !utf8.Valid(record) {
+		return fmt.Errorf("%w in record of %q", ErrInvalidUTF8, key)
+	}
+
+	entry := writerEntry{
+		key:    key,
+		sortBy: strings.ToLower(key),
+		record: record,
+		size:   len(record),
+	}
+	w.entries = append(w.entries, entry)
+	w.buffered += int64(len(record))
+
+	if w.buffered > w.opts.MemoryLimit {
+		return w.spillRecords()
+	}
+	return nil
+}
+
+// spillRecords moves all records held in memory to the spill file.
+func (w *Writer) spillRecords() error {
+	if w.spill == nil {
+		file, err := os.CreateTemp(w.opts.TempDir, "mdict-records-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		w.spill = file
+	}
+
+	for i := range w.entries {
+		entry := &w.entries[i]
+		if entry.record == nil {
+			continue
+		}
+		if _, err := w.spill.Write(entry.record); err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+		entry.offset = w.spillSize
+		entry.record = nil
+		w.spillSize += int64(entry.size)
+	}
+	w.buffered = 0
+	return nil
+}
+
+// readRecord returns the record of an entry from memory or the spill file.
+func (w *Writer) readRecord(entry *writerEntry) ([]byte, error) {
+	if entry.record != nil || entry.size == 0 {
+		return entry.record, nil
+	}
+	record := make([]byte, entry.size)
+	if _, err := w.spill.ReadAt(record, entry.offset); err != nil {
+		return nil, fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	return record, nil
+}
+
+// Len returns the number of distinct keys added so far.
+func (w *Writer) Len() int {
+	w.sortEntries()
+	count := 0
+	for i := 0; i < len(w.entries); i = w.groupEnd(i) {
+		count++
+	}
+	return count
+}
+
+// sortEntries orders entries case-insensitively, keeping entries with the
+// same key next to each other in the order they were added.
+func (w *Writer) sortEntries() {
+	sort.SliceStable(w.entries, func(i, j int) bool {
+		a, b := &w.entries[i], &w.entries[j]
+		if a.sortBy != b.sortBy {
+			return a.sortBy < b.sortBy
+		}
+		return a.key < b.key
+	})
+}
+
+// groupEnd returns the index after the last sorted entry sharing the key of
+// entry i.
+func (w *Writer) groupEnd(i int) int {
+	end := i + 1
+	for end < len(w.entries) && w.entries[end].key == w.entries[i].key {
+		end++
+	}
+	return end
+}
+
+// recordStart returns the first entry of the group start..end whose record
+// is stored. Resources cannot be joined, so an MDD key keeps only the record
+// added last.
+func (w *Writer) recordStart(start, end int) int {
+	if w.dictType == DictTypeMDD {
+		return end - 1
+	}
+	return start
+}
+
+// Close removes the temporary file holding spilled records. The Writer
+// cannot be used afterwards.
+func (w *Writer) Close() error {
+	w.entries = nil
+	if w.spill == nil {
+		return nil
+	}
+	w.spill.Close()
+	err := os.Remove(w.spill.Name())
+	w.spill = nil
+	return err
+}
+
+// WriteFile writes the dictionary to path. The file is written to a temporary
+// file first and renamed into place once complete.
+func (w *Writer) WriteFile(path string) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if _, err := w.WriteTo(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+// WriteTo encodes the dictionary and writes it to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	if len(w.entries) == 0 {
+		return 0, ErrNoEntries
+	}
+
+	keyCount := w.Len()
+
+	// Compressed record blocks are needed before the key blocks are written
+	// (for the record block info), keep them in a temporary file when the
+	// records do not fit in memory
+	var recordBlocks io.ReadWriter = &bytes.Buffer{}
+	if w.spill != nil {
+		file, err := os.CreateTemp(w.opts.TempDir, "mdict-blocks-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		recordBlocks = file
+	}
+	recordBlockInfo, recordBlocksTotal, err := w.buildRecordBlocks(recordBlocks)
+	if err != nil {
+		return 0, err
+	}
+	if file, ok := recordBlocks.(*os.File); ok {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+
+	keyBlocks, err := w.buildKeyBlocks()
+	if err != nil {
+		return 0, err
+	}
+
+	keyBlockInfo, keyBlockInfoRawSize, err := w.buildKeyBlockInfo(keyBlocks)
+	if err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: out}
+
+	// 1. Header
+	if err := w.writeHeader(cw); err != nil {
+		return cw.n, err
+	}
+
+	// 2. Key block metadata + checksum
+	var keyBlocksTotal int64
+	for _, kb := range keyBlocks {
+		keyBlocksTotal += int64(len(kb.data))
+	}
+	meta := make([]byte, 40)
+	binary.BigEndian.PutUint64(meta[0:], uint64(len(keyBlocks)))
+	binary.BigEndian.PutUint64(meta[8:], uint64(keyCount))
+	binary.BigEndian.PutUint64(meta[16:], uint64(keyBlockInfoRawSize))
+	binary.BigEndian.PutUint64(meta[24:], uint64(len(keyBlockInfo)))
+	binary.BigEndian.PutUint64(meta[32:], uint64(keyBlocksTotal))
+	if _, err := cw.Write(meta); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.BigEndian, adler32.Checksum(meta)); err != nil {
+		return cw.n, err
+	}
+
+	// 3. Key block info and key blocks
+	if _, err := cw.Write(keyBlockInfo); err != nil {
+		return cw.n, err
+	}
+	for _, kb := range keyBlocks {
+		if _, err := cw.Write(kb.data); err != nil {
+			return cw.n, err
+		}
+	}
+
+	// 4. Record block metadata, record block info and record blocks
+	recordMeta := make([]byte, 32)
+	binary.BigEndian.PutUint64(recordMeta[0:], uint64(len(recordBlockInfo)/16))
+	binary.BigEndian.PutUint64(recordMeta[8:], uint64(keyCount))
+	binary.BigEndian.PutUint64(recordMeta[16:], uint64(len(recordBlockInfo)))
+	binary.BigEndian.PutUint64(recordMeta[24:], uint64(recordBlocksTotal))
+	if _, err := cw.Write(recordMeta); err != nil {
+		return cw.n, err
+	}
+	if _, err := cw.Write(recordBlockInfo); err != nil {
+		return cw.n, err
+	}
+	if _, err := io.Copy(cw, recordBlocks); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, nil
+}
+
+// writeHeader writes the header size, UTF-16LE header XML and its checksum.
+func (w *Writer) writeHeader(out io.Writer) error {
+	headerBytes := encodeUTF16LE(w.headerXML() + "\x00")
+
+	if err := binary.Write(out, binary.BigEndian, uint32(len(headerBytes))); err != nil {
+		return err
+	}
+	if _, err := out.Write(headerBytes); err != nil {
+		return err
+	}
+	// The header checksum is stored little-endian
+	return binary.Write(out, binary.LittleEndian, adler32.Checksum(headerBytes))
+}
+
+// headerXML builds the header XML string.
+func (w *Writer) headerXML() string {
+	attr := func(name, value string) string {
+		return fmt.Sprintf(` %s="%s"`, name, html.EscapeString(value))
+	}
+
+	var sb strings.Builder
+	if w.dictType == DictTypeMDD {
+		sb.WriteString("<Library_Data")
+		sb.WriteString(attr("GeneratedByEngineVersion", "2.0"))
+		sb.WriteString(attr("RequiredEngineVersion", "2.0"))
+		sb.WriteString(attr("Encrypted", "0"))
+		sb.WriteString(attr("Format", ""))
+		sb.WriteString(attr("CreationDate", w.opts.CreationDate))
+		sb.WriteString(attr("Compact", "No"))
+		sb.WriteString(attr("Compat", "No"))
+		sb.WriteString(attr("KeyCaseSensitive", "No"))
+		sb.WriteString(attr("Stripkey", "No"))
+		sb.WriteString(attr("Description", w.opts.Description))
+		sb.WriteString(attr("Title", w.opts.Title))
+		sb.WriteString(attr("RegisterBy", ""))
+	} else {
+		sb.WriteString("<Dictionary")
+		sb.WriteString(attr("GeneratedByEngineVersion", "2.0"))
+		sb.WriteString(attr("RequiredEngineVersion", "2.0"))
+		sb.WriteString(attr("Encrypted", "No"))
+		sb.WriteString(attr("Encoding", "UTF-8"))
+		sb.WriteString(attr("Format", "Html"))
+		sb.WriteString(attr("Stripkey", "No"))
+		sb.WriteString(attr("CreationDate", w.opts.CreationDate))
+		sb.WriteString(attr("Compact", "No"))
+		sb.WriteString(attr("Compat", "No"))
+		sb.WriteString(attr("KeyCaseSensitive", "No"))
+		sb.WriteString(attr("Description", w.opts.Description))
+		sb.WriteString(attr("Title", w.opts.Title))
+		sb.WriteString(attr("DataSourceFormat", "106"))
+		sb.WriteString(attr("StyleSheet", w.opts.StyleSheet))
+		sb.WriteString(attr("Left2Right", "Yes"))
+		sb.WriteString(attr("RegisterBy", ""))
+	}
+	sb.WriteString("/>\r\n")
+	return sb.String()
+}
+
+// recordSize returns the stored size of the record of the group of entries
+// start to end.
+func (w *Writer) recordSize(start, end int) int64 {
+	var size int64
+	for i := w.recordStart(start, end); i < end; i++ {
+		size += int64(w.entries[i].size)
+	}
+	if w.dictType == DictTypeMDX {
+		// MDX text records are null terminated
+		size++
+	}
+	return size
+}
+
+// buildRecordBlocks groups records into compressed blocks written to out. It
+// returns the record block info section and the total size of the blocks.
+func (w *Writer) buildRecordBlocks(out io.Writer) ([]byte, int64, error) {
+	var info bytes.Buffer
+	var total int64
+	var current bytes.Buffer
+
+	flush := func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		block, err := encodeBlock(current.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to compress record block: %w", err)
+		}
+		if _, err := out.Write(block); err != nil {
+			return err
+		}
+		binary.Write(&info, binary.BigEndian, uint64(len(block)))
+		binary.Write(&info, binary.BigEndian, uint64(current.Len()))
+		total += int64(len(block))
+		current.Reset()
+		return nil
+	}
+
+	for start := 0; start < len(w.entries); {
+		end := w.groupEnd(start)
+		size := w.recordSize(start, end)
+		if current.Len() > 0 && int64(current.Len())+size > int64(w.opts.RecordBlockSize) {
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		for i := w.recordStart(start, end); i < end; i++ {
+			record, err := w.readRecord(&w.entries[i])
+			if err != nil {
+				return nil, 0, err
+			}
+			current.Write(record)
+		}
+		if w.dictType == DictTypeMDX {
+			current.WriteByte(0)
+		}
+		start = end
+	}
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+
+	return info.Bytes(), total, nil
+}
+
+// buildKeyBlocks groups keys into compressed key blocks. Each key stores the
+// decompressed offset of its record, which is the sum of the preceding record
+// sizes.
+func (w *Writer) buildKeyBlocks() ([]*writerKeyBlock, error) {
+	var blocks []*writerKeyBlock
+	var current bytes.Buffer
+	var block *writerKeyBlock
+	var recordOffset int64
+
+	flush := func() error {
+		if block == nil {
+			return nil
+		}
+		data, err := encodeBlock(current.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to compress key block: %w", err)
+		}
+		block.data = data
+		block.rawSize = current.Len()
+		blocks = append(blocks, block)
+		block = nil
+		current.Reset()
+		return nil
+	}
+
+	for i := 0; i < len(w.entries); {
+		entry := &w.entries[i]
+		end := w.groupEnd(i)
+		encodedKey := w.encodeKey(entry.key)
+		size := 8 + len(encodedKey)
+
+		if block != nil && current.Len()+size > w.opts.KeyBlockSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		if block == nil {
+			block = &writerKeyBlock{firstKey: entry.key}
+		}
+
+		binary.Write(&current, binary.BigEndian, uint64(recordOffset))
+		current.Write(encodedKey)
+		recordOffset += w.recordSize(i, end)
+		block.entries++
+		block.lastKey = entry.key
+		i = end
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// buildKeyBlockInfo encodes the key block info section. It returns the
+// compressed section and its decompressed size.
+func (w *Writer) buildKeyBlockInfo(blocks []*writerKeyBlock) ([]byte, int, error) {
+	var info bytes.Buffer
+
+	writeKey := func(key string) {
+		encoded := w.encodeKey(key)
+		// The size is counted in code units, excluding the terminator
+		units := len(encoded) - 1
+		if w.isUTF16() {
+			units = len(encoded)/2 - 1
+		}
+		binary.Write(&info, binary.BigEndian, uint16(units))
+		info.Write(encoded)
+	}
+
+	for _, block := range blocks {
+		binary.Write(&info, binary.BigEndian, uint64(block.entries))
+		writeKey(block.firstKey)
+		writeKey(block.lastKey)
+		binary.Write(&info, binary.BigEndian, uint64(len(block.data)))
+		binary.Write(&info, binary.BigEndian, uint64(block.rawSize))
+	}
+
+	data, err := encodeBlock(info.Bytes())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to compress key block info: %w", err)
+	}
+	return data, info.Len(), nil
+}
+
+// encodeKey encodes a key in the dictionary's key encoding, including the
+// null terminator.
+func (w *Writer) encodeKey(key string) []byte {
+	if w.isUTF16() {
+		return encodeUTF16LE(key + "\x00")
+	}
+	return append([]byte(key), 0)
+}
+
+// isUTF16 reports whether keys are stored as UTF-16LE (MDD files).
+func (w *Writer) isUTF16() bool {
+	return w.dictType == DictTypeMDD
+}
+
+// NormalizeResourceKey converts a resource path into the MDD key form,
+// using backslash separators and a leading backslash.
+func NormalizeResourceKey(path string) string {
+	key := strings.ReplaceAll(path, "/", `\`)
+	if !strings.HasPrefix(key, `\`) {
+		key = `\` + key
+	}
+	return key
+}
+
+// encodeBlock compresses data with zlib and prepends the block header:
+// 4 bytes compression type followed by the big-endian adler32 checksum of
+// the decompressed data.
+func encodeBlock(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{byte(CompressionZlib), 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, adler32.Checksum(data))
+
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeUTF16LE encodes a string as UTF-16 Little Endian bytes.
+func encodeUTF16LE(s string) []byte {
+	u16s := utf16.Encode([]rune(s))
+	data := make([]byte, len(u16s)*2)
+	for i, u := range u16s {
+		binary.LittleEndian.PutUint16(data[i*2:], u)
+	}
+	return data
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,239 @@
+package mdict
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestDict writes a dictionary with the given entries into a temp dir
+// and returns the indexed result.
+func writeTestDict(t *testing.T, name string, dictType DictType, opts WriterOptions, entries map[string][]byte) *Mdict {
+	t.Helper()
+
+	w := NewWriter(dictType, opts)
+	defer w.Close()
+	for key, record := range entries {
+		if err := w.Add(key, record); err != nil {
+			t.Fatalf("Add(%q) failed: %v", key, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := m.BuildIndex(); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	return m
+}
+
+func TestWriterMDXRoundTrip(t *testing.T) {
+	entries := map[string][]byte{
+		"Hello":  []byte("<b>hello</b> 你好"),
+		"apple":  []byte("<i>n.</i> 苹果"),
+		"banana": []byte("香蕉"),
+		"Zebra":  []byte("斑马"),
+	}
+	for i := 0; i < 500; i++ {
+		entries[fmt.Sprintf("word%04d", i)] = []byte(fmt.Sprintf("definition %d", i))
+	}
+
+	// Small blocks force multiple key and record blocks
+	m := writeTestDict(t, "test.mdx", DictTypeMDX, WriterOptions{
+		Title:           "Test & Dict",
+		Description:     "A test dictionary",
+		KeyBlockSize:    256,
+		RecordBlockSize: 512,
+	}, entries)
+
+	if got := m.Title(); got != "Test & Dict" {
+		t.Errorf("Title() = %q", got)
+	}
+	if got := m.WordCount(); got != int64(len(entries)) {
+		t.Errorf("WordCount() = %d, want %d", got, len(entries))
+	}
+	if len(m.KeyBlockInfos) < 2 || len(m.RecordBlockInfos) < 2 {
+		t.Errorf("expected multiple blocks, got %d key blocks and %d record blocks",
+			len(m.KeyBlockInfos), len(m.RecordBlockInfos))
+	}
+
+	for key, want := range entries {
+		got, err := m.Lookup(key)
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", key, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Lookup(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	// Lookup is case-insensitive
+	if got, err := m.Lookup("hello"); err != nil || string(got) != "<b>hello</b> 你好" {
+		t.Errorf("Lookup(hello) = %q, %v", got, err)
+	}
+
+	suggestions := m.Suggest("word001", 20)
+	if len(suggestions) != 10 {
+		t.Errorf("Suggest(word001) returned %d results: %v", len(suggestions), suggestions)
+	}
+}
+
+func TestWriterMDDRoundTrip(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00}
+	entries := map[string][]byte{
+		"img/cat.png":  png,
+		`\sound\a.mp3`: bytes.Repeat([]byte{0xff, 0xfb, 0x00}, 100),
+		"style.css":    []byte("body { color: red; }"),
+	}
+
+	m := writeTestDict(t, "test.mdd", DictTypeMDD, WriterOptions{RecordBlockSize: 64}, entries)
+
+	cases := map[string][]byte{
+		`\img\cat.png`: png,
+		`\sound\a.mp3`: entries[`\sound\a.mp3`],
+		`\style.css`:   entries["style.css"],
+		`\IMG\CAT.PNG`: png,
+	}
+	for key, want := range cases {
+		got, err := m.Lookup(key)
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", key, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Lookup(%q) returned %d bytes, want %d", key, len(got), len(want))
+		}
+	}
+}
+
+func TestWriterSpill(t *testing.T) {
+	entries := make(map[string][]byte)
+	for i := 0; i < 300; i++ {
+		entries[fmt.Sprintf("spill%03d", i)] = bytes.Repeat([]byte{byte('a' + i%26)}, 100+i)
+	}
+
+	tempDir := t.TempDir()
+	w := NewWriter(DictTypeMDX, WriterOptions{MemoryLimit: 1024, TempDir: tempDir, RecordBlockSize: 2048})
+	for key, record := range entries {
+		if err := w.Add(key, record); err != nil {
+			t.Fatalf("Add(%q) failed: %v", key, err)
+		}
+	}
+	if w.spill == nil {
+		t.Fatal("records were not spilled to disk")
+	}
+
+	path := filepath.Join(t.TempDir(), "spill.mdx")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files, _ := os.ReadDir(tempDir); len(files) != 0 {
+		t.Errorf("temporary files left behind: %d", len(files))
+	}
+
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := m.BuildIndex(); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	for key, want := range entries {
+		got, err := m.Lookup(key)
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", key, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Lookup(%q) = %d bytes, want %d", key, len(got), len(want))
+		}
+	}
+}
+
+func TestWriterJoinsDuplicateKeys(t *testing.T) {
+	w := NewWriter(DictTypeMDX, WriterOptions{})
+	defer w.Close()
+	for _, e := range [][2]string{{"run", "<p>verb</p>"}, {"apple", "<p>fruit</p>"}, {"run", "<p>noun</p>"}} {
+		if err := w.Add(e[0], []byte(e[1])); err != nil {
+			t.Fatalf("Add(%q) failed: %v", e[0], err)
+		}
+	}
+	if got := w.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "dup.mdx")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := m.BuildIndex(); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if got := m.WordCount(); got != 2 {
+		t.Errorf("WordCount() = %d, want 2", got)
+	}
+	if got, err := m.Lookup("run"); err != nil || string(got) != "<p>verb</p><p>noun</p>" {
+		t.Errorf("Lookup(run) = %q, %v", got, err)
+	}
+	if got, err := m.Lookup("apple"); err != nil || string(got) != "<p>fruit</p>" {
+		t.Errorf("Lookup(apple) = %q, %v", got, err)
+	}
+}
+
+func TestWriterReplacesDuplicateResources(t *testing.T) {
+	w := NewWriter(DictTypeMDD, WriterOptions{})
+	defer w.Close()
+	for _, e := range [][2]string{{`\a.png`, "first"}, {"b.css", "body {}"}, {"a.png", "second"}} {
+		if err := w.Add(e[0], []byte(e[1])); err != nil {
+			t.Fatalf("Add(%q) failed: %v", e[0], err)
+		}
+	}
+	if got := w.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "dup.mdd")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	m, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := m.BuildIndex(); err != nil {
+		t.Fatalf("BuildIndex failed: %v", err)
+	}
+	if got, err := m.Lookup(`\a.png`); err != nil || string(got) != "second" {
+		t.Errorf("Lookup(a.png) = %q, %v", got, err)
+	}
+	if got, err := m.Lookup(`\b.css`); err != nil || string(got) != "body {}" {
+		t.Errorf("Lookup(b.css) = %q, %v", got, err)
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	w := NewWriter(DictTypeMDX, WriterOptions{})
+	if err := w.Add("  ", []byte("x")); err != ErrEmptyKey {
+		t.Errorf("Add(empty) = %v, want ErrEmptyKey", err)
+	}
+	if err := w.Add("bad", []byte{0xff, 0xfe}); err == nil {
+		t.Error("Add with invalid UTF-8 record should fail")
+	}
+	if _, err := w.WriteTo(&bytes.Buffer{}); err != ErrNoEntries {
+		t.Errorf("WriteTo without entries = %v, want ErrNoEntries", err)
+	}
+}
@@ -0,0 +1,146 @@
+package stardict
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"strings"
+
+	"dict-hub/pkg/xdxf"
+)
+
+// Field is one typed part of a dictionary entry.
+//
+// Lower case types are text: 'm' plain UTF-8, 'l' plain text in the locale
+// encoding, 'g' Pango markup, 't' English phonetics, 'x' XDXF markup,
+// 'y' Chinese YinBiao or Japanese Kana, 'k' KingSoft PowerWord XML,
+// 'w' MediaWiki markup, 'h' HTML, 'n' WordNet data and 'r' a resource file
+// list. Upper case types are binary, e.g. 'W' wav audio and 'P' a picture.
+type Field struct {
+	Type byte
+	Data []byte
+}
+
+// isText reports whether a field type holds NUL-terminated text.
+func isText(t byte) bool {
+	return t >= 'a' && t <= 'z'
+}
+
+// readFields reads and splits the data of the entry at index.
+func (d *Dict) readFields(index int) ([]Field, error) {
+	e := d.entries[index]
+	data := make([]byte, e.size)
+	if _, err := d.data.ReadAt(data, e.offset); err != nil {
+		return nil, fmt.Errorf("stardict: failed to read entry %q: %w", e.word, err)
+	}
+	return parseFields(data, d.SameTypeSequence)
+}
+
+// parseFields splits entry data into fields. With sametypesequence the type
+// characters are omitted from the data and the last field has no
+// terminator or size prefix.
+func parseFields(data []byte, sameTypeSequence string) ([]Field, error) {
+	var fields []Field
+
+	if sameTypeSequence != "" {
+		for i := 0; i < len(sameTypeSequence); i++ {
+			t := sameTypeSequence[i]
+			if i == len(sameTypeSequence)-1 {
+				fields = append(fields, Field{Type: t, Data: data})
+				break
+			}
+			var (
+				f   Field
+				err error
+			)
+			f, data, err = readField(t, data)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+		}
+		return fields, nil
+	}
+
+	for len(data) > 0 {
+		t := data[0]
+		f, rest, err := readField(t, data[1:])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		data = rest
+	}
+	return fields, nil
+}
+
+// readField reads a single NUL-terminated or size-prefixed field.
+func readField(t byte, data []byte) (Field, []byte, error) {
+	if isText(t) {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			// Tolerate a missing terminator on the last field
+			return Field{Type: t, Data: data}, nil, nil
+		}
+		return Field{Type: t, Data: data[:end]}, data[end+1:], nil
+	}
+
+	if len(data) < 4 {
+		return Field{}, nil, fmt.Errorf("stardict: truncated field %q", t)
+	}
+	size := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+size {
+		return Field{}, nil, fmt.Errorf("stardict: truncated field %q", t)
+	}
+	return Field{Type: t, Data: data[4 : 4+size]}, data[4+size:], nil
+}
+
+// renderHTML writes the fields of an entry as HTML.
+func renderHTML(buf *bytes.Buffer, fields []Field) {
+	for _, f := range fields {
+		switch f.Type {
+		case 'h', 'g':
+			buf.Write(f.Data)
+		case 't':
+			buf.WriteString(`<span class="phonetic">[`)
+			buf.WriteString(html.EscapeString(string(f.Data)))
+			buf.WriteString("]</span><br>")
+		case 'x':
+			buf.WriteString(`<div class="xdxf">`)
+			buf.WriteString(xdxf.RenderHTML(string(f.Data)))
+			buf.WriteString("</div>")
+		case 'r':
+			renderResources(buf, string(f.Data))
+		default:
+			if !isText(f.Type) {
+				// Binary data is not embedded in the HTML output
+				continue
+			}
+			buf.WriteString(`<div class="stardict-` + string(f.Type) + `">`)
+			text := html.EscapeString(strings.TrimRight(string(f.Data), "\n"))
+			buf.WriteString(strings.ReplaceAll(text, "\n", "<br>"))
+			buf.WriteString("</div>")
+		}
+	}
+}
+
+// renderResources renders an 'r' field. Each line has the form
+// "type:path" where type is img, snd, vdo or att.
+func renderResources(buf *bytes.Buffer, list string) {
+	for _, line := range strings.Split(list, "\n") {
+		kind, path, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || path == "" {
+			continue
+		}
+		path = html.EscapeString(path)
+		switch kind {
+		case "img":
+			buf.WriteString(`<img src="` + path + `">`)
+		case "snd":
+			buf.WriteString(`<a class="sound" href="sound://` + path + `">&#128266;</a>`)
+		default:
+			buf.WriteString(`<a href="` + path + `">` + path + `</a>`)
+		}
+	}
+}
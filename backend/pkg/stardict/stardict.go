@@ -0,0 +1,408 @@
+// Package stardict reads and writes StarDict dictionaries.
+//
+// A StarDict dictionary consists of an .ifo file with the metadata, an .idx
+// index (optionally gzip compressed), a .dict data file (optionally dictzip
+// compressed) and an optional .syn synonym file. Resources referenced by
+// entries are stored as plain files in a "res" directory next to the .ifo.
+package stardict
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dict-hub/pkg/dictzip"
+)
+
+var (
+	// ErrNotFound is returned when a word is not in the dictionary.
+	ErrNotFound = errors.New("stardict: word not found")
+	// ErrInvalidIfo is returned for .ifo files without the StarDict magic line.
+	ErrInvalidIfo = errors.New("stardict: invalid .ifo file")
+	// ErrInvalidEntry is returned for index entries outside the data file.
+	ErrInvalidEntry = errors.New("stardict: entry outside the data file")
+)
+
+// ifoMagic is the first line of every .ifo file.
+const ifoMagic = "StarDict's dict ifo file"
+
+// Info holds the metadata from the .ifo file.
+type Info struct {
+	Version          string
+	BookName         string
+	WordCount        int64
+	SynWordCount     int64
+	IdxFileSize      int64
+	IdxOffsetBits    int
+	Author           string
+	Email            string
+	Website          string
+	Description      string
+	Date             string
+	SameTypeSequence string
+}
+
+// entry is one record of the .idx file.
+type entry struct {
+	word   string
+	offset int64
+	size   uint32
+}
+
+// key is a sorted lookup key. Synonyms point to the same index as their
+// head word.
+type key struct {
+	word  string
+	lower string
+	index int
+}
+
+// Dict is an opened StarDict dictionary. It is safe for concurrent use.
+type Dict struct {
+	Info
+
+	ifoPath string
+	base    string // path without the .ifo extension
+
+	entries []entry
+	keys    []key
+
+	data     io.ReaderAt
+	dataSize int64 // uncompressed size, an upper bound for dictzip files
+	closer   io.Closer
+}
+
+// Open opens the dictionary described by the given .ifo file.
+func Open(ifoPath string) (*Dict, error) {
+	info, err := ReadInfo(ifoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Dict{
+		Info:    *info,
+		ifoPath: ifoPath,
+		base:    strings.TrimSuffix(ifoPath, filepath.Ext(ifoPath)),
+	}
+
+	// The data file is opened first so index entries can be checked
+	// against its size
+	if err := d.openData(); err != nil {
+		return nil, err
+	}
+	if err := d.readIndex(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	if err := d.readSynonyms(); err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	sort.SliceStable(d.keys, func(i, j int) bool {
+		return d.keys[i].lower < d.keys[j].lower
+	})
+	return d, nil
+}
+
+// ReadInfo parses an .ifo file.
+func ReadInfo(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() || strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")) != ifoMagic {
+		return nil, ErrInvalidIfo
+	}
+
+	info := &Info{IdxOffsetBits: 32}
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(name) {
+		case "version":
+			info.Version = value
+		case "bookname":
+			info.BookName = value
+		case "wordcount":
+			info.WordCount, _ = strconv.ParseInt(value, 10, 64)
+		case "synwordcount":
+			info.SynWordCount, _ = strconv.ParseInt(value, 10, 64)
+		case "idxfilesize":
+			info.IdxFileSize, _ = strconv.ParseInt(value, 10, 64)
+		case "idxoffsetbits":
+			if value == "64" {
+				info.IdxOffsetBits = 64
+			}
+		case "author":
+			info.Author = value
+		case "email":
+			info.Email = value
+		case "website":
+			info.Website = value
+		case "description":
+			// Descriptions use <br> for line breaks
+			info.Description = value
+		case "date":
+			info.Date = value
+		case "sametypesequence":
+			info.SameTypeSequence = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if info.WordCount < 0 || info.SynWordCount < 0 || info.IdxFileSize < 0 {
+		return nil, ErrInvalidIfo
+	}
+	return info, nil
+}
+
+// openFirst opens the first existing file from candidates. Files ending in
+// .gz or .dz are decompressed.
+func openFirst(candidates ...string) ([]byte, error) {
+	for _, path := range candidates {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+
+		if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".dz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			defer gz.Close()
+			return io.ReadAll(gz)
+		}
+		return io.ReadAll(file)
+	}
+	return nil, os.ErrNotExist
+}
+
+// readIndex parses the .idx file.
+func (d *Dict) readIndex() error {
+	data, err := openFirst(d.base+".idx", d.base+".idx.gz", d.base+".idx.dz")
+	if err != nil {
+		return fmt.Errorf("stardict: failed to read index: %w", err)
+	}
+
+	// The word count is only a capacity hint, bounded by the smallest
+	// possible entry (empty word, offset and size) so a corrupt .ifo cannot
+	// force a huge allocation
+	offsetSize := d.IdxOffsetBits / 8
+	d.entries = make([]entry, 0, min(d.WordCount, int64(len(data)/(offsetSize+5))))
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 || len(data) < end+1+offsetSize+4 {
+			return fmt.Errorf("stardict: truncated index at entry %d", len(d.entries))
+		}
+		e := entry{word: string(data[:end])}
+		data = data[end+1:]
+		if offsetSize == 8 {
+			e.offset = int64(binary.BigEndian.Uint64(data))
+		} else {
+			e.offset = int64(binary.BigEndian.Uint32(data))
+		}
+		e.size = binary.BigEndian.Uint32(data[offsetSize:])
+		data = data[offsetSize+4:]
+		if e.offset < 0 || e.offset > d.dataSize || int64(e.size) > d.dataSize-e.offset {
+			return fmt.Errorf("%w: entry %d (%q)", ErrInvalidEntry, len(d.entries), e.word)
+		}
+
+		d.keys = append(d.keys, key{word: e.word, lower: strings.ToLower(e.word), index: len(d.entries)})
+		d.entries = append(d.entries, e)
+	}
+	return nil
+}
+
+// readSynonyms parses the optional .syn file.
+func (d *Dict) readSynonyms() error {
+	data, err := openFirst(d.base + ".syn")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stardict: failed to read synonyms: %w", err)
+	}
+
+	d.keys = slices.Grow(d.keys, int(min(d.SynWordCount, int64(len(data)/5))))
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 || len(data) < end+5 {
+			return fmt.Errorf("stardict: truncated synonym file")
+		}
+		word := string(data[:end])
+		index := int(binary.BigEndian.Uint32(data[end+1:]))
+		data = data[end+5:]
+
+		if index >= len(d.entries) {
+			continue
+		}
+		d.keys = append(d.keys, key{word: word, lower: strings.ToLower(word), index: index})
+	}
+	return nil
+}
+
+// openData opens the .dict or .dict.dz file for random access.
+func (d *Dict) openData() error {
+	if file, err := os.Open(d.base + ".dict"); err == nil {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("stardict: failed to open data file: %w", err)
+		}
+		d.data, d.dataSize, d.closer = file, info.Size(), file
+		return nil
+	}
+	r, err := dictzip.Open(d.base + ".dict.dz")
+	if err != nil {
+		return fmt.Errorf("stardict: failed to open data file: %w", err)
+	}
+	d.data, d.dataSize, d.closer = r, r.Size(), r
+	return nil
+}
+
+// Path returns the path of the .ifo file.
+func (d *Dict) Path() string {
+	return d.ifoPath
+}
+
+// Len returns the number of head words.
+func (d *Dict) Len() int {
+	return len(d.entries)
+}
+
+// find returns the range of keys equal to word (case-insensitive).
+func (d *Dict) find(word string) (int, int) {
+	lower := strings.ToLower(strings.TrimSpace(word))
+	start := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= lower
+	})
+	end := start
+	for end < len(d.keys) && d.keys[end].lower == lower {
+		end++
+	}
+	return start, end
+}
+
+// Lookup returns the entries for word rendered as HTML. Homographs and
+// synonyms pointing to different head words are concatenated.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	start, end := d.find(word)
+	if start == end {
+		return nil, ErrNotFound
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[int]bool)
+	for _, k := range d.keys[start:end] {
+		if seen[k.index] {
+			continue
+		}
+		seen[k.index] = true
+
+		fields, err := d.readFields(k.index)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("<hr>")
+		}
+		renderHTML(&buf, fields)
+	}
+	return buf.Bytes(), nil
+}
+
+// Suggest returns up to limit words starting with prefix (case-insensitive).
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every head word in index order with the entry
+// rendered as HTML. Synonyms are not visited. Returning a non-nil error
+// from fn stops the iteration and returns that error.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	var buf bytes.Buffer
+	for i, e := range d.entries {
+		fields, err := d.readFields(i)
+		if err != nil {
+			return err
+		}
+		buf.Reset()
+		renderHTML(&buf, fields)
+		if err := fn(e.word, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResourceDir returns the directory holding the dictionary resources.
+func (d *Dict) ResourceDir() string {
+	return filepath.Join(filepath.Dir(d.ifoPath), "res")
+}
+
+// Resource reads a file from the resource directory.
+func (d *Dict) Resource(name string) ([]byte, error) {
+	name = filepath.FromSlash(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	path := filepath.Join(d.ResourceDir(), name)
+	// Reject paths escaping the resource directory
+	if rel, err := filepath.Rel(d.ResourceDir(), path); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(path)
+}
+
+// HasResources reports whether the dictionary has a resource directory.
+func (d *Dict) HasResources() bool {
+	info, err := os.Stat(d.ResourceDir())
+	return err == nil && info.IsDir()
+}
+
+// Close closes the data file.
+func (d *Dict) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
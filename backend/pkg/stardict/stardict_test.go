@@ -0,0 +1,204 @@
+package stardict
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"dict-hub/pkg/dictzip"
+)
+
+// writeTestDict writes a StarDict dictionary into dir and returns the .ifo
+// path. entries maps words to raw entry data, synonyms map to head words.
+func writeTestDict(t *testing.T, dir string, sameTypeSequence string, entries map[string]string, synonyms map[string]string, compress bool) string {
+	t.Helper()
+
+	words := make([]string, 0, len(entries))
+	for w := range entries {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+
+	var idx, dict, syn bytes.Buffer
+	positions := make(map[string]int)
+	for i, w := range words {
+		positions[w] = i
+		idx.WriteString(w)
+		idx.WriteByte(0)
+		binary.Write(&idx, binary.BigEndian, uint32(dict.Len()))
+		binary.Write(&idx, binary.BigEndian, uint32(len(entries[w])))
+		dict.WriteString(entries[w])
+	}
+	for s, w := range synonyms {
+		syn.WriteString(s)
+		syn.WriteByte(0)
+		binary.Write(&syn, binary.BigEndian, uint32(positions[w]))
+	}
+
+	base := filepath.Join(dir, "test")
+	ifo := fmt.Sprintf("%s\nversion=3.0.0\nbookname=Test Dict\nwordcount=%d\nsynwordcount=%d\nidxfilesize=%d\ndescription=A test\n",
+		ifoMagic, len(words), len(synonyms), idx.Len())
+	if sameTypeSequence != "" {
+		ifo += "sametypesequence=" + sameTypeSequence + "\n"
+	}
+	os.WriteFile(base+".ifo", []byte(ifo), 0644)
+	os.WriteFile(base+".idx", idx.Bytes(), 0644)
+	if len(synonyms) > 0 {
+		os.WriteFile(base+".syn", syn.Bytes(), 0644)
+	}
+	if compress {
+		if err := dictzip.WriteFile(base+".dict.dz", dict.Bytes()); err != nil {
+			t.Fatalf("dictzip.WriteFile failed: %v", err)
+		}
+	} else {
+		os.WriteFile(base+".dict", dict.Bytes(), 0644)
+	}
+	return base + ".ifo"
+}
+
+func TestOpenAndLookup(t *testing.T) {
+	entries := map[string]string{
+		"apple":  "a fruit\nred or green",
+		"Banana": "a <yellow> fruit",
+	}
+	synonyms := map[string]string{"pomme": "apple"}
+	path := writeTestDict(t, t.TempDir(), "m", entries, synonyms, true)
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.BookName != "Test Dict" || d.WordCount != 2 || d.Len() != 2 {
+		t.Errorf("unexpected info: %+v", d.Info)
+	}
+
+	got, err := d.Lookup("APPLE")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if want := `<div class="stardict-m">a fruit<br>red or green</div>`; string(got) != want {
+		t.Errorf("Lookup(APPLE) = %q, want %q", got, want)
+	}
+
+	if got, err := d.Lookup("banana"); err != nil || !strings.Contains(string(got), "a &lt;yellow&gt; fruit") {
+		t.Errorf("Lookup(banana) = %q, %v", got, err)
+	}
+
+	syn, err := d.Lookup("pomme")
+	if err != nil || !strings.Contains(string(syn), "a fruit") {
+		t.Errorf("Lookup(pomme) = %q, %v", syn, err)
+	}
+
+	if _, err := d.Lookup("cherry"); err != ErrNotFound {
+		t.Errorf("Lookup(cherry) error = %v, want ErrNotFound", err)
+	}
+
+	if got := d.Suggest("p", 10); len(got) != 1 || got[0] != "pomme" {
+		t.Errorf("Suggest(p) = %v", got)
+	}
+
+	var words []string
+	d.Iterate(func(word string, definition []byte) error {
+		words = append(words, word)
+		return nil
+	})
+	if len(words) != 2 {
+		t.Errorf("Iterate visited %v", words)
+	}
+}
+
+func TestTypedFields(t *testing.T) {
+	entries := map[string]string{
+		"cat": "thello\x00m<b>plain</b>\x00h<i>html</i>\x00rimg:cat.png\nsnd:cat.wav",
+	}
+	d, err := Open(writeTestDict(t, t.TempDir(), "", entries, nil, false))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	got, err := d.Lookup("cat")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	for _, want := range []string{
+		`<span class="phonetic">[hello]</span>`,
+		"&lt;b&gt;plain&lt;/b&gt;",
+		"<i>html</i>",
+		`<img src="cat.png">`,
+		`href="sound://cat.wav"`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(cat) = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestResource(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Open(writeTestDict(t, dir, "h", map[string]string{"a": "<img src=\"a.png\">"}, nil, false))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.HasResources() {
+		t.Error("HasResources() = true without res directory")
+	}
+	os.MkdirAll(filepath.Join(dir, "res", "img"), 0755)
+	os.WriteFile(filepath.Join(dir, "res", "img", "a.png"), []byte("png"), 0644)
+
+	if data, err := d.Resource(`\img\a.png`); err != nil || string(data) != "png" {
+		t.Errorf("Resource = %q, %v", data, err)
+	}
+	if _, err := d.Resource("../test.ifo"); err == nil {
+		t.Error("Resource outside res directory should fail")
+	}
+}
+
+func TestCorruptWordCount(t *testing.T) {
+	for _, count := range []string{"-1", "9000000000000000000"} {
+		path := writeTestDict(t, t.TempDir(), "m", map[string]string{"apple": "a fruit"}, map[string]string{"pomme": "apple"}, false)
+		data, _ := os.ReadFile(path)
+		ifo := strings.Replace(string(data), "wordcount=1", "wordcount="+count, 1)
+		ifo = strings.Replace(ifo, "synwordcount=1", "synwordcount="+count, 1)
+		os.WriteFile(path, []byte(ifo), 0644)
+
+		d, err := Open(path)
+		if strings.HasPrefix(count, "-") {
+			if err != ErrInvalidIfo {
+				t.Errorf("Open with wordcount=%s error = %v, want ErrInvalidIfo", count, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Open with wordcount=%s failed: %v", count, err)
+		}
+		if _, err := d.Lookup("pomme"); err != nil {
+			t.Errorf("Lookup(pomme) with wordcount=%s failed: %v", count, err)
+		}
+		d.Close()
+	}
+}
+
+func TestEntryOutsideData(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		path := writeTestDict(t, t.TempDir(), "m", map[string]string{"apple": "a fruit"}, nil, compress)
+		idx := strings.TrimSuffix(path, ".ifo") + ".idx"
+		data, _ := os.ReadFile(idx)
+		binary.BigEndian.PutUint32(data[len(data)-4:], 0xffffffff)
+		os.WriteFile(idx, data, 0644)
+
+		if _, err := Open(path); !errors.Is(err, ErrInvalidEntry) {
+			t.Errorf("Open (compress=%v) error = %v, want ErrInvalidEntry", compress, err)
+		}
+	}
+}
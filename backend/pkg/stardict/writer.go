@@ -0,0 +1,300 @@
+package stardict
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"dict-hub/pkg/dictzip"
+)
+
+var (
+	// ErrEmptyWord is returned when adding an entry with an empty word.
+	ErrEmptyWord = errors.New("stardict: empty word")
+	// ErrNoEntries is returned when writing a dictionary without entries.
+	ErrNoEntries = errors.New("stardict: no entries to write")
+)
+
+// WriterOptions contains the metadata written to the .ifo file.
+type WriterOptions struct {
+	BookName    string
+	Description string
+	Author      string
+	Website     string
+	Date        string // Defaults to the current date (YYYY.MM.DD)
+	TempDir     string // Directory for the temporary data file, defaults to the system temp directory
+}
+
+// writerEntry is a head word with the location of its data.
+type writerEntry struct {
+	word   string
+	offset int64
+	size   uint32
+}
+
+// writerSynonym is a synonym pointing to a head word by name.
+type writerSynonym struct {
+	word   string
+	target string
+}
+
+// Files holds the encoded index files of a dictionary. Syn is empty when the
+// dictionary has no synonyms. The data file stays in the Writer's temporary
+// file and is compressed by WriteDict, so Files is only valid until the
+// Writer is closed.
+type Files struct {
+	Ifo []byte
+	Idx []byte
+	Syn []byte
+
+	data     io.ReaderAt
+	dataSize int64
+	tempDir  string
+}
+
+// Writer builds StarDict dictionaries with HTML entries
+// (sametypesequence=h). Entry data is appended to a temporary file in the
+// order it is added and only the index is kept in memory, so dictionaries
+// much larger than memory can be built; call Close to remove the temporary
+// file. The index is sorted on write.
+type Writer struct {
+	opts     WriterOptions
+	entries  []writerEntry
+	synonyms []writerSynonym
+
+	data     *os.File // nil until the first entry is added
+	buf      *bufio.Writer
+	dataSize int64
+}
+
+// NewWriter creates a new Writer.
+func NewWriter(opts WriterOptions) *Writer {
+	return &Writer{opts: opts}
+}
+
+// Add appends an entry. Adding the same word twice creates homographs.
+func (w *Writer) Add(word string, definition []byte) error {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return ErrEmptyWord
+	}
+	if len(definition) > 0xffffffff {
+		return fmt.Errorf("stardict: entry %q too large", word)
+	}
+	if w.data == nil {
+		file, err := os.CreateTemp(w.opts.TempDir, "stardict-data-*")
+		if err != nil {
+			return fmt.Errorf("stardict: failed to create temporary file: %w", err)
+		}
+		w.data, w.buf = file, bufio.NewWriter(file)
+	}
+	if _, err := w.buf.Write(definition); err != nil {
+		return fmt.Errorf("stardict: failed to write temporary file: %w", err)
+	}
+	w.entries = append(w.entries, writerEntry{
+		word:   word,
+		offset: w.dataSize,
+		size:   uint32(len(definition)),
+	})
+	w.dataSize += int64(len(definition))
+	return nil
+}
+
+// AddSynonym adds word as a synonym of the head word target. Synonyms whose
+// target was never added are dropped on write.
+func (w *Writer) AddSynonym(word, target string) {
+	word, target = strings.TrimSpace(word), strings.TrimSpace(target)
+	if word == "" || target == "" || word == target {
+		return
+	}
+	w.synonyms = append(w.synonyms, writerSynonym{word: word, target: target})
+}
+
+// Len returns the number of head words added so far.
+func (w *Writer) Len() int {
+	return len(w.entries)
+}
+
+// Close removes the temporary data file. The Writer and the Files built by
+// it cannot be used afterwards.
+func (w *Writer) Close() error {
+	if w.data == nil {
+		return nil
+	}
+	w.data.Close()
+	err := os.Remove(w.data.Name())
+	w.data, w.buf = nil, nil
+	return err
+}
+
+// Build encodes the index files. The data file is written by
+// Files.WriteDict.
+func (w *Writer) Build() (*Files, error) {
+	if len(w.entries) == 0 {
+		return nil, ErrNoEntries
+	}
+	if err := w.buf.Flush(); err != nil {
+		return nil, fmt.Errorf("stardict: failed to write temporary file: %w", err)
+	}
+
+	entries := make([]writerEntry, len(w.entries))
+	copy(entries, w.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareWords(entries[i].word, entries[j].word) < 0
+	})
+
+	offsetBits := 32
+	if w.dataSize > 0xffffffff {
+		offsetBits = 64
+	}
+
+	var idx bytes.Buffer
+	positions := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if _, ok := positions[e.word]; !ok {
+			positions[e.word] = i
+		}
+		idx.WriteString(e.word)
+		idx.WriteByte(0)
+		if offsetBits == 64 {
+			binary.Write(&idx, binary.BigEndian, uint64(e.offset))
+		} else {
+			binary.Write(&idx, binary.BigEndian, uint32(e.offset))
+		}
+		binary.Write(&idx, binary.BigEndian, e.size)
+	}
+
+	synonyms := make([]writerSynonym, 0, len(w.synonyms))
+	for _, s := range w.synonyms {
+		if _, ok := positions[s.target]; ok {
+			synonyms = append(synonyms, s)
+		}
+	}
+	sort.SliceStable(synonyms, func(i, j int) bool {
+		return compareWords(synonyms[i].word, synonyms[j].word) < 0
+	})
+
+	var syn bytes.Buffer
+	for _, s := range synonyms {
+		syn.WriteString(s.word)
+		syn.WriteByte(0)
+		binary.Write(&syn, binary.BigEndian, uint32(positions[s.target]))
+	}
+
+	return &Files{
+		Ifo:      w.ifo(len(entries), len(synonyms), idx.Len(), offsetBits),
+		Idx:      idx.Bytes(),
+		Syn:      syn.Bytes(),
+		data:     w.data,
+		dataSize: w.dataSize,
+		tempDir:  w.opts.TempDir,
+	}, nil
+}
+
+// WriteFiles writes base.ifo, base.idx, base.dict.dz and, if there are
+// synonyms, base.syn.
+func (w *Writer) WriteFiles(base string) error {
+	files, err := w.Build()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".ifo", files.Ifo, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".idx", files.Idx, 0644); err != nil {
+		return err
+	}
+	if len(files.Syn) > 0 {
+		if err := os.WriteFile(base+".syn", files.Syn, 0644); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(base + ".dict.dz")
+	if err != nil {
+		return err
+	}
+	if err := files.WriteDict(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteDict streams the compressed data file (.dict.dz) to out.
+func (f *Files) WriteDict(out io.Writer) error {
+	return dictzip.WriteReader(out, io.NewSectionReader(f.data, 0, f.dataSize), f.tempDir)
+}
+
+// ifo renders the .ifo file.
+func (w *Writer) ifo(wordCount, synCount, idxSize, offsetBits int) []byte {
+	date := w.opts.Date
+	if date == "" {
+		date = time.Now().Format("2006.01.02")
+	}
+	bookName := w.opts.BookName
+	if bookName == "" {
+		bookName = "Untitled"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(ifoMagic + "\n")
+	if offsetBits == 64 {
+		buf.WriteString("version=3.0.0\n")
+	} else {
+		buf.WriteString("version=2.4.2\n")
+	}
+	fmt.Fprintf(&buf, "bookname=%s\n", ifoValue(bookName))
+	fmt.Fprintf(&buf, "wordcount=%d\n", wordCount)
+	if synCount > 0 {
+		fmt.Fprintf(&buf, "synwordcount=%d\n", synCount)
+	}
+	fmt.Fprintf(&buf, "idxfilesize=%d\n", idxSize)
+	if offsetBits == 64 {
+		buf.WriteString("idxoffsetbits=64\n")
+	}
+	for _, field := range []struct{ name, value string }{
+		{"author", w.opts.Author},
+		{"website", w.opts.Website},
+		{"description", w.opts.Description},
+		{"date", date},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&buf, "%s=%s\n", field.name, ifoValue(field.value))
+		}
+	}
+	buf.WriteString("sametypesequence=h\n")
+	return buf.Bytes()
+}
+
+// ifoValue makes a value fit on a single .ifo line. Line breaks become <br>.
+func ifoValue(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// compareWords implements the StarDict index order: ASCII case-insensitive
+// comparison with a byte-wise comparison as tie breaker.
+func compareWords(a, b string) int {
+	if c := strings.Compare(asciiLower(a), asciiLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// asciiLower lowercases ASCII letters only, like g_ascii_strcasecmp.
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,77 @@
+package stardict
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	w := NewWriter(WriterOptions{BookName: "Round Trip", Description: "line one\nline two", TempDir: tempDir})
+	defer w.Close()
+	w.Add("banana", []byte("<b>yellow</b>"))
+	w.Add("Apple", []byte("red"))
+	w.Add("apple", []byte("green"))
+	w.AddSynonym("pomme", "Apple")
+	w.AddSynonym("orphan", "cherry")
+
+	if err := w.Add(" ", nil); err != ErrEmptyWord {
+		t.Errorf("Add(empty) error = %v, want ErrEmptyWord", err)
+	}
+
+	base := filepath.Join(t.TempDir(), "rt")
+	if err := w.WriteFiles(base); err != nil {
+		t.Fatalf("WriteFiles failed: %v", err)
+	}
+
+	d, err := Open(base + ".ifo")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.BookName != "Round Trip" || d.WordCount != 3 || d.SynWordCount != 1 || d.SameTypeSequence != "h" {
+		t.Errorf("unexpected info: %+v", d.Info)
+	}
+	if d.Description != "line one<br>line two" {
+		t.Errorf("Description = %q", d.Description)
+	}
+
+	got, err := d.Lookup("APPLE")
+	if err != nil || !strings.Contains(string(got), "red") || !strings.Contains(string(got), "green") {
+		t.Errorf("Lookup(APPLE) = %q, %v", got, err)
+	}
+	if got, err := d.Lookup("banana"); err != nil || string(got) != "<b>yellow</b>" {
+		t.Errorf("Lookup(banana) = %q, %v", got, err)
+	}
+	if got, err := d.Lookup("pomme"); err != nil || !strings.Contains(string(got), "red") {
+		t.Errorf("Lookup(pomme) = %q, %v", got, err)
+	}
+	if _, err := d.Lookup("orphan"); err != ErrNotFound {
+		t.Errorf("synonym without target should be dropped, got %v", err)
+	}
+
+	var words []string
+	d.Iterate(func(word string, definition []byte) error {
+		words = append(words, word)
+		return nil
+	})
+	if strings.Join(words, ",") != "Apple,apple,banana" {
+		t.Errorf("index order = %v", words)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if left, _ := os.ReadDir(tempDir); len(left) != 0 {
+		t.Errorf("temporary files left after Close: %v", left)
+	}
+}
+
+func TestWriterNoEntries(t *testing.T) {
+	if _, err := NewWriter(WriterOptions{}).Build(); err != ErrNoEntries {
+		t.Errorf("Build error = %v, want ErrNoEntries", err)
+	}
+}
@@ -0,0 +1,195 @@
+package wiktionary
+
+import (
+	"html"
+	"strings"
+)
+
+// RenderHTML renders an entry as an HTML fragment. References to other
+// entries use entry:// links.
+func RenderHTML(e *Entry) string {
+	var sb strings.Builder
+	sb.WriteString(`<div class="wiktionary-entry">`)
+
+	sb.WriteString(`<div class="wiktionary-head">`)
+	if len(e.HeadTemplates) > 0 && e.HeadTemplates[0].Expansion != "" {
+		sb.WriteString("<b>" + html.EscapeString(e.HeadTemplates[0].Expansion) + "</b>")
+	} else {
+		sb.WriteString("<b>" + html.EscapeString(e.Word) + "</b>")
+	}
+	if e.Pos != "" {
+		sb.WriteString(` <i class="pos">` + html.EscapeString(e.Pos) + "</i>")
+	}
+	sb.WriteString("</div>")
+
+	renderSounds(&sb, e.Sounds)
+
+	if len(e.Senses) > 0 {
+		sb.WriteString(`<ol class="wiktionary-senses">`)
+		for _, s := range e.Senses {
+			renderSense(&sb, s)
+		}
+		sb.WriteString("</ol>")
+	}
+
+	renderForms(&sb, e.Forms)
+	renderWordList(&sb, "Synonyms", e.Synonyms)
+	renderWordList(&sb, "Antonyms", e.Antonyms)
+	renderWordList(&sb, "Derived terms", e.Derived)
+	renderWordList(&sb, "Related terms", e.Related)
+
+	if e.EtymologyText != "" {
+		sb.WriteString(`<div class="wiktionary-etymology"><b>Etymology</b><p>` + html.EscapeString(e.EtymologyText) + "</p></div>")
+	}
+
+	translations := e.Translations
+	for _, s := range e.Senses {
+		translations = append(translations, s.Translations...)
+	}
+	renderTranslations(&sb, translations)
+
+	sb.WriteString("</div>")
+	return sb.String()
+}
+
+// entryLink renders a link to another entry.
+func entryLink(word string) string {
+	return `<a href="entry://` + html.EscapeString(word) + `">` + html.EscapeString(word) + "</a>"
+}
+
+// renderTags renders tags like "plural" or "archaic" as labels.
+func renderTags(sb *strings.Builder, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	sb.WriteString(`<span class="wiktionary-tags">(` + html.EscapeString(strings.Join(tags, ", ")) + ")</span> ")
+}
+
+func renderSounds(sb *strings.Builder, sounds []Sound) {
+	var ipa []string
+	for _, s := range sounds {
+		if s.IPA == "" {
+			continue
+		}
+		text := html.EscapeString(s.IPA)
+		if len(s.Tags) > 0 {
+			text = html.EscapeString(strings.Join(s.Tags, ", ")) + " " + text
+		}
+		ipa = append(ipa, `<span class="phonetic">`+text+"</span>")
+	}
+	if len(ipa) > 0 {
+		sb.WriteString(`<div class="wiktionary-sounds">` + strings.Join(ipa, "; ") + "</div>")
+	}
+}
+
+func renderSense(sb *strings.Builder, s Sense) {
+	sb.WriteString("<li>")
+	renderTags(sb, s.Tags)
+
+	glosses := s.Glosses
+	// Nested senses repeat the parent gloss first
+	if len(glosses) > 1 {
+		glosses = glosses[len(glosses)-1:]
+	}
+	for _, g := range glosses {
+		sb.WriteString(html.EscapeString(g))
+	}
+
+	var refs []LinkedWord
+	refs = append(refs, s.FormOf...)
+	refs = append(refs, s.AltOf...)
+	if len(refs) > 0 {
+		sb.WriteString(` <span class="wiktionary-form-of">→ `)
+		for i, r := range refs {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(entryLink(r.Word))
+		}
+		sb.WriteString("</span>")
+	}
+
+	if len(s.Examples) > 0 {
+		sb.WriteString(`<ul class="wiktionary-examples">`)
+		for _, ex := range s.Examples {
+			if ex.Text == "" {
+				continue
+			}
+			sb.WriteString("<li><i>" + html.EscapeString(ex.Text) + "</i>")
+			translation := ex.Translation
+			if translation == "" {
+				translation = ex.English
+			}
+			if translation != "" {
+				sb.WriteString(" — " + html.EscapeString(translation))
+			}
+			sb.WriteString("</li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	sb.WriteString("</li>")
+}
+
+func renderForms(sb *strings.Builder, forms []Form) {
+	var items []string
+	for _, f := range forms {
+		skip := f.Form == "" || f.Form == "-"
+		for _, tag := range f.Tags {
+			if skipFormTags[tag] {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		item := html.EscapeString(f.Form)
+		if len(f.Tags) > 0 {
+			item += ` <span class="wiktionary-tags">(` + html.EscapeString(strings.Join(f.Tags, ", ")) + ")</span>"
+		}
+		items = append(items, item)
+	}
+	if len(items) > 0 {
+		sb.WriteString(`<div class="wiktionary-forms"><b>Forms</b>: ` + strings.Join(items, ", ") + "</div>")
+	}
+}
+
+func renderWordList(sb *strings.Builder, title string, words []LinkedWord) {
+	var links []string
+	for _, w := range words {
+		if w.Word != "" {
+			links = append(links, entryLink(w.Word))
+		}
+	}
+	if len(links) > 0 {
+		sb.WriteString(`<div class="wiktionary-related"><b>` + title + "</b>: " + strings.Join(links, ", ") + "</div>")
+	}
+}
+
+// renderTranslations renders translations grouped by language in a
+// collapsed block, as common words have hundreds of them.
+func renderTranslations(sb *strings.Builder, translations []Translation) {
+	var langs []string
+	byLang := make(map[string][]string)
+	for _, t := range translations {
+		if t.Word == "" {
+			continue
+		}
+		lang := t.Lang
+		if lang == "" {
+			lang = t.Code
+		}
+		if _, ok := byLang[lang]; !ok {
+			langs = append(langs, lang)
+		}
+		byLang[lang] = append(byLang[lang], html.EscapeString(t.Word))
+	}
+	if len(langs) == 0 {
+		return
+	}
+
+	sb.WriteString(`<details class="wiktionary-translations"><summary>Translations</summary><ul>`)
+	for _, lang := range langs {
+		sb.WriteString("<li><b>" + html.EscapeString(lang) + "</b>: " + strings.Join(byLang[lang], ", ") + "</li>")
+	}
+	sb.WriteString("</ul></details>")
+}
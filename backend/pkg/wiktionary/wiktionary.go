@@ -0,0 +1,241 @@
+// Package wiktionary reads the Wiktionary extracts published by kaikki.org.
+//
+// A dump is a JSON Lines file (optionally gzip compressed) with one object per
+// word and part of speech, as produced by wiktextract. Entries are decoded
+// one at a time, so dumps of several gigabytes can be streamed.
+package wiktionary
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Entry is a word and part of speech with its senses.
+type Entry struct {
+	Word          string        `json:"word"`
+	Pos           string        `json:"pos"`
+	Lang          string        `json:"lang"`
+	LangCode      string        `json:"lang_code"`
+	EtymologyText string        `json:"etymology_text"`
+	HeadTemplates []Template    `json:"head_templates"`
+	Senses        []Sense       `json:"senses"`
+	Sounds        []Sound       `json:"sounds"`
+	Forms         []Form        `json:"forms"`
+	Translations  []Translation `json:"translations"`
+	Synonyms      []LinkedWord  `json:"synonyms"`
+	Antonyms      []LinkedWord  `json:"antonyms"`
+	Derived       []LinkedWord  `json:"derived"`
+	Related       []LinkedWord  `json:"related"`
+}
+
+// Template is an expanded Wiktionary template.
+type Template struct {
+	Name      string `json:"name"`
+	Expansion string `json:"expansion"`
+}
+
+// Sense is a single meaning of an entry.
+type Sense struct {
+	Glosses      []string      `json:"glosses"`
+	Tags         []string      `json:"tags"`
+	Examples     []Example     `json:"examples"`
+	FormOf       []LinkedWord  `json:"form_of"`
+	AltOf        []LinkedWord  `json:"alt_of"`
+	Synonyms     []LinkedWord  `json:"synonyms"`
+	Translations []Translation `json:"translations"`
+}
+
+// Example is a usage example of a sense.
+type Example struct {
+	Text        string `json:"text"`
+	English     string `json:"english"`
+	Translation string `json:"translation"`
+}
+
+// Sound is a pronunciation.
+type Sound struct {
+	IPA  string   `json:"ipa"`
+	Enpr string   `json:"enpr"`
+	Tags []string `json:"tags"`
+}
+
+// Form is an inflected or alternative form of an entry.
+type Form struct {
+	Form string   `json:"form"`
+	Tags []string `json:"tags"`
+}
+
+// Translation is a translation of an entry into another language.
+type Translation struct {
+	Lang  string   `json:"lang"`
+	Code  string   `json:"code"`
+	Word  string   `json:"word"`
+	Sense string   `json:"sense"`
+	Tags  []string `json:"tags"`
+}
+
+// LinkedWord is a reference to another entry.
+type LinkedWord struct {
+	Word string `json:"word"`
+}
+
+// Reader decodes entries from a JSON Lines dump.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+	closer  io.Closer
+
+	// Set by OpenFile for progress reporting
+	counter *countingReader
+	size    int64
+}
+
+// countingReader counts the bytes read from the file.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// maxLineSize bounds a single JSON line. Entries with large translation
+// tables reach a few megabytes.
+const maxLineSize = 64 << 20
+
+// NewReader returns a Reader decoding entries from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1<<20), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// OpenFile opens a dump, decompressing it when the name ends in ".gz".
+func OpenFile(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	counter := &countingReader{r: f}
+	var r io.Reader = counter
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = gz
+	}
+	reader := NewReader(r)
+	reader.closer = f
+	reader.counter = counter
+	reader.size = info.Size()
+	return reader, nil
+}
+
+// Next returns the next entry, or io.EOF at the end of the dump. Blank lines
+// are skipped.
+func (r *Reader) Next() (*Entry, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("wiktionary: line %d: %w", r.line, err)
+		}
+		return &e, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("wiktionary: line %d: %w", r.line+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Line returns the number of lines read so far.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Progress returns the number of file bytes consumed and the file size.
+// Both are zero for readers not created by OpenFile.
+func (r *Reader) Progress() (done, total int64) {
+	if r.counter == nil {
+		return 0, 0
+	}
+	return r.counter.n, r.size
+}
+
+// Close closes the underlying file when the Reader was created by OpenFile.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// skipFormTags marks form rows that describe inflection tables rather than
+// actual word forms.
+var skipFormTags = map[string]bool{
+	"table-tags":          true,
+	"inflection-template": true,
+	"class":               true,
+}
+
+// InflectedForm maps a word form to its lemma.
+type InflectedForm struct {
+	Form  string
+	Lemma string
+	Tags  string
+}
+
+// InflectedForms returns the forms listed in the entry and, for entries that
+// are themselves forms of another word ("went" is the past tense of "go"),
+// the lemmas they point to.
+func (e *Entry) InflectedForms() []InflectedForm {
+	var forms []InflectedForm
+	seen := make(map[string]bool)
+	add := func(form, lemma string, tags []string) {
+		form, lemma = strings.TrimSpace(form), strings.TrimSpace(lemma)
+		if form == "" || lemma == "" || form == lemma || form == "-" || seen[form+"\x00"+lemma] {
+			return
+		}
+		seen[form+"\x00"+lemma] = true
+		forms = append(forms, InflectedForm{Form: form, Lemma: lemma, Tags: strings.Join(tags, " ")})
+	}
+
+	for _, f := range e.Forms {
+		skip := false
+		for _, tag := range f.Tags {
+			if skipFormTags[tag] {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			add(f.Form, e.Word, f.Tags)
+		}
+	}
+	for _, s := range e.Senses {
+		for _, lemma := range s.FormOf {
+			add(e.Word, lemma.Word, s.Tags)
+		}
+	}
+	return forms
+}
@@ -0,0 +1,81 @@
+package wiktionary
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDump = `{"word":"go","pos":"verb","lang":"English","lang_code":"en","etymology_text":"From Old English gān.","sounds":[{"ipa":"/ɡəʊ/","tags":["UK"]}],"forms":[{"form":"goes","tags":["present","singular","third-person"]},{"form":"went","tags":["past"]},{"form":"en-verb","tags":["inflection-template"]}],"senses":[{"glosses":["To move from one place to another."],"examples":[{"text":"We go to school."}],"translations":[{"lang":"French","code":"fr","word":"aller"}]}],"synonyms":[{"word":"move"}]}
+
+{"word":"went","pos":"verb","lang":"English","lang_code":"en","senses":[{"glosses":["simple past of go"],"tags":["form-of","past"],"form_of":[{"word":"go"}]}]}
+`
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader(testDump))
+	var entries []*Entry
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 || entries[0].Word != "go" || entries[1].Word != "went" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	forms := entries[0].InflectedForms()
+	if len(forms) != 2 || forms[0].Form != "goes" || forms[0].Lemma != "go" || forms[1].Tags != "past" {
+		t.Errorf("InflectedForms(go) = %+v", forms)
+	}
+	forms = entries[1].InflectedForms()
+	if len(forms) != 1 || forms[0].Form != "went" || forms[0].Lemma != "go" {
+		t.Errorf("InflectedForms(went) = %+v", forms)
+	}
+
+	got := RenderHTML(entries[0])
+	for _, want := range []string{"<b>go</b>", "verb", "/ɡəʊ/", "To move", "We go to school.", "goes", "Old English", "aller", `<a href="entry://move">move</a>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHTML(go) missing %q: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "en-verb") {
+		t.Errorf("RenderHTML(go) includes template form: %s", got)
+	}
+	if got := RenderHTML(entries[1]); !strings.Contains(got, `<a href="entry://go">go</a>`) {
+		t.Errorf("RenderHTML(went) = %s", got)
+	}
+}
+
+func TestOpenFileGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kaikki.jsonl.gz")
+	f, _ := os.Create(path)
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(testDump))
+	gz.Close()
+	f.Close()
+
+	r, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer r.Close()
+	if e, err := r.Next(); err != nil || e.Word != "go" {
+		t.Errorf("Next() = %v, %v", e, err)
+	}
+}
+
+func TestReaderInvalidLine(t *testing.T) {
+	r := NewReader(strings.NewReader("{\"word\":\"a\"}\nnot json\n"))
+	r.Next()
+	if _, err := r.Next(); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected line 2 error, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package xdxf
+
+import (
+	"encoding/xml"
+	"html"
+	"io"
+	"path"
+	"strings"
+)
+
+// simpleTags maps XDXF elements to HTML wrappers.
+var simpleTags = map[string][2]string{
+	"ar":         {`<div class="xdxf">`, "</div>"},
+	"k":          {`<div class="k"><b>`, "</b></div>"},
+	"tr":         {`<span class="tr">[`, "]</span>"},
+	"ex":         {`<span class="ex">`, "</span>"},
+	"co":         {`<span class="co">`, "</span>"},
+	"abr":        {`<i class="abr">`, "</i>"},
+	"abbr":       {`<i class="abr">`, "</i>"},
+	"dtrn":       {`<span class="dtrn">`, "</span>"},
+	"def":        {`<div class="def">`, "</div>"},
+	"gr":         {`<span class="gr">`, "</span>"},
+	"pos":        {`<i class="pos">`, "</i>"},
+	"categ":      {`<span class="categ">`, "</span>"},
+	"blockquote": {"<blockquote>", "</blockquote>"},
+	"b":          {"<b>", "</b>"},
+	"i":          {"<i>", "</i>"},
+	"u":          {"<u>", "</u>"},
+	"sub":        {"<sub>", "</sub>"},
+	"sup":        {"<sup>", "</sup>"},
+	"big":        {"<big>", "</big>"},
+	"small":      {"<small>", "</small>"},
+	"br":         {"<br>", ""},
+}
+
+// RenderHTML converts an XDXF fragment in visual format to HTML. It is
+// also used for XDXF fields embedded in other formats.
+func RenderHTML(fragment string) string {
+	return render(fragment, true)
+}
+
+// render converts XDXF markup to HTML. In visual format line breaks of the
+// source are kept.
+func render(fragment string, visual bool) string {
+	var out strings.Builder
+	dec := newDecoder(strings.NewReader(fragment))
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Emit the rest as text rather than losing the article
+			out.WriteString(html.EscapeString(fragment[dec.InputOffset():]))
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch name {
+			case "kref":
+				word := readText(dec)
+				out.WriteString(`<a href="entry://` + html.EscapeString(word) + `">` + html.EscapeString(word) + `</a>`)
+			case "iref":
+				target := attr(t, "href")
+				text := readText(dec)
+				if text == "" {
+					text = target
+				}
+				out.WriteString(`<a href="` + html.EscapeString(target) + `">` + html.EscapeString(text) + `</a>`)
+			case "rref":
+				out.WriteString(resourceHTML(strings.TrimSpace(readText(dec))))
+			case "c":
+				color := attr(t, "c")
+				if color == "" || !isSafeColor(color) {
+					color = "green"
+				}
+				out.WriteString(`<span style="color:` + color + `">`)
+			default:
+				if tag, ok := simpleTags[name]; ok {
+					out.WriteString(tag[0])
+				}
+			}
+
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if name == "c" {
+				out.WriteString("</span>")
+			} else if tag, ok := simpleTags[name]; ok {
+				out.WriteString(tag[1])
+			}
+
+		case xml.CharData:
+			text := html.EscapeString(string(t))
+			if visual {
+				text = strings.ReplaceAll(strings.Trim(text, "\r\n"), "\n", "<br>")
+			}
+			out.WriteString(text)
+		}
+	}
+	return out.String()
+}
+
+// readText consumes the content of the current element and returns its text.
+func readText(dec *xml.Decoder) string {
+	var sb strings.Builder
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			sb.Write(t)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// attr returns the value of an attribute.
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// isSafeColor reports whether a color name can be put into a style attribute.
+func isSafeColor(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '#') {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceHTML renders an <rref> element.
+func resourceHTML(name string) string {
+	escaped := html.EscapeString(name)
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".svg", ".webp":
+		return `<img src="` + escaped + `">`
+	case ".wav", ".mp3", ".ogg", ".oga", ".spx", ".opus", ".m4a":
+		return `<a class="sound" href="sound://` + escaped + `">&#128266;</a>`
+	}
+	return `<a href="` + escaped + `">` + escaped + `</a>`
+}
@@ -0,0 +1,473 @@
+// Package xdxf reads XDXF dictionaries.
+//
+// An XDXF dictionary is a single XML file (.xdxf or .xdx, optionally gzip or
+// dictzip compressed) with one <ar> element per article. The index records
+// the byte range of every article so that articles are parsed on demand.
+package xdxf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dict-hub/pkg/dictzip"
+
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// ErrNotFound is returned when a word is not in the dictionary.
+var ErrNotFound = errors.New("xdxf: word not found")
+
+// encodingPattern finds the encoding in the XML declaration.
+var encodingPattern = regexp.MustCompile(`encoding=["']([^"']+)["']`)
+
+// article is the byte range of one <ar> element.
+type article struct {
+	headword string
+	offset   int64
+	size     int64
+}
+
+// key is a sorted lookup key.
+type key struct {
+	word    string
+	lower   string
+	article int
+}
+
+// Dict is an opened XDXF dictionary. It is safe for concurrent use.
+type Dict struct {
+	path string
+
+	Title       string
+	Description string
+	LangFrom    string
+	LangTo      string
+
+	// visual format keeps line breaks of the source
+	visual bool
+
+	articles []article
+	keys     []key
+
+	data   io.ReaderAt
+	closer io.Closer
+}
+
+// isCompressed reports whether the file name indicates gzip compression.
+func isCompressed(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".dz")
+}
+
+// Open opens an XDXF file and builds the key index.
+func Open(path string) (*Dict, error) {
+	d := &Dict{path: path, visual: true}
+
+	if isCompressed(path) {
+		r, err := dictzip.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		d.data, d.closer = r, r
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		d.data, d.closer = file, file
+	}
+
+	if err := d.buildIndex(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("xdxf: %s: %w", path, err)
+	}
+
+	sort.SliceStable(d.keys, func(i, j int) bool {
+		return d.keys[i].lower < d.keys[j].lower
+	})
+	return d, nil
+}
+
+// openStream returns the uncompressed content for sequential reading.
+// Files in a legacy encoding are converted to UTF-8 in memory, and the
+// article offsets then refer to the converted content.
+func (d *Dict) openStream() (io.Reader, func(), error) {
+	file, err := os.Open(d.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var r io.Reader = file
+	closeFn := func() { file.Close() }
+	if isCompressed(d.path) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+	prolog, _ := br.Peek(200)
+	m := encodingPattern.FindSubmatch(prolog)
+	if m == nil || strings.EqualFold(string(m[1]), "utf-8") {
+		return br, closeFn, nil
+	}
+
+	enc, err := ianaindex.IANA.Encoding(string(m[1]))
+	if err != nil || enc == nil {
+		closeFn()
+		return nil, nil, fmt.Errorf("unsupported encoding %q", m[1])
+	}
+	raw, err := io.ReadAll(br)
+	closeFn()
+	if err != nil {
+		return nil, nil, err
+	}
+	converted, err := enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The declaration no longer matches the content
+	converted = encodingPattern.ReplaceAll(converted, []byte(`encoding="UTF-8"`))
+
+	if d.closer != nil {
+		d.closer.Close()
+	}
+	d.data, d.closer = bytes.NewReader(converted), nil
+	return bytes.NewReader(converted), func() {}, nil
+}
+
+// newDecoder returns a lenient XML decoder. Real world XDXF files often
+// use HTML entities and unclosed tags.
+func newDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+	return dec
+}
+
+// buildIndex walks the XML once and records every article.
+func (d *Dict) buildIndex() error {
+	r, closeFn, err := d.openStream()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	dec := newDecoder(r)
+	var (
+		path     []string // open element names
+		text     strings.Builder
+		inKey    bool
+		keys     []string
+		arOffset int64
+	)
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			path = append(path, name)
+			switch name {
+			case "xdxf":
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "lang_from":
+						d.LangFrom = attr.Value
+					case "lang_to":
+						d.LangTo = attr.Value
+					case "format":
+						d.visual = attr.Value != "logical"
+					}
+				}
+			case "ar":
+				arOffset = offset
+				keys = nil
+			case "k":
+				if len(path) >= 2 && path[len(path)-2] == "ar" {
+					inKey = true
+					text.Reset()
+				}
+			case "opt":
+				if inKey {
+					text.WriteString("(")
+				}
+			case "nu":
+				if inKey {
+					text.WriteString("{")
+				}
+			default:
+				if isMetaElement(name) {
+					text.Reset()
+				}
+			}
+
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			switch name {
+			case "k":
+				if inKey {
+					inKey = false
+					keys = append(keys, text.String())
+				}
+			case "opt":
+				if inKey {
+					text.WriteString(")")
+				}
+			case "nu":
+				if inKey {
+					text.WriteString("}")
+				}
+			case "ar":
+				d.addArticle(keys, arOffset, dec.InputOffset())
+			case "full_name", "title", "full_title":
+				if d.Title == "" || name == "full_title" {
+					d.Title = strings.TrimSpace(text.String())
+				}
+			case "description":
+				if d.Description == "" {
+					d.Description = strings.TrimSpace(text.String())
+				}
+			}
+
+		case xml.CharData:
+			if inKey || (len(path) > 0 && isMetaElement(path[len(path)-1])) {
+				text.Write(t)
+			}
+		}
+	}
+	return nil
+}
+
+// isMetaElement reports whether an element holds dictionary metadata.
+func isMetaElement(name string) bool {
+	switch name {
+	case "full_name", "title", "full_title", "description":
+		return true
+	}
+	return false
+}
+
+// addArticle adds an article and its keys to the index. Keys written as
+// "a(b)c" with <opt> are indexed with and without the optional part, {nu}
+// parts are not indexed.
+func (d *Dict) addArticle(rawKeys []string, start, end int64) {
+	if len(rawKeys) == 0 {
+		return
+	}
+	index := len(d.articles)
+	headword := ""
+	seen := make(map[string]bool)
+	for _, raw := range rawKeys {
+		for _, k := range expandKey(raw) {
+			if headword == "" {
+				headword = k
+			}
+			if !seen[k] {
+				seen[k] = true
+				d.keys = append(d.keys, key{word: k, lower: strings.ToLower(k), article: index})
+			}
+		}
+	}
+	if headword == "" {
+		return
+	}
+	d.articles = append(d.articles, article{headword: headword, offset: start, size: end - start})
+}
+
+// expandKey expands (optional) parts and drops {unused} parts.
+func expandKey(raw string) []string {
+	var full, short strings.Builder
+	optional, unused := false, false
+	for _, r := range raw {
+		switch {
+		case r == '{':
+			unused = true
+		case r == '}':
+			unused = false
+		case unused:
+		case r == '(':
+			optional = true
+		case r == ')':
+			optional = false
+		default:
+			full.WriteRune(r)
+			if !optional {
+				short.WriteRune(r)
+			}
+		}
+	}
+
+	var keys []string
+	for _, k := range []string{full.String(), short.String()} {
+		k = strings.Join(strings.Fields(k), " ")
+		if k != "" && (len(keys) == 0 || keys[0] != k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Name returns the file name without extensions.
+func (d *Dict) Name() string {
+	name := filepath.Base(d.path)
+	if isCompressed(name) {
+		name = name[:len(name)-3]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Path returns the file path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Len returns the number of articles.
+func (d *Dict) Len() int {
+	return len(d.articles)
+}
+
+// readArticle reads and renders the article at index.
+func (d *Dict) readArticle(index int) (string, error) {
+	a := d.articles[index]
+	raw := make([]byte, a.size)
+	if _, err := d.data.ReadAt(raw, a.offset); err != nil && err != io.EOF {
+		return "", fmt.Errorf("xdxf: failed to read article %q: %w", a.headword, err)
+	}
+	return render(string(raw), d.visual), nil
+}
+
+// find returns the range of keys equal to word (case-insensitive).
+func (d *Dict) find(word string) (int, int) {
+	lower := strings.ToLower(strings.TrimSpace(word))
+	start := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= lower
+	})
+	end := start
+	for end < len(d.keys) && d.keys[end].lower == lower {
+		end++
+	}
+	return start, end
+}
+
+// Lookup returns the articles for word rendered as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	start, end := d.find(word)
+	if start == end {
+		return nil, ErrNotFound
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[int]bool)
+	for _, k := range d.keys[start:end] {
+		if seen[k.article] {
+			continue
+		}
+		seen[k.article] = true
+
+		html, err := d.readArticle(k.article)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("<hr>")
+		}
+		buf.WriteString(html)
+	}
+	return buf.Bytes(), nil
+}
+
+// Suggest returns up to limit keys starting with prefix (case-insensitive).
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every article in file order with its first key and
+// the rendered HTML. Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for i, a := range d.articles {
+		html, err := d.readArticle(i)
+		if err != nil {
+			return err
+		}
+		if err := fn(a.headword, []byte(html)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResourceDir returns the directory holding files referenced by <rref>.
+func (d *Dict) ResourceDir() string {
+	return filepath.Join(filepath.Dir(d.path), "res")
+}
+
+// HasResources reports whether the dictionary has a resource directory.
+func (d *Dict) HasResources() bool {
+	info, err := os.Stat(d.ResourceDir())
+	return err == nil && info.IsDir()
+}
+
+// Resource reads a file from the resource directory.
+func (d *Dict) Resource(name string) ([]byte, error) {
+	name = filepath.FromSlash(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	path := filepath.Join(d.ResourceDir(), name)
+	// Reject paths escaping the resource directory
+	if rel, err := filepath.Rel(d.ResourceDir(), path); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(path)
+}
+
+// Close closes the dictionary file.
+func (d *Dict) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
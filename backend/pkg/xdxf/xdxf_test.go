@@ -0,0 +1,111 @@
+package xdxf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const testXDXF = `<?xml version="1.0" encoding="UTF-8" ?>
+<!DOCTYPE xdxf SYSTEM "http://xdxf.sourceforge.net/xdxf_lousy.dtd">
+<xdxf lang_from="ENG" lang_to="RUS" format="visual">
+<full_name>Test XDXF</full_name>
+<description>A small &amp; simple test</description>
+<ar><k>apple</k>
+<tr>ˈæpl</tr>
+<abr>n.</abr> яблоко
+<ex>an apple a day</ex> see <kref>pear</kref></ar>
+<ar><k>colo<opt>u</opt>r</k><k>hue</k>
+цвет &nbsp;<rref>color.png</rref></ar>
+<ar><k>pear</k>
+груша</ar>
+</xdxf>
+`
+
+func openTest(t *testing.T, name string, content []byte) *Dict {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestOpenAndLookup(t *testing.T) {
+	d := openTest(t, "test.xdxf", []byte(testXDXF))
+
+	if d.Title != "Test XDXF" || d.Description != "A small & simple test" || d.LangFrom != "ENG" {
+		t.Errorf("unexpected metadata: %q %q %q", d.Title, d.Description, d.LangFrom)
+	}
+	if d.Len() != 3 || d.Name() != "test" {
+		t.Errorf("Len() = %d, Name() = %q", d.Len(), d.Name())
+	}
+
+	got, err := d.Lookup("Apple")
+	if err != nil {
+		t.Fatalf("Lookup(Apple) failed: %v", err)
+	}
+	for _, want := range []string{
+		`<div class="k"><b>apple</b></div>`,
+		`<span class="tr">[ˈæpl]</span>`,
+		`<i class="abr">n.</i>`,
+		`<span class="ex">an apple a day</span>`,
+		`<a href="entry://pear">pear</a>`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(Apple) = %q, missing %q", got, want)
+		}
+	}
+
+	for _, word := range []string{"color", "colour", "hue"} {
+		got, err := d.Lookup(word)
+		if err != nil || !strings.Contains(string(got), "цвет") || !strings.Contains(string(got), `<img src="color.png">`) {
+			t.Errorf("Lookup(%s) = %q, %v", word, got, err)
+		}
+	}
+
+	if got := d.Suggest("co", 10); strings.Join(got, ",") != "color,colour" {
+		t.Errorf("Suggest(co) = %v", got)
+	}
+
+	var words []string
+	d.Iterate(func(word string, definition []byte) error {
+		words = append(words, word)
+		return nil
+	})
+	if strings.Join(words, ",") != "apple,colour,pear" {
+		t.Errorf("Iterate visited %v", words)
+	}
+}
+
+func TestOpenGzipAndLegacyEncoding(t *testing.T) {
+	legacy := strings.Replace(testXDXF, "UTF-8", "windows-1251", 1)
+	legacy = strings.ReplaceAll(legacy, "ˈæpl", "apl")
+	encoded, err := charmap.Windows1251.NewEncoder().String(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(encoded))
+	gz.Close()
+
+	d := openTest(t, "legacy.xdxf.gz", buf.Bytes())
+	if d.Name() != "legacy" {
+		t.Errorf("Name() = %q", d.Name())
+	}
+	if got, err := d.Lookup("pear"); err != nil || !strings.Contains(string(got), "груша") {
+		t.Errorf("Lookup(pear) = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,227 @@
+package yomitan
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// containerTags are structured-content elements rendered as the same HTML
+// element.
+var containerTags = map[string]bool{
+	"ruby": true, "rt": true, "rp": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+	"span": true, "div": true, "ol": true, "ul": true, "li": true,
+	"details": true, "summary": true,
+}
+
+// renderGlossary renders one glossary item of a term: a plain string, a
+// text or image object, or structured content.
+func renderGlossary(sb *strings.Builder, raw json.RawMessage) {
+	var item any
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return
+	}
+
+	switch v := item.(type) {
+	case string:
+		writeText(sb, v)
+	case map[string]any:
+		switch v["type"] {
+		case "text":
+			writeText(sb, stringValue(v["text"]))
+		case "image":
+			renderImage(sb, v)
+		case "structured-content":
+			renderNode(sb, v["content"])
+		}
+	}
+}
+
+// writeText writes escaped text, keeping line breaks.
+func writeText(sb *strings.Builder, text string) {
+	sb.WriteString(strings.ReplaceAll(html.EscapeString(text), "\n", "<br>"))
+}
+
+// renderNode renders a structured-content node.
+func renderNode(sb *strings.Builder, node any) {
+	switch v := node.(type) {
+	case string:
+		writeText(sb, v)
+	case []any:
+		for _, child := range v {
+			renderNode(sb, child)
+		}
+	case map[string]any:
+		tag := stringValue(v["tag"])
+		switch {
+		case tag == "br":
+			sb.WriteString("<br>")
+		case tag == "img":
+			renderImage(sb, v)
+		case tag == "a":
+			href := linkTarget(stringValue(v["href"]))
+			sb.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+			renderNode(sb, v["content"])
+			sb.WriteString("</a>")
+		case containerTags[tag]:
+			sb.WriteString("<" + tag + attributes(v) + ">")
+			renderNode(sb, v["content"])
+			sb.WriteString("</" + tag + ">")
+		default:
+			renderNode(sb, v["content"])
+		}
+	}
+}
+
+// linkTarget converts Yomitan links. Internal links of the form
+// "?query=word" become entry:// links, only http(s) links are kept.
+func linkTarget(href string) string {
+	if strings.HasPrefix(href, "?") {
+		values, err := url.ParseQuery(href[1:])
+		if err == nil && values.Get("query") != "" {
+			return "entry://" + values.Get("query")
+		}
+		return "#"
+	}
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href
+	}
+	return "#"
+}
+
+// attributes renders the style, data and other safe attributes of a node.
+func attributes(node map[string]any) string {
+	var sb strings.Builder
+
+	if style, ok := node["style"].(map[string]any); ok {
+		if css := styleCSS(style); css != "" {
+			sb.WriteString(` style="` + html.EscapeString(css) + `"`)
+		}
+	}
+	if data, ok := node["data"].(map[string]any); ok {
+		for _, k := range sortedKeys(data) {
+			if isSafeName(k) {
+				sb.WriteString(` data-sc-` + k + `="` + html.EscapeString(stringValue(data[k])) + `"`)
+			}
+		}
+	}
+	for _, name := range []string{"lang", "title", "colSpan", "rowSpan"} {
+		if value := stringValue(node[name]); value != "" {
+			sb.WriteString(" " + strings.ToLower(name) + `="` + html.EscapeString(value) + `"`)
+		}
+	}
+	if open, ok := node["open"].(bool); ok && open {
+		sb.WriteString(" open")
+	}
+	return sb.String()
+}
+
+// lengthProperties take numbers in em.
+var lengthProperties = map[string]bool{
+	"marginTop": true, "marginLeft": true, "marginRight": true, "marginBottom": true,
+	"paddingTop": true, "paddingLeft": true, "paddingRight": true, "paddingBottom": true,
+}
+
+// styleCSS converts a structured-content style object to CSS.
+func styleCSS(style map[string]any) string {
+	var parts []string
+	for _, k := range sortedKeys(style) {
+		if !isSafeName(k) {
+			continue
+		}
+		value := stringValue(style[k])
+		if _, isNumber := style[k].(float64); isNumber && lengthProperties[k] {
+			value += "em"
+		}
+		if value == "" || strings.ContainsAny(value, `;"<>{}\`) || strings.Contains(strings.ToLower(value), "url(") {
+			continue
+		}
+		parts = append(parts, kebabCase(k)+":"+value)
+	}
+	return strings.Join(parts, ";")
+}
+
+// renderImage renders an image node. Paths refer to files in the archive.
+func renderImage(sb *strings.Builder, node map[string]any) {
+	path := stringValue(node["path"])
+	if path == "" {
+		return
+	}
+	sb.WriteString(`<img src="` + html.EscapeString(path) + `"`)
+
+	units := "px"
+	if stringValue(node["sizeUnits"]) == "em" {
+		units = "em"
+	}
+	var style []string
+	for _, name := range []string{"width", "height"} {
+		if size, ok := node[name].(float64); ok {
+			style = append(style, name+":"+strconv.FormatFloat(size, 'f', -1, 64)+units)
+		}
+	}
+	if len(style) > 0 {
+		sb.WriteString(` style="` + strings.Join(style, ";") + `"`)
+	}
+	for _, name := range []string{"title", "alt"} {
+		if value := stringValue(node[name]); value != "" {
+			sb.WriteString(" " + name + `="` + html.EscapeString(value) + `"`)
+		}
+	}
+	sb.WriteString(">")
+}
+
+// stringValue formats a JSON scalar as a string.
+func stringValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isSafeName reports whether s only contains letters, digits and dashes.
+func isSafeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// kebabCase converts camelCase property names to CSS names.
+func kebabCase(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteByte('-')
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
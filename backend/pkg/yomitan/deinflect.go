@@ -0,0 +1,223 @@
+package yomitan
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// maxDeinflections limits the number of candidates produced for one word.
+const maxDeinflections = 256
+
+// Rule is one deinflection rule in the format of Yomichan's deinflect.json:
+// a word ending in KanaIn is turned into a word ending in KanaOut. RulesIn
+// are the word classes the inflected form may have, RulesOut the classes of
+// the result. A rule with empty RulesIn only applies to the original word.
+type Rule struct {
+	KanaIn   string   `json:"kanaIn"`
+	KanaOut  string   `json:"kanaOut"`
+	RulesIn  []string `json:"rulesIn"`
+	RulesOut []string `json:"rulesOut"`
+}
+
+// Rules maps a reason (e.g. "past") to its rules.
+type Rules map[string][]Rule
+
+// ParseRules parses rules in the deinflect.json format.
+func ParseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Deinflection is a candidate dictionary form of a word.
+type Deinflection struct {
+	Term    string
+	Rules   []string // word classes the term must have, nil for any
+	Reasons []string // applied reasons, outermost first
+}
+
+// Deinflect returns the word itself and all forms it may have been
+// inflected from.
+func (rules Rules) Deinflect(word string) []Deinflection {
+	results := []Deinflection{{Term: word}}
+	seen := map[string]bool{word + "|": true}
+
+	// Apply reasons in a fixed order so that results are deterministic
+	reasons := make([]string, 0, len(rules))
+	for reason := range rules {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	for i := 0; i < len(results) && len(results) < maxDeinflections; i++ {
+		current := results[i]
+		for _, reason := range reasons {
+			for _, rule := range rules[reason] {
+				if current.Rules != nil && !intersects(current.Rules, rule.RulesIn) {
+					continue
+				}
+				if rule.KanaIn == "" || !strings.HasSuffix(current.Term, rule.KanaIn) {
+					continue
+				}
+				term := strings.TrimSuffix(current.Term, rule.KanaIn) + rule.KanaOut
+				if term == "" {
+					continue
+				}
+
+				key := term + "|" + strings.Join(rule.RulesOut, " ")
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				results = append(results, Deinflection{
+					Term:    term,
+					Rules:   rule.RulesOut,
+					Reasons: append([]string{reason}, current.Reasons...),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// intersects reports whether a and b share an element.
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesRules reports whether a term with the space separated word
+// classes in termRules satisfies a deinflection.
+func matchesRules(termRules string, d Deinflection) bool {
+	if d.Rules == nil {
+		return true
+	}
+	return intersects(strings.Fields(termRules), d.Rules)
+}
+
+// godanForms lists the stems of each godan verb ending:
+// dictionary ending, a, i, e and o stems, te and ta forms.
+var godanForms = [][7]string{
+	{"う", "わ", "い", "え", "お", "って", "った"},
+	{"く", "か", "き", "け", "こ", "いて", "いた"},
+	{"ぐ", "が", "ぎ", "げ", "ご", "いで", "いだ"},
+	{"す", "さ", "し", "せ", "そ", "して", "した"},
+	{"つ", "た", "ち", "て", "と", "って", "った"},
+	{"ぬ", "な", "に", "ね", "の", "んで", "んだ"},
+	{"ぶ", "ば", "び", "べ", "ぼ", "んで", "んだ"},
+	{"む", "ま", "み", "め", "も", "んで", "んだ"},
+	{"る", "ら", "り", "れ", "ろ", "って", "った"},
+}
+
+// DefaultRules returns the built-in Japanese deinflection rules, used when
+// an archive does not ship its own deinflect.json.
+func DefaultRules() Rules {
+	rules := make(Rules)
+	add := func(reason, in, out string, rulesIn []string, rulesOut ...string) {
+		rules[reason] = append(rules[reason], Rule{KanaIn: in, KanaOut: out, RulesIn: rulesIn, RulesOut: rulesOut})
+	}
+	v1 := []string{"v1"}
+	adj := []string{"adj-i"}
+	iru := []string{"iru"}
+
+	// Godan verbs
+	for _, f := range godanForms {
+		u, a, i, e, o, te, ta := f[0], f[1], f[2], f[3], f[4], f[5], f[6]
+		add("negative", a+"ない", u, adj, "v5")
+		add("-zu", a+"ず", u, nil, "v5")
+		add("passive", a+"れる", u, v1, "v5")
+		add("causative", a+"せる", u, v1, "v5")
+		add("masu stem", i, u, nil, "v5")
+		add("polite", i+"ます", u, nil, "v5")
+		add("polite past", i+"ました", u, nil, "v5")
+		add("polite negative", i+"ません", u, nil, "v5")
+		add("polite past negative", i+"ませんでした", u, nil, "v5")
+		add("polite volitional", i+"ましょう", u, nil, "v5")
+		add("-tai", i+"たい", u, adj, "v5")
+		add("-nasai", i+"なさい", u, nil, "v5")
+		add("potential", e+"る", u, v1, "v5")
+		add("imperative", e, u, nil, "v5")
+		add("-ba", e+"ば", u, nil, "v5")
+		add("volitional", o+"う", u, nil, "v5")
+		add("-te", te, u, iru, "v5")
+		add("past", ta, u, nil, "v5")
+		add("-tara", ta+"ら", u, nil, "v5")
+		add("-tari", ta+"り", u, nil, "v5")
+	}
+	// 行く is irregular in the te and ta forms
+	add("-te", "行って", "行く", iru, "v5")
+	add("past", "行った", "行く", nil, "v5")
+	add("-te", "いって", "いく", iru, "v5")
+	add("past", "いった", "いく", nil, "v5")
+
+	// Ichidan verbs
+	for _, r := range []struct{ reason, in string }{
+		{"polite", "ます"}, {"polite past", "ました"}, {"polite negative", "ません"},
+		{"polite past negative", "ませんでした"}, {"polite volitional", "ましょう"},
+		{"-zu", "ず"}, {"-nasai", "なさい"}, {"volitional", "よう"}, {"imperative", "ろ"},
+		{"-ba", "れば"}, {"past", "た"}, {"-tara", "たら"}, {"-tari", "たり"},
+	} {
+		add(r.reason, r.in, "る", nil, "v1")
+	}
+	add("negative", "ない", "る", adj, "v1")
+	add("-tai", "たい", "る", adj, "v1")
+	add("-te", "て", "る", iru, "v1")
+	add("potential or passive", "られる", "る", v1, "v1")
+	add("causative", "させる", "る", v1, "v1")
+
+	// Irregular verbs する and 来る
+	for _, irregular := range []struct {
+		dict, class                   string
+		neg, stem, te, ta, pass, caus string
+		vol, imp, ba                  string
+	}{
+		{"する", "vs", "しない", "し", "して", "した", "される", "させる", "しよう", "しろ", "すれば"},
+		{"くる", "vk", "こない", "き", "きて", "きた", "こられる", "こさせる", "こよう", "こい", "くれば"},
+		{"来る", "vk", "来ない", "来", "来て", "来た", "来られる", "来させる", "来よう", "来い", "来れば"},
+	} {
+		d, c := irregular.dict, irregular.class
+		add("negative", irregular.neg, d, adj, c)
+		add("masu stem", irregular.stem, d, nil, c)
+		add("polite", irregular.stem+"ます", d, nil, c)
+		add("polite past", irregular.stem+"ました", d, nil, c)
+		add("polite negative", irregular.stem+"ません", d, nil, c)
+		add("-tai", irregular.stem+"たい", d, adj, c)
+		add("-te", irregular.te, d, iru, c)
+		add("past", irregular.ta, d, nil, c)
+		add("-tara", irregular.ta+"ら", d, nil, c)
+		add("passive", irregular.pass, d, v1, c)
+		add("causative", irregular.caus, d, v1, c)
+		add("volitional", irregular.vol, d, nil, c)
+		add("imperative", irregular.imp, d, nil, c)
+		add("-ba", irregular.ba, d, nil, c)
+	}
+
+	// Progressive and perfect forms with いる / ている
+	add("progressive or perfect", "ている", "て", v1, "iru")
+	add("progressive or perfect", "でいる", "で", v1, "iru")
+	add("progressive or perfect", "てる", "て", v1, "iru")
+	add("progressive or perfect", "でる", "で", v1, "iru")
+
+	// I-adjectives
+	add("negative", "くない", "い", adj, "adj-i")
+	add("past", "かった", "い", nil, "adj-i")
+	add("-te", "くて", "い", nil, "adj-i")
+	add("adv", "く", "い", nil, "adj-i")
+	add("-ba", "ければ", "い", nil, "adj-i")
+	add("-sou", "そう", "い", nil, "adj-i")
+	add("noun", "さ", "い", nil, "adj-i")
+	add("-sugiru", "すぎる", "い", v1, "adj-i")
+	add("-tara", "かったら", "い", nil, "adj-i")
+
+	return rules
+}
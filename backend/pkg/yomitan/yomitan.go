@@ -0,0 +1,713 @@
+// Package yomitan reads Yomitan (formerly Yomichan) dictionary archives.
+//
+// An archive is a zip file with an index.json describing the dictionary and
+// numbered JSON banks: term_bank_*.json (terms and glossaries),
+// kanji_bank_*.json, term_meta_bank_*.json (frequency, pitch accent and IPA
+// data) and tag_bank_*.json. Images referenced by structured content are
+// stored in the archive as well. The banks are loaded into memory on open.
+package yomitan
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a word is not in the dictionary.
+	ErrNotFound = errors.New("yomitan: word not found")
+	// ErrNoIndex is returned for zip files without index.json.
+	ErrNoIndex = errors.New("yomitan: index.json not found")
+)
+
+// rulesFile is the optional deinflection rules file in an archive.
+const rulesFile = "deinflect.json"
+
+// Index is the content of index.json.
+type Index struct {
+	Title          string `json:"title"`
+	Revision       string `json:"revision"`
+	Format         int    `json:"format"`
+	Version        int    `json:"version"`
+	Author         string `json:"author"`
+	URL            string `json:"url"`
+	Description    string `json:"description"`
+	Attribution    string `json:"attribution"`
+	SourceLanguage string `json:"sourceLanguage"`
+	TargetLanguage string `json:"targetLanguage"`
+	FrequencyMode  string `json:"frequencyMode"`
+}
+
+// version returns the bank format version.
+func (i *Index) version() int {
+	if i.Format != 0 {
+		return i.Format
+	}
+	return i.Version
+}
+
+type term struct {
+	expression     string
+	reading        string
+	definitionTags string
+	rules          string
+	score          int
+	glossary       []json.RawMessage
+	termTags       string
+}
+
+type kanji struct {
+	character string
+	onyomi    string
+	kunyomi   string
+	tags      string
+	meanings  []string
+	stats     map[string]string
+}
+
+type tag struct {
+	category string
+	notes    string
+}
+
+type frequency struct {
+	reading string
+	value   int64
+	display string
+}
+
+type pitch struct {
+	reading   string
+	positions []string
+}
+
+type phonetic struct {
+	reading string
+	ipa     []string
+}
+
+// key is a sorted lookup key pointing to a term or kanji.
+type key struct {
+	word  string
+	lower string
+	kanji bool
+	index int
+}
+
+// Dict is an opened Yomitan dictionary. It is safe for concurrent use.
+type Dict struct {
+	Index
+
+	path string
+	zip  *zip.ReadCloser
+
+	terms       []term
+	kanji       []kanji
+	tags        map[string]tag
+	frequencies map[string][]frequency
+	pitches     map[string][]pitch
+	phonetics   map[string][]phonetic
+	keys        []key
+
+	rules Rules
+}
+
+// IsArchive reports whether path is a zip file with an index.json.
+func IsArchive(path string) bool {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name == "index.json" {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reads a Yomitan archive.
+func Open(path string) (*Dict, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dict{
+		path:        path,
+		zip:         zr,
+		tags:        make(map[string]tag),
+		frequencies: make(map[string][]frequency),
+		pitches:     make(map[string][]pitch),
+		phonetics:   make(map[string][]phonetic),
+		rules:       DefaultRules(),
+	}
+	if err := d.load(); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("yomitan: %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// load parses index.json and all banks.
+func (d *Dict) load() error {
+	files := make(map[string]*zip.File)
+	var names []string
+	for _, f := range d.zip.File {
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	// Banks are numbered, keep their order stable
+	sort.Strings(names)
+
+	indexFile, ok := files["index.json"]
+	if !ok {
+		return ErrNoIndex
+	}
+	if err := readJSON(indexFile, &d.Index); err != nil {
+		return fmt.Errorf("index.json: %w", err)
+	}
+
+	if f, ok := files[rulesFile]; ok {
+		data, err := readFile(f)
+		if err == nil {
+			if rules, err := ParseRules(data); err == nil {
+				d.rules = rules
+			}
+		}
+	}
+
+	for _, name := range names {
+		var load func([][]json.RawMessage)
+		switch {
+		case strings.HasPrefix(name, "term_bank_"):
+			load = d.loadTerms
+		case strings.HasPrefix(name, "kanji_bank_"):
+			load = d.loadKanji
+		case strings.HasPrefix(name, "term_meta_bank_"), strings.HasPrefix(name, "kanji_meta_bank_"):
+			load = d.loadMeta
+		case strings.HasPrefix(name, "tag_bank_"):
+			load = d.loadTags
+		default:
+			continue
+		}
+
+		var rows [][]json.RawMessage
+		if err := readJSON(files[name], &rows); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		load(rows)
+	}
+
+	for i, t := range d.terms {
+		d.keys = append(d.keys, key{word: t.expression, lower: strings.ToLower(t.expression), index: i})
+		if t.reading != "" && t.reading != t.expression {
+			d.keys = append(d.keys, key{word: t.reading, lower: strings.ToLower(t.reading), index: i})
+		}
+	}
+	for i, k := range d.kanji {
+		d.keys = append(d.keys, key{word: k.character, lower: k.character, kanji: true, index: i})
+	}
+	sort.SliceStable(d.keys, func(i, j int) bool {
+		return d.keys[i].lower < d.keys[j].lower
+	})
+	return nil
+}
+
+// readFile reads a file from the archive.
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// readJSON decodes a JSON file from the archive.
+func readJSON(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// str decodes a JSON string, returning "" for null or other types.
+func str(raw json.RawMessage) string {
+	var s string
+	json.Unmarshal(raw, &s)
+	return s
+}
+
+// num decodes a JSON number, returning 0 for other types.
+func num(raw json.RawMessage) float64 {
+	var n float64
+	json.Unmarshal(raw, &n)
+	return n
+}
+
+// loadTerms parses term bank rows. Version 3 rows are
+// [expression, reading, definitionTags, rules, score, glossary[], sequence,
+// termTags], version 1 rows list the glossary strings from index 5 on.
+func (d *Dict) loadTerms(rows [][]json.RawMessage) {
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		t := term{
+			expression:     str(row[0]),
+			reading:        str(row[1]),
+			definitionTags: str(row[2]),
+			rules:          str(row[3]),
+			score:          int(num(row[4])),
+		}
+		if d.version() >= 3 {
+			if len(row) > 5 {
+				json.Unmarshal(row[5], &t.glossary)
+			}
+			if len(row) > 7 {
+				t.termTags = str(row[7])
+			}
+		} else {
+			t.glossary = row[5:]
+		}
+		if t.expression != "" {
+			d.terms = append(d.terms, t)
+		}
+	}
+}
+
+// loadKanji parses kanji bank rows:
+// [character, onyomi, kunyomi, tags, meanings[], stats{}].
+func (d *Dict) loadKanji(rows [][]json.RawMessage) {
+	for _, row := range rows {
+		if len(row) < 4 {
+			continue
+		}
+		k := kanji{
+			character: str(row[0]),
+			onyomi:    str(row[1]),
+			kunyomi:   str(row[2]),
+			tags:      str(row[3]),
+		}
+		if d.version() >= 3 {
+			if len(row) > 4 {
+				json.Unmarshal(row[4], &k.meanings)
+			}
+			if len(row) > 5 {
+				json.Unmarshal(row[5], &k.stats)
+			}
+		} else {
+			for _, m := range row[4:] {
+				k.meanings = append(k.meanings, str(m))
+			}
+		}
+		if k.character != "" {
+			d.kanji = append(d.kanji, k)
+		}
+	}
+}
+
+// loadTags parses tag bank rows: [name, category, order, notes, score].
+func (d *Dict) loadTags(rows [][]json.RawMessage) {
+	for _, row := range rows {
+		if len(row) < 4 {
+			continue
+		}
+		d.tags[str(row[0])] = tag{category: str(row[1]), notes: str(row[3])}
+	}
+}
+
+// loadMeta parses meta bank rows: [term, mode, data] with mode "freq",
+// "pitch" or "ipa".
+func (d *Dict) loadMeta(rows [][]json.RawMessage) {
+	for _, row := range rows {
+		if len(row) < 3 {
+			continue
+		}
+		word := str(row[0])
+		switch str(row[1]) {
+		case "freq":
+			if f, ok := parseFrequency(row[2]); ok {
+				d.frequencies[word] = append(d.frequencies[word], f)
+			}
+		case "pitch":
+			var data struct {
+				Reading string `json:"reading"`
+				Pitches []struct {
+					Position json.RawMessage `json:"position"`
+				} `json:"pitches"`
+			}
+			if json.Unmarshal(row[2], &data) != nil {
+				continue
+			}
+			p := pitch{reading: data.Reading}
+			for _, pp := range data.Pitches {
+				p.positions = append(p.positions, strings.Trim(string(pp.Position), `"`))
+			}
+			d.pitches[word] = append(d.pitches[word], p)
+		case "ipa":
+			var data struct {
+				Reading        string `json:"reading"`
+				Transcriptions []struct {
+					IPA string `json:"ipa"`
+				} `json:"transcriptions"`
+			}
+			if json.Unmarshal(row[2], &data) != nil {
+				continue
+			}
+			p := phonetic{reading: data.Reading}
+			for _, t := range data.Transcriptions {
+				p.ipa = append(p.ipa, t.IPA)
+			}
+			d.phonetics[word] = append(d.phonetics[word], p)
+		}
+	}
+}
+
+// parseFrequency parses the data of a frequency row, which is a number, a
+// string, {value, displayValue} or {reading, frequency}.
+func parseFrequency(raw json.RawMessage) (frequency, bool) {
+	var f frequency
+
+	var n float64
+	if json.Unmarshal(raw, &n) == nil {
+		return frequency{value: int64(n)}, true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		v, err := strconv.ParseInt(strings.Fields(s + " ")[0], 10, 64)
+		return frequency{value: v, display: s}, err == nil
+	}
+
+	var obj struct {
+		Reading      string          `json:"reading"`
+		Frequency    json.RawMessage `json:"frequency"`
+		Value        *float64        `json:"value"`
+		DisplayValue string          `json:"displayValue"`
+	}
+	if json.Unmarshal(raw, &obj) != nil {
+		return f, false
+	}
+	if obj.Frequency != nil {
+		inner, ok := parseFrequency(obj.Frequency)
+		inner.reading = obj.Reading
+		return inner, ok
+	}
+	if obj.Value == nil {
+		return f, false
+	}
+	return frequency{value: int64(*obj.Value), display: obj.DisplayValue}, true
+}
+
+// Name returns the file name without extension.
+func (d *Dict) Name() string {
+	name := filepath.Base(d.path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Path returns the archive path.
+func (d *Dict) Path() string {
+	return d.path
+}
+
+// Len returns the number of terms and kanji, or the number of words with
+// metadata for frequency and pitch only dictionaries.
+func (d *Dict) Len() int {
+	if n := len(d.terms) + len(d.kanji); n > 0 {
+		return n
+	}
+	words := make(map[string]bool)
+	for w := range d.frequencies {
+		words[w] = true
+	}
+	for w := range d.pitches {
+		words[w] = true
+	}
+	for w := range d.phonetics {
+		words[w] = true
+	}
+	return len(words)
+}
+
+// Frequencies returns one frequency score per word where a higher score
+// means a more frequent word. Rank based values are inverted.
+func (d *Dict) Frequencies() map[string]int64 {
+	var maxValue int64
+	for _, fs := range d.frequencies {
+		for _, f := range fs {
+			if f.value > maxValue {
+				maxValue = f.value
+			}
+		}
+	}
+
+	scores := make(map[string]int64, len(d.frequencies))
+	for word, fs := range d.frequencies {
+		if len(fs) == 0 || fs[0].value <= 0 {
+			continue
+		}
+		if d.FrequencyMode == "occurrence-based" {
+			scores[word] = fs[0].value
+		} else {
+			scores[word] = maxValue - fs[0].value + 1
+		}
+	}
+	return scores
+}
+
+// match is a term found for a looked up word.
+type match struct {
+	index   int
+	reasons []string
+}
+
+// Lookup deinflects word, finds matching terms and kanji and renders them
+// as HTML.
+func (d *Dict) Lookup(word string) ([]byte, error) {
+	word = strings.TrimSpace(word)
+
+	var (
+		matches    []match
+		kanjiFound []int
+		seen       = make(map[int]bool)
+	)
+	for _, candidate := range d.rules.Deinflect(word) {
+		lower := strings.ToLower(candidate.Term)
+		start := sort.Search(len(d.keys), func(i int) bool {
+			return d.keys[i].lower >= lower
+		})
+		for i := start; i < len(d.keys) && d.keys[i].lower == lower; i++ {
+			k := d.keys[i]
+			if k.kanji {
+				if len(candidate.Reasons) == 0 {
+					kanjiFound = append(kanjiFound, k.index)
+				}
+				continue
+			}
+			if seen[k.index] || !matchesRules(d.terms[k.index].rules, candidate) {
+				continue
+			}
+			seen[k.index] = true
+			matches = append(matches, match{index: k.index, reasons: candidate.Reasons})
+		}
+	}
+
+	// Exact matches first, then by the dictionary's score
+	sort.SliceStable(matches, func(i, j int) bool {
+		if len(matches[i].reasons) != len(matches[j].reasons) {
+			return len(matches[i].reasons) < len(matches[j].reasons)
+		}
+		return d.terms[matches[i].index].score > d.terms[matches[j].index].score
+	})
+
+	var sb strings.Builder
+	for _, m := range matches {
+		d.renderTerm(&sb, d.terms[m.index], m.reasons)
+	}
+	for _, i := range kanjiFound {
+		d.renderKanji(&sb, d.kanji[i])
+	}
+	if sb.Len() == 0 {
+		// Frequency and pitch dictionaries have no terms
+		d.renderMeta(&sb, word, "")
+	}
+	if sb.Len() == 0 {
+		return nil, ErrNotFound
+	}
+	return []byte(sb.String()), nil
+}
+
+// renderTags renders space separated tag names.
+func (d *Dict) renderTags(sb *strings.Builder, names ...string) {
+	var all []string
+	for _, n := range names {
+		all = append(all, strings.Fields(n)...)
+	}
+	if len(all) == 0 {
+		return
+	}
+	sb.WriteString(`<div class="yomitan-tags">`)
+	for _, name := range all {
+		t := d.tags[name]
+		sb.WriteString(`<span class="tag tag-` + html.EscapeString(t.category) + `" title="` + html.EscapeString(t.notes) + `">` + html.EscapeString(name) + `</span> `)
+	}
+	sb.WriteString("</div>")
+}
+
+// renderTerm renders a term with its glossary and metadata.
+func (d *Dict) renderTerm(sb *strings.Builder, t term, reasons []string) {
+	sb.WriteString(`<div class="yomitan-term"><div class="yomitan-head">`)
+	if t.reading != "" && t.reading != t.expression {
+		sb.WriteString("<ruby>" + html.EscapeString(t.expression) + "<rt>" + html.EscapeString(t.reading) + "</rt></ruby>")
+	} else {
+		sb.WriteString(html.EscapeString(t.expression))
+	}
+	if len(reasons) > 0 {
+		sb.WriteString(` <span class="yomitan-reasons">« ` + html.EscapeString(strings.Join(reasons, " « ")) + `</span>`)
+	}
+	sb.WriteString("</div>")
+
+	d.renderTags(sb, t.termTags, t.definitionTags)
+
+	sb.WriteString(`<ol class="yomitan-glossary">`)
+	for _, g := range t.glossary {
+		sb.WriteString("<li>")
+		renderGlossary(sb, g)
+		sb.WriteString("</li>")
+	}
+	sb.WriteString("</ol>")
+
+	d.renderMeta(sb, t.expression, t.reading)
+	sb.WriteString("</div>")
+}
+
+// renderMeta renders pitch accent, IPA and frequency data of a word. When
+// reading is set only data for that reading is shown.
+func (d *Dict) renderMeta(sb *strings.Builder, word, reading string) {
+	matches := func(r string) bool {
+		return reading == "" || r == "" || r == reading
+	}
+
+	for _, p := range d.pitches[word] {
+		if !matches(p.reading) {
+			continue
+		}
+		sb.WriteString(`<div class="yomitan-pitch">` + html.EscapeString(p.reading))
+		for _, pos := range p.positions {
+			sb.WriteString(" [" + html.EscapeString(pos) + "]")
+		}
+		sb.WriteString("</div>")
+	}
+	for _, p := range d.phonetics[word] {
+		if !matches(p.reading) {
+			continue
+		}
+		sb.WriteString(`<div class="yomitan-ipa"><span class="phonetic">` + html.EscapeString(strings.Join(p.ipa, ", ")) + "</span></div>")
+	}
+	for _, f := range d.frequencies[word] {
+		if !matches(f.reading) {
+			continue
+		}
+		value := f.display
+		if value == "" {
+			value = strconv.FormatInt(f.value, 10)
+		}
+		sb.WriteString(`<div class="yomitan-freq">` + html.EscapeString(d.Title) + ": " + html.EscapeString(value) + "</div>")
+	}
+}
+
+// renderKanji renders a kanji entry.
+func (d *Dict) renderKanji(sb *strings.Builder, k kanji) {
+	sb.WriteString(`<div class="yomitan-kanji"><div class="yomitan-head" style="font-size:2em">` + html.EscapeString(k.character) + "</div>")
+	d.renderTags(sb, k.tags)
+	if len(k.meanings) > 0 {
+		sb.WriteString(`<ol class="yomitan-glossary">`)
+		for _, m := range k.meanings {
+			sb.WriteString("<li>" + html.EscapeString(m) + "</li>")
+		}
+		sb.WriteString("</ol>")
+	}
+	if k.onyomi != "" {
+		sb.WriteString(`<div class="yomitan-onyomi">` + html.EscapeString(k.onyomi) + "</div>")
+	}
+	if k.kunyomi != "" {
+		sb.WriteString(`<div class="yomitan-kunyomi">` + html.EscapeString(k.kunyomi) + "</div>")
+	}
+	if len(k.stats) > 0 {
+		sb.WriteString(`<table class="yomitan-stats">`)
+		names := make([]string, 0, len(k.stats))
+		for name := range k.stats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sb.WriteString("<tr><th>" + html.EscapeString(name) + "</th><td>" + html.EscapeString(k.stats[name]) + "</td></tr>")
+		}
+		sb.WriteString("</table>")
+	}
+	sb.WriteString("</div>")
+}
+
+// Suggest returns up to limit terms, readings or kanji starting with prefix.
+func (d *Dict) Suggest(prefix string, limit int) []string {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(d.keys), func(i int) bool {
+		return d.keys[i].lower >= prefix
+	})
+
+	results := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for i := idx; i < len(d.keys) && len(results) < limit; i++ {
+		k := d.keys[i]
+		if !strings.HasPrefix(k.lower, prefix) {
+			break
+		}
+		if !seen[k.word] {
+			seen[k.word] = true
+			results = append(results, k.word)
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every term and kanji with the rendered HTML.
+// Returning a non-nil error stops the iteration.
+func (d *Dict) Iterate(fn func(word string, definition []byte) error) error {
+	for _, t := range d.terms {
+		var sb strings.Builder
+		d.renderTerm(&sb, t, nil)
+		if err := fn(t.expression, []byte(sb.String())); err != nil {
+			return err
+		}
+	}
+	for _, k := range d.kanji {
+		var sb strings.Builder
+		d.renderKanji(&sb, k)
+		if err := fn(k.character, []byte(sb.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasResources reports whether the archive contains files besides the banks.
+func (d *Dict) HasResources() bool {
+	for _, f := range d.zip.File {
+		if !strings.HasSuffix(f.Name, ".json") && !strings.HasSuffix(f.Name, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource reads a file referenced by structured content from the archive.
+func (d *Dict) Resource(name string) ([]byte, error) {
+	name = path.Clean(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	for _, f := range d.zip.File {
+		if f.Name == name {
+			return readFile(f)
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// Close closes the archive.
+func (d *Dict) Close() error {
+	return d.zip.Close()
+}
@@ -0,0 +1,151 @@
+package yomitan
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeArchive writes a zip file with the given files.
+func writeArchive(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+func testArchive(t *testing.T) string {
+	return writeArchive(t, map[string]string{
+		"index.json": `{"title":"Test JP","revision":"1","format":3,"description":"A test"}`,
+		"term_bank_1.json": `[
+			["食べる","たべる","v1","v1",10,["to eat",{"type":"structured-content","content":{"tag":"a","href":"?query=飲む","content":"飲む"}}],1,"P"],
+			["飲む","のむ","v5","v5",5,["to drink"],2,""],
+			["猫","ねこ","n","",1,[{"type":"image","path":"img/cat.png"}],3,""]
+		]`,
+		"kanji_bank_1.json": `[["猫","ビョウ","ねこ","jouyou",["cat"],{"strokes":"11"}]]`,
+		"tag_bank_1.json":   `[["P","popular",0,"popular term",0]]`,
+		"img/cat.png":       "PNG",
+	})
+}
+
+func TestOpenAndLookup(t *testing.T) {
+	path := testArchive(t)
+	if !IsArchive(path) {
+		t.Fatal("IsArchive = false")
+	}
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	if d.Title != "Test JP" || d.Len() != 4 || d.Name() != "test" {
+		t.Errorf("Title = %q, Len() = %d, Name() = %q", d.Title, d.Len(), d.Name())
+	}
+
+	got, err := d.Lookup("食べた")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	for _, want := range []string{"<ruby>食べる<rt>たべる</rt></ruby>", "past", "to eat", `href="entry://飲む"`, `title="popular term"`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(食べた) = %q, missing %q", got, want)
+		}
+	}
+
+	// 飲む is a godan verb and must not match ichidan deinflections
+	if _, err := d.Lookup("飲た"); err != ErrNotFound {
+		t.Errorf("Lookup(飲た) error = %v", err)
+	}
+	if got, err := d.Lookup("飲んだ"); err != nil || !strings.Contains(string(got), "to drink") {
+		t.Errorf("Lookup(飲んだ) = %q, %v", got, err)
+	}
+
+	got, err = d.Lookup("ねこ")
+	if err != nil || !strings.Contains(string(got), "img/cat.png") {
+		t.Errorf("Lookup(ねこ) = %q, %v", got, err)
+	}
+	got, _ = d.Lookup("猫")
+	if !strings.Contains(string(got), "yomitan-kanji") || !strings.Contains(string(got), "ビョウ") {
+		t.Errorf("Lookup(猫) missing kanji entry: %q", got)
+	}
+
+	if res, err := d.Resource("/img/cat.png"); err != nil || string(res) != "PNG" {
+		t.Errorf("Resource = %q, %v", res, err)
+	}
+	if !d.HasResources() {
+		t.Error("HasResources = false")
+	}
+
+	if s := d.Suggest("た", 10); len(s) != 1 || s[0] != "たべる" {
+		t.Errorf("Suggest(た) = %v", s)
+	}
+
+	var count int
+	d.Iterate(func(word string, definition []byte) error {
+		count++
+		return nil
+	})
+	if count != 4 {
+		t.Errorf("Iterate visited %d entries", count)
+	}
+}
+
+func TestFrequencies(t *testing.T) {
+	path := writeArchive(t, map[string]string{
+		"index.json": `{"title":"Freq","format":3}`,
+		"term_meta_bank_1.json": `[
+			["の","freq",1],
+			["猫","freq",{"reading":"ねこ","frequency":{"value":50,"displayValue":"50㋕"}}],
+			["犬","freq","100"],
+			["猫","pitch",{"reading":"ねこ","pitches":[{"position":1}]}]
+		]`,
+	})
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close()
+
+	freq := d.Frequencies()
+	if freq["の"] != 100 || freq["猫"] != 51 || freq["犬"] != 1 {
+		t.Errorf("Frequencies() = %v", freq)
+	}
+
+	got, err := d.Lookup("猫")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if !strings.Contains(string(got), "50㋕") || !strings.Contains(string(got), "[1]") {
+		t.Errorf("Lookup(猫) = %q", got)
+	}
+}
+
+func TestNotArchive(t *testing.T) {
+	path := writeArchive(t, map[string]string{"readme.txt": "x"})
+	if IsArchive(path) {
+		t.Error("IsArchive = true for zip without index.json")
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open succeeded without index.json")
+	}
+}
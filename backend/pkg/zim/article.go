@@ -0,0 +1,224 @@
+package zim
+
+import (
+	"html"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	bodyRe       = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	stylesheetRe = regexp.MustCompile(`(?is)<link[^>]+rel=["']?stylesheet["']?[^>]*>`)
+	scriptRe     = regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
+	linkAttrRe   = regexp.MustCompile(`(src|href)=(["'])([^"']*)["']`)
+)
+
+// isHTML reports whether an entry is an HTML page.
+func isHTML(e *Entry) bool {
+	return strings.HasPrefix(e.MimeType, "text/html")
+}
+
+// titleVariants returns the spellings tried for a looked up word:
+// as typed, with the first letter capitalized (as on Wikipedia), lower and
+// title case.
+func titleVariants(word string) []string {
+	var variants []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			variants = append(variants, s)
+		}
+	}
+
+	add(word)
+	r, size := utf8.DecodeRuneInString(word)
+	add(string(unicode.ToUpper(r)) + word[size:])
+	add(strings.ToLower(word))
+	words := strings.Fields(strings.ToLower(word))
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	add(strings.Join(words, " "))
+	return variants
+}
+
+// Find looks up an article by title, trying common capitalizations and
+// path spellings with underscores.
+func (a *Archive) Find(word string) (*Entry, error) {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return nil, ErrNotFound
+	}
+	for _, title := range titleVariants(word) {
+		if e, err := a.EntryByTitle(a.articleNS, title); err == nil {
+			return e, nil
+		}
+		if e, err := a.EntryByPath(a.articleNS, strings.ReplaceAll(title, " ", "_")); err == nil {
+			return e, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Lookup finds an article and returns its body as an HTML fragment, with
+// links to other articles turned into entry:// links and resource links
+// made relative to the archive root ("I/image.png").
+func (a *Archive) Lookup(word string) ([]byte, error) {
+	e, err := a.Find(word)
+	if err != nil {
+		return nil, err
+	}
+	e, err = a.Resolve(e)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTML(e) {
+		return nil, ErrNotFound
+	}
+	data, err := a.Content(e)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(a.renderArticle(e, string(data))), nil
+}
+
+// renderArticle extracts the body of an article page and rewrites links.
+func (a *Archive) renderArticle(e *Entry, page string) string {
+	body := page
+	var head string
+	if m := bodyRe.FindStringSubmatchIndex(page); m != nil {
+		body = page[m[2]:m[3]]
+		head = page[:m[0]]
+	}
+	body = scriptRe.ReplaceAllString(body, "")
+
+	var sb strings.Builder
+	for _, link := range stylesheetRe.FindAllString(head, -1) {
+		sb.WriteString(link)
+	}
+	sb.WriteString(`<div class="zim-article">`)
+	sb.WriteString(body)
+	sb.WriteString("</div>")
+
+	return linkAttrRe.ReplaceAllStringFunc(sb.String(), func(match string) string {
+		parts := linkAttrRe.FindStringSubmatch(match)
+		attr, quote, target := parts[1], parts[2], html.UnescapeString(parts[3])
+		if rewritten, ok := a.rewriteLink(e, attr, target); ok {
+			return attr + "=" + quote + html.EscapeString(rewritten) + quote
+		}
+		return match
+	})
+}
+
+// rewriteLink resolves a relative link of an article. Links to other
+// articles become entry:// links, other content becomes a path relative to
+// the archive root.
+func (a *Archive) rewriteLink(from *Entry, attr, target string) (string, bool) {
+	if target == "" || strings.HasPrefix(target, "#") || strings.Contains(target, "://") ||
+		strings.HasPrefix(target, "//") || strings.HasPrefix(target, "data:") || strings.HasPrefix(target, "mailto:") {
+		return "", false
+	}
+	target, fragment, _ := strings.Cut(target, "#")
+	target, _, _ = strings.Cut(target, "?")
+
+	full := path.Clean(path.Join(path.Dir(from.FullPath()), target))
+	if strings.HasPrefix(target, "/") {
+		full = path.Clean(strings.TrimPrefix(target, "/"))
+	}
+
+	ns, p, ok := splitPath(full)
+	if !ok {
+		return "", false
+	}
+	if attr == "href" && ns == a.articleNS {
+		if e, err := a.EntryByPath(ns, p); err == nil {
+			if resolved, err := a.Resolve(e); err == nil && isHTML(resolved) {
+				return "entry://" + e.Title, true
+			}
+		}
+		if fragment != "" && p == from.Path {
+			return "#" + fragment, true
+		}
+	}
+	return full, true
+}
+
+// splitPath splits "A/Apple" into its namespace and path.
+func splitPath(full string) (byte, string, bool) {
+	if len(full) < 3 || full[1] != '/' {
+		return 0, "", false
+	}
+	return full[0], full[2:], true
+}
+
+// Resource returns the content of a path relative to the archive root, such
+// as "I/image.png" or "C/_assets_/style.css". Paths without a namespace are
+// looked up in the content namespaces.
+func (a *Archive) Resource(name string) ([]byte, error) {
+	name = path.Clean(strings.TrimLeft(strings.ReplaceAll(name, "\\", "/"), "/"))
+	if ns, p, ok := splitPath(name); ok {
+		if e, err := a.EntryByPath(ns, p); err == nil {
+			return a.Content(e)
+		}
+	}
+	for _, ns := range []byte{a.articleNS, 'I', '-'} {
+		if e, err := a.EntryByPath(ns, name); err == nil {
+			return a.Content(e)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Suggest returns up to limit article titles starting with prefix.
+func (a *Archive) Suggest(prefix string, limit int) []string {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	var results []string
+	seen := make(map[string]bool)
+	for _, variant := range titleVariants(prefix) {
+		for _, e := range a.TitlesWithPrefix(a.articleNS, variant, limit) {
+			if len(results) >= limit {
+				return results
+			}
+			if !seen[e.Title] && (e.Redirect || isHTML(e)) {
+				seen[e.Title] = true
+				results = append(results, e.Title)
+			}
+		}
+	}
+	return results
+}
+
+// Iterate calls fn for every HTML article in title order. Redirects are
+// skipped. Returning a non-nil error stops the iteration.
+func (a *Archive) Iterate(fn func(title string, article []byte) error) error {
+	start := search(a.EntryCount, a.articleNS, "", a.EntryByTitleAt, entryTitle)
+	for n := start; n < a.EntryCount; n++ {
+		e, err := a.EntryByTitleAt(n)
+		if err != nil {
+			return err
+		}
+		if e.Namespace != a.articleNS {
+			break
+		}
+		if e.Redirect || !isHTML(e) {
+			continue
+		}
+		data, err := a.Content(e)
+		if err != nil {
+			return err
+		}
+		if err := fn(e.Title, []byte(a.renderArticle(e, string(data)))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package zim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Cluster compression types (low four bits of the info byte).
+const (
+	compressionDefault = 0
+	compressionNone    = 1
+	compressionXZ      = 4
+	compressionZstd    = 5
+)
+
+// clusterExtended marks clusters with 64 bit blob offsets.
+const clusterExtended = 0x10
+
+// clusterCacheSize is the number of decompressed clusters kept in memory.
+const clusterCacheSize = 8
+
+// cluster is a decompressed cluster.
+type cluster struct {
+	offsets []uint64
+	data    []byte
+}
+
+// blob returns the n-th blob of the cluster.
+func (c *cluster) blob(n uint32) ([]byte, error) {
+	if int(n)+1 >= len(c.offsets) {
+		return nil, ErrNotFound
+	}
+	start, end := c.offsets[n], c.offsets[n+1]
+	if start > end || end > uint64(len(c.data)) {
+		return nil, fmt.Errorf("zim: invalid blob %d", n)
+	}
+	return c.data[start:end], nil
+}
+
+// cluster returns a decompressed cluster, using the cache when possible.
+func (a *Archive) cluster(n uint32) (*cluster, error) {
+	if n >= a.ClusterCount {
+		return nil, fmt.Errorf("zim: cluster %d out of range", n)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if c, ok := a.clusters[n]; ok {
+		return c, nil
+	}
+
+	start, err := a.readUint64(int64(a.ClusterPtrPos) + int64(n)*8)
+	if err != nil {
+		return nil, err
+	}
+	if start >= uint64(a.size) {
+		return nil, fmt.Errorf("zim: cluster %d beyond end of file", n)
+	}
+	// The last cluster ends at the checksum
+	end := a.ChecksumPos
+	if end == 0 || end > uint64(a.size) {
+		end = uint64(a.size)
+	}
+	if n+1 < a.ClusterCount {
+		if end, err = a.readUint64(int64(a.ClusterPtrPos) + int64(n+1)*8); err != nil {
+			return nil, err
+		}
+	}
+	if start >= end || end > uint64(a.size) {
+		return nil, fmt.Errorf("zim: invalid cluster %d", n)
+	}
+
+	raw := make([]byte, end-start)
+	if _, err := a.file.ReadAt(raw, int64(start)); err != nil {
+		return nil, fmt.Errorf("zim: failed to read cluster %d: %w", n, err)
+	}
+	c, err := decodeCluster(raw)
+	if err != nil {
+		return nil, fmt.Errorf("zim: cluster %d: %w", n, err)
+	}
+
+	if len(a.order) >= clusterCacheSize {
+		delete(a.clusters, a.order[0])
+		a.order = a.order[1:]
+	}
+	a.clusters[n] = c
+	a.order = append(a.order, n)
+
+	return c, nil
+}
+
+// decodeCluster decompresses a cluster and parses its blob offsets.
+func decodeCluster(raw []byte) (*cluster, error) {
+	info := raw[0]
+	var data []byte
+	switch info & 0x0f {
+	case compressionDefault, compressionNone:
+		data = raw[1:]
+	case compressionXZ:
+		r, err := xz.NewReader(bytes.NewReader(raw[1:]))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	case compressionZstd:
+		r, err := zstd.NewReader(bytes.NewReader(raw[1:]))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedCompression
+	}
+
+	size := 4
+	if info&clusterExtended != 0 {
+		size = 8
+	}
+	readOffset := func(i int) uint64 {
+		if size == 8 {
+			return binary.LittleEndian.Uint64(data[i*8:])
+		}
+		return uint64(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+
+	if len(data) < size {
+		return nil, fmt.Errorf("truncated cluster")
+	}
+	// The first offset points past the offset table
+	first := readOffset(0)
+	if first > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid blob offset table")
+	}
+	count := int(first) / size
+	if count == 0 {
+		return nil, fmt.Errorf("invalid blob offset table")
+	}
+	offsets := make([]uint64, count)
+	for i := range offsets {
+		offsets[i] = readOffset(i)
+	}
+	return &cluster{offsets: offsets, data: data}, nil
+}
@@ -0,0 +1,440 @@
+// Package zim reads ZIM archives as produced by openZIM for Kiwix.
+//
+// A ZIM file holds a directory of entries sorted by path and by title, and
+// clusters of blobs compressed with xz or zstd. Entries are either content
+// (pointing to a blob) or redirects to other entries. Both the old
+// namespace layout ("A" articles, "I" images, "-" assets) and the new one
+// ("C" for all content) are supported. Only single-file archives are read;
+// split .zimaa/.zimab files must be joined first.
+package zim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// magicNumber is the first field of a ZIM header.
+const magicNumber = 72173914
+
+// headerSize is the size of the fixed ZIM header.
+const headerSize = 80
+
+// Special MIME type indexes of directory entries.
+const (
+	mimeRedirect   = 0xffff
+	mimeLinkTarget = 0xfffe
+	mimeDeleted    = 0xfffd
+)
+
+// maxRedirects bounds redirect chains.
+const maxRedirects = 8
+
+var (
+	// ErrNotZIM is returned for files without the ZIM magic number.
+	ErrNotZIM = errors.New("zim: not a ZIM file")
+	// ErrNotFound is returned when an entry does not exist.
+	ErrNotFound = errors.New("zim: entry not found")
+	// ErrUnsupportedCompression is returned for clusters with an unknown
+	// compression type.
+	ErrUnsupportedCompression = errors.New("zim: unsupported cluster compression")
+)
+
+// Header is the fixed header of a ZIM file.
+type Header struct {
+	MajorVersion  uint16
+	MinorVersion  uint16
+	EntryCount    uint32
+	ClusterCount  uint32
+	PathPtrPos    uint64
+	TitlePtrPos   uint64
+	ClusterPtrPos uint64
+	MimeListPos   uint64
+	MainPage      uint32
+	ChecksumPos   uint64
+}
+
+// Entry is a directory entry.
+type Entry struct {
+	Index     uint32
+	MimeType  string // empty for redirects
+	Namespace byte
+	Path      string // path within the namespace
+	Title     string // defaults to Path
+	Redirect  bool
+
+	redirectIndex uint32
+	cluster       uint32
+	blob          uint32
+}
+
+// FullPath returns the entry path including its namespace, e.g. "A/Apple".
+func (e *Entry) FullPath() string {
+	return string(e.Namespace) + "/" + e.Path
+}
+
+// Archive is an opened ZIM file. It is safe for concurrent use.
+type Archive struct {
+	Header
+
+	path      string
+	file      *os.File
+	size      int64
+	mimeTypes []string
+
+	// articleNS is "C" for the new namespace layout and "A" for the old one.
+	articleNS byte
+
+	mu       sync.Mutex
+	clusters map[uint32]*cluster
+	order    []uint32 // cluster numbers in cache, oldest first
+
+	metaOnce sync.Once
+	metadata map[string]string
+}
+
+// Open opens a ZIM file and reads its header and MIME type list.
+func Open(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		path:     path,
+		file:     file,
+		clusters: make(map[uint32]*cluster),
+	}
+	if err := a.readHeader(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("zim: %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// readHeader parses the fixed header and the MIME type list.
+func (a *Archive) readHeader() error {
+	info, err := a.file.Stat()
+	if err != nil {
+		return err
+	}
+	a.size = info.Size()
+
+	buf := make([]byte, headerSize)
+	if _, err := a.file.ReadAt(buf, 0); err != nil {
+		return ErrNotZIM
+	}
+	le := binary.LittleEndian
+	if le.Uint32(buf[0:4]) != magicNumber {
+		return ErrNotZIM
+	}
+	a.Header = Header{
+		MajorVersion:  le.Uint16(buf[4:6]),
+		MinorVersion:  le.Uint16(buf[6:8]),
+		EntryCount:    le.Uint32(buf[24:28]),
+		ClusterCount:  le.Uint32(buf[28:32]),
+		PathPtrPos:    le.Uint64(buf[32:40]),
+		TitlePtrPos:   le.Uint64(buf[40:48]),
+		ClusterPtrPos: le.Uint64(buf[48:56]),
+		MimeListPos:   le.Uint64(buf[56:64]),
+		MainPage:      le.Uint32(buf[64:68]),
+		ChecksumPos:   le.Uint64(buf[72:80]),
+	}
+	for _, pos := range []uint64{a.PathPtrPos, a.TitlePtrPos, a.ClusterPtrPos, a.MimeListPos} {
+		if pos >= uint64(a.size) {
+			return fmt.Errorf("pointer %d beyond end of file", pos)
+		}
+	}
+
+	// MIME types are zero terminated strings ending with an empty string
+	end := a.PathPtrPos
+	if end <= a.MimeListPos || end-a.MimeListPos > 1<<20 {
+		end = a.MimeListPos + 1<<16
+	}
+	list := make([]byte, end-a.MimeListPos)
+	n, _ := a.file.ReadAt(list, int64(a.MimeListPos))
+	for _, s := range bytes.Split(list[:n], []byte{0}) {
+		if len(s) == 0 {
+			break
+		}
+		a.mimeTypes = append(a.mimeTypes, string(s))
+	}
+
+	a.articleNS = 'A'
+	if a.MajorVersion >= 6 && a.MinorVersion >= 1 {
+		a.articleNS = 'C'
+	}
+	return nil
+}
+
+// Name returns the file name without extension.
+func (a *Archive) Name() string {
+	name := filepath.Base(a.path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Path returns the archive path.
+func (a *Archive) Path() string {
+	return a.path
+}
+
+// ArticleNamespace returns the namespace holding articles.
+func (a *Archive) ArticleNamespace() byte {
+	return a.articleNS
+}
+
+// readUint64 reads a little endian uint64 at off.
+func (a *Archive) readUint64(off int64) (uint64, error) {
+	var buf [8]byte
+	if _, err := a.file.ReadAt(buf[:], off); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// readUint32 reads a little endian uint32 at off.
+func (a *Archive) readUint32(off int64) (uint32, error) {
+	var buf [4]byte
+	if _, err := a.file.ReadAt(buf[:], off); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(buf[:]), nil
+}
+
+// EntryAt reads the directory entry with the given path index.
+func (a *Archive) EntryAt(index uint32) (*Entry, error) {
+	if index >= a.EntryCount {
+		return nil, ErrNotFound
+	}
+	off, err := a.readUint64(int64(a.PathPtrPos) + int64(index)*8)
+	if err != nil {
+		return nil, err
+	}
+	if off >= uint64(a.size) {
+		return nil, fmt.Errorf("zim: directory entry %d beyond end of file", index)
+	}
+
+	// Fixed part (16 bytes) plus path, title and parameters. Paths and titles
+	// are short, read a generous block and extend it if needed.
+	size := int64(512)
+	for {
+		if int64(off)+size > a.size {
+			size = a.size - int64(off)
+		}
+		buf := make([]byte, size)
+		if _, err := a.file.ReadAt(buf, int64(off)); err != nil {
+			return nil, err
+		}
+		e, ok := a.parseEntry(buf)
+		if ok {
+			e.Index = index
+			return e, nil
+		}
+		if int64(off)+size >= a.size {
+			return nil, fmt.Errorf("zim: truncated directory entry %d", index)
+		}
+		size *= 4
+	}
+}
+
+// parseEntry decodes a directory entry. It reports false when buf does not
+// hold the complete entry.
+func (a *Archive) parseEntry(buf []byte) (*Entry, bool) {
+	if len(buf) < 12 {
+		return nil, false
+	}
+	le := binary.LittleEndian
+	mime := le.Uint16(buf[0:2])
+	e := &Entry{Namespace: buf[3]}
+
+	var rest []byte
+	switch mime {
+	case mimeRedirect:
+		e.Redirect = true
+		e.redirectIndex = le.Uint32(buf[8:12])
+		rest = buf[12:]
+	case mimeLinkTarget, mimeDeleted:
+		rest = buf[8:]
+	default:
+		if len(buf) < 16 {
+			return nil, false
+		}
+		if int(mime) < len(a.mimeTypes) {
+			e.MimeType = a.mimeTypes[mime]
+		}
+		e.cluster = le.Uint32(buf[8:12])
+		e.blob = le.Uint32(buf[12:16])
+		rest = buf[16:]
+	}
+
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return nil, false
+	}
+	e.Path = string(rest[:end])
+	rest = rest[end+1:]
+	end = bytes.IndexByte(rest, 0)
+	if end < 0 {
+		return nil, false
+	}
+	e.Title = string(rest[:end])
+	if e.Title == "" {
+		e.Title = e.Path
+	}
+	return e, true
+}
+
+// titleIndexAt returns the path index of the n-th entry in title order.
+func (a *Archive) titleIndexAt(n uint32) (uint32, error) {
+	return a.readUint32(int64(a.TitlePtrPos) + int64(n)*4)
+}
+
+// EntryByTitleAt returns the n-th entry in title order.
+func (a *Archive) EntryByTitleAt(n uint32) (*Entry, error) {
+	index, err := a.titleIndexAt(n)
+	if err != nil {
+		return nil, err
+	}
+	return a.EntryAt(index)
+}
+
+// compareKey compares a namespace and string with the given entry key.
+func compareKey(ns byte, s string, entryNS byte, entryKey string) int {
+	if ns != entryNS {
+		if ns < entryNS {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(s, entryKey)
+}
+
+// search finds the first index in [0, count) whose key is not less than
+// (ns, s). Read errors stop the search at the failing position.
+func search(count uint32, ns byte, s string, entry func(i uint32) (*Entry, error), key func(*Entry) string) uint32 {
+	return uint32(sort.Search(int(count), func(i int) bool {
+		e, err := entry(uint32(i))
+		if err != nil {
+			return true
+		}
+		return compareKey(ns, s, e.Namespace, key(e)) <= 0
+	}))
+}
+
+func entryPath(e *Entry) string  { return e.Path }
+func entryTitle(e *Entry) string { return e.Title }
+
+// EntryByPath finds an entry by namespace and path.
+func (a *Archive) EntryByPath(ns byte, path string) (*Entry, error) {
+	i := search(a.EntryCount, ns, path, a.EntryAt, entryPath)
+	e, err := a.EntryAt(i)
+	if err != nil || e.Namespace != ns || e.Path != path {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// EntryByTitle finds an entry by namespace and exact title.
+func (a *Archive) EntryByTitle(ns byte, title string) (*Entry, error) {
+	i := search(a.EntryCount, ns, title, a.EntryByTitleAt, entryTitle)
+	e, err := a.EntryByTitleAt(i)
+	if err != nil || e.Namespace != ns || e.Title != title {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// TitlesWithPrefix returns up to limit entries of a namespace whose titles
+// start with prefix, in title order.
+func (a *Archive) TitlesWithPrefix(ns byte, prefix string, limit int) []*Entry {
+	var entries []*Entry
+	for n := search(a.EntryCount, ns, prefix, a.EntryByTitleAt, entryTitle); n < a.EntryCount && len(entries) < limit; n++ {
+		e, err := a.EntryByTitleAt(n)
+		if err != nil || e.Namespace != ns || !strings.HasPrefix(e.Title, prefix) {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// NamespaceRange returns the path indexes [start, end) of a namespace.
+func (a *Archive) NamespaceRange(ns byte) (start, end uint32) {
+	start = search(a.EntryCount, ns, "", a.EntryAt, entryPath)
+	end = search(a.EntryCount, ns+1, "", a.EntryAt, entryPath)
+	return start, end
+}
+
+// Resolve follows redirects and returns the content entry.
+func (a *Archive) Resolve(e *Entry) (*Entry, error) {
+	for i := 0; e.Redirect; i++ {
+		if i >= maxRedirects {
+			return nil, fmt.Errorf("zim: too many redirects from %s", e.FullPath())
+		}
+		next, err := a.EntryAt(e.redirectIndex)
+		if err != nil {
+			return nil, err
+		}
+		e = next
+	}
+	return e, nil
+}
+
+// Content returns the blob of an entry, following redirects.
+func (a *Archive) Content(e *Entry) ([]byte, error) {
+	e, err := a.Resolve(e)
+	if err != nil {
+		return nil, err
+	}
+	if e.MimeType == "" {
+		return nil, ErrNotFound
+	}
+	c, err := a.cluster(e.cluster)
+	if err != nil {
+		return nil, err
+	}
+	return c.blob(e.blob)
+}
+
+// Metadata returns a metadata value such as "Title", "Description" or
+// "Language" from the M namespace.
+func (a *Archive) Metadata(name string) string {
+	a.metaOnce.Do(func() {
+		a.metadata = make(map[string]string)
+		for _, key := range []string{"Title", "Description", "Language", "Creator", "Publisher", "Date", "Counter", "Name"} {
+			e, err := a.EntryByPath('M', key)
+			if err != nil {
+				continue
+			}
+			if data, err := a.Content(e); err == nil {
+				a.metadata[key] = string(data)
+			}
+		}
+	})
+	return a.metadata[name]
+}
+
+// ArticleCount returns the number of HTML articles, from the Counter
+// metadata when available.
+func (a *Archive) ArticleCount() int64 {
+	for _, item := range strings.Split(a.Metadata("Counter"), ";") {
+		mime, count, ok := strings.Cut(item, "=")
+		if ok && strings.HasPrefix(mime, "text/html") {
+			n, _ := strconv.ParseInt(count, 10, 64)
+			return n
+		}
+	}
+	start, end := a.NamespaceRange(a.articleNS)
+	return int64(end - start)
+}
+
+// Close closes the file.
+func (a *Archive) Close() error {
+	return a.file.Close()
+}
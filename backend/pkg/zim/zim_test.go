@@ -0,0 +1,322 @@
+package zim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// testEntry is a directory entry of a test archive. Redirect is the full
+// path of the target.
+type testEntry struct {
+	ns       byte
+	path     string
+	title    string
+	mime     string
+	content  string
+	redirect string
+}
+
+// writeZIM writes a minimal ZIM file with one uncompressed cluster for text
+// and one zstd cluster for everything else.
+func writeZIM(t *testing.T, major, minor uint16, entries []testEntry) string {
+	t.Helper()
+	le := binary.LittleEndian
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].ns != entries[j].ns {
+			return entries[i].ns < entries[j].ns
+		}
+		return entries[i].path < entries[j].path
+	})
+	indexOf := make(map[string]uint32)
+	for i, e := range entries {
+		indexOf[string(e.ns)+"/"+e.path] = uint32(i)
+	}
+
+	var mimes []string
+	mimeIndex := make(map[string]uint16)
+	var blobs [2][]string
+	type location struct{ cluster, blob uint32 }
+	locations := make([]location, len(entries))
+	for i, e := range entries {
+		if e.redirect != "" {
+			continue
+		}
+		if _, ok := mimeIndex[e.mime]; !ok {
+			mimeIndex[e.mime] = uint16(len(mimes))
+			mimes = append(mimes, e.mime)
+		}
+		c := 1
+		if strings.HasPrefix(e.mime, "text/") {
+			c = 0
+		}
+		locations[i] = location{uint32(c), uint32(len(blobs[c]))}
+		blobs[c] = append(blobs[c], e.content)
+	}
+
+	var dirents [][]byte
+	for i, e := range entries {
+		var d bytes.Buffer
+		if e.redirect != "" {
+			binary.Write(&d, le, uint16(mimeRedirect))
+			d.Write([]byte{0, e.ns, 0, 0, 0, 0})
+			binary.Write(&d, le, indexOf[e.redirect])
+		} else {
+			binary.Write(&d, le, mimeIndex[e.mime])
+			d.Write([]byte{0, e.ns, 0, 0, 0, 0})
+			binary.Write(&d, le, locations[i].cluster)
+			binary.Write(&d, le, locations[i].blob)
+		}
+		d.WriteString(e.path + "\x00" + e.title + "\x00")
+		dirents = append(dirents, d.Bytes())
+	}
+
+	var clusters [][]byte
+	for c, list := range blobs {
+		var data bytes.Buffer
+		offset := uint32(4 * (len(list) + 1))
+		binary.Write(&data, le, offset)
+		for _, b := range list {
+			offset += uint32(len(b))
+			binary.Write(&data, le, offset)
+		}
+		for _, b := range list {
+			data.WriteString(b)
+		}
+		if c == 0 {
+			clusters = append(clusters, append([]byte{compressionNone}, data.Bytes()...))
+			continue
+		}
+		enc, _ := zstd.NewWriter(nil)
+		clusters = append(clusters, append([]byte{compressionZstd}, enc.EncodeAll(data.Bytes(), nil)...))
+		enc.Close()
+	}
+
+	// Title order
+	titleOrder := make([]uint32, len(entries))
+	for i := range titleOrder {
+		titleOrder[i] = uint32(i)
+	}
+	title := func(e testEntry) string {
+		if e.title != "" {
+			return e.title
+		}
+		return e.path
+	}
+	sort.Slice(titleOrder, func(i, j int) bool {
+		a, b := entries[titleOrder[i]], entries[titleOrder[j]]
+		if a.ns != b.ns {
+			return a.ns < b.ns
+		}
+		return title(a) < title(b)
+	})
+
+	// Layout: header, MIME list, path pointers, title pointers, cluster
+	// pointers, dirents, clusters, checksum
+	mimeList := []byte(strings.Join(mimes, "\x00") + "\x00\x00")
+	mimePos := uint64(headerSize)
+	pathPtrPos := mimePos + uint64(len(mimeList))
+	titlePtrPos := pathPtrPos + uint64(8*len(entries))
+	clusterPtrPos := titlePtrPos + uint64(4*len(entries))
+	pos := clusterPtrPos + uint64(8*len(clusters))
+
+	var body bytes.Buffer
+	body.Write(mimeList)
+	direntPos := pos
+	for _, d := range dirents {
+		binary.Write(&body, le, direntPos)
+		direntPos += uint64(len(d))
+	}
+	for _, i := range titleOrder {
+		binary.Write(&body, le, i)
+	}
+	clusterPos := direntPos
+	for _, c := range clusters {
+		binary.Write(&body, le, clusterPos)
+		clusterPos += uint64(len(c))
+	}
+	for _, d := range dirents {
+		body.Write(d)
+	}
+	for _, c := range clusters {
+		body.Write(c)
+	}
+
+	header := make([]byte, headerSize)
+	le.PutUint32(header[0:], magicNumber)
+	le.PutUint16(header[4:], major)
+	le.PutUint16(header[6:], minor)
+	le.PutUint32(header[24:], uint32(len(entries)))
+	le.PutUint32(header[28:], uint32(len(clusters)))
+	le.PutUint64(header[32:], pathPtrPos)
+	le.PutUint64(header[40:], titlePtrPos)
+	le.PutUint64(header[48:], clusterPtrPos)
+	le.PutUint64(header[56:], mimePos)
+	le.PutUint64(header[72:], clusterPos)
+
+	path := filepath.Join(t.TempDir(), "test.zim")
+	file := append(header, body.Bytes()...)
+	file = append(file, make([]byte, 16)...)
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const applePage = `<html><head><title>Apple</title><link rel="stylesheet" href="../-/style.css"></head>` +
+	`<body><p>An <a href="Apple_pie#History">apple pie</a> <img src="../I/red.png"> <a href="https://example.org/">ext</a>` +
+	`<script>alert(1)</script> <a href="#Uses">uses</a></p></body></html>`
+
+func TestArchive(t *testing.T) {
+	path := writeZIM(t, 5, 0, []testEntry{
+		{ns: 'A', path: "Apple", title: "Apple", mime: "text/html", content: applePage},
+		{ns: 'A', path: "Apple_pie", title: "Apple pie", mime: "text/html", content: "<body>A pie.</body>"},
+		{ns: 'A', path: "Fruit", title: "Fruit", redirect: "A/Apple"},
+		{ns: 'I', path: "red.png", mime: "image/png", content: "PNGDATA"},
+		{ns: '-', path: "style.css", mime: "text/css", content: "p{}"},
+		{ns: 'M', path: "Title", mime: "text/plain", content: "Test Wiki"},
+		{ns: 'M', path: "Counter", mime: "text/plain", content: "image/png=1;text/html=2"},
+	})
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer a.Close()
+
+	if a.Metadata("Title") != "Test Wiki" || a.ArticleCount() != 2 || a.ArticleNamespace() != 'A' {
+		t.Errorf("Title = %q, ArticleCount() = %d", a.Metadata("Title"), a.ArticleCount())
+	}
+
+	got, err := a.Lookup("apple")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	for _, want := range []string{`href="entry://Apple pie"`, `src="I/red.png"`, `href="-/style.css"`, `href="https://example.org/"`, `href="#Uses"`, "zim-article"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Lookup(apple) = %s, missing %q", got, want)
+		}
+	}
+	if strings.Contains(string(got), "alert") || strings.Contains(string(got), "<title>") {
+		t.Errorf("Lookup(apple) kept script or head: %s", got)
+	}
+
+	if got, err := a.Lookup("fruit"); err != nil || !strings.Contains(string(got), "apple pie") {
+		t.Errorf("Lookup(fruit) = %s, %v", got, err)
+	}
+	if got, err := a.Lookup("apple pie"); err != nil || !strings.Contains(string(got), "A pie.") {
+		t.Errorf("Lookup(apple pie) = %s, %v", got, err)
+	}
+	if _, err := a.Lookup("pear"); err != ErrNotFound {
+		t.Errorf("Lookup(pear) error = %v", err)
+	}
+
+	if data, err := a.Resource("I/red.png"); err != nil || string(data) != "PNGDATA" {
+		t.Errorf("Resource = %q, %v", data, err)
+	}
+
+	if s := a.Suggest("app", 10); len(s) != 2 || s[0] != "Apple" || s[1] != "Apple pie" {
+		t.Errorf("Suggest(app) = %v", s)
+	}
+
+	var titles []string
+	a.Iterate(func(title string, article []byte) error {
+		titles = append(titles, title)
+		return nil
+	})
+	if strings.Join(titles, ",") != "Apple,Apple pie" {
+		t.Errorf("Iterate visited %v", titles)
+	}
+}
+
+func TestNewNamespaceLayout(t *testing.T) {
+	path := writeZIM(t, 6, 1, []testEntry{
+		{ns: 'C', path: "Cat", title: "Cat", mime: "text/html", content: `<body><img src="_assets_/cat.jpg"><a href="Dog">dog</a></body>`},
+		{ns: 'C', path: "Dog", title: "Dog", mime: "text/html", content: "<body>Woof</body>"},
+		{ns: 'C', path: "_assets_/cat.jpg", mime: "image/jpeg", content: "JPEG"},
+	})
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer a.Close()
+
+	got, err := a.Lookup("cat")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if !strings.Contains(string(got), `src="C/_assets_/cat.jpg"`) || !strings.Contains(string(got), `href="entry://Dog"`) {
+		t.Errorf("Lookup(cat) = %s", got)
+	}
+	if data, err := a.Resource("C/_assets_/cat.jpg"); err != nil || string(data) != "JPEG" {
+		t.Errorf("Resource = %q, %v", data, err)
+	}
+}
+
+func TestNotZIM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.zim")
+	os.WriteFile(path, make([]byte, 100), 0644)
+	if _, err := Open(path); err == nil {
+		t.Error("Open succeeded for a non-ZIM file")
+	}
+}
+
+func TestCorruptZIM(t *testing.T) {
+	path := writeZIM(t, 5, 0, []testEntry{
+		{ns: 'A', path: "Apple", title: "Apple", mime: "text/html", content: applePage},
+		{ns: 'M', path: "Title", mime: "text/plain", content: "Test Wiki"},
+	})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	pathPtrPos := le.Uint64(data[32:40])
+	clusterPtrPos := le.Uint64(data[48:56])
+
+	corrupt := func(name string, mutate func(b []byte) []byte) *Archive {
+		b := mutate(bytes.Clone(data))
+		p := filepath.Join(t.TempDir(), name+".zim")
+		os.WriteFile(p, b, 0644)
+		a, err := Open(p)
+		if err != nil {
+			return nil
+		}
+		t.Cleanup(func() { a.Close() })
+		return a
+	}
+
+	// Directory entry pointer past the end of the file
+	a := corrupt("entry", func(b []byte) []byte {
+		le.PutUint64(b[pathPtrPos:], uint64(len(b))+100)
+		return b
+	})
+	if _, err := a.EntryAt(0); err == nil {
+		t.Error("EntryAt succeeded for an entry beyond end of file")
+	}
+	a.Metadata("Title")
+
+	// Cluster pointers past the end of the file
+	a = corrupt("cluster", func(b []byte) []byte {
+		le.PutUint64(b[clusterPtrPos:], 1<<62)
+		le.PutUint64(b[clusterPtrPos+8:], 1<<62)
+		return b
+	})
+	if title := a.Metadata("Title"); title != "" {
+		t.Errorf("Metadata(Title) = %q from a corrupt cluster", title)
+	}
+
+	// Truncated file
+	if a = corrupt("truncated", func(b []byte) []byte { return b[:len(b)*2/3] }); a != nil {
+		a.Metadata("Title")
+		a.Lookup("apple")
+	}
+}
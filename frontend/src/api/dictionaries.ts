@@ -1,5 +1,5 @@
 import { apiClient } from './client'
-import type { ApiResponse, DictSource } from '@/types'
+import type { ApiResponse, DictGroup, DictGroupRequest, DictSource, DictSourceUpdate } from '@/types'
 
 export const dictionariesApi = {
   list: () => apiClient.get<ApiResponse<DictSource[]>>('/dictionaries'),
@@ -7,6 +7,9 @@ export const dictionariesApi = {
   add: (path: string) =>
     apiClient.post<ApiResponse<DictSource>>('/dictionaries', { path }),
 
+  update: (id: number, data: DictSourceUpdate) =>
+    apiClient.patch<ApiResponse<DictSource>>(`/dictionaries/${id}`, data),
+
   toggle: (id: number) =>
     apiClient.put<ApiResponse<DictSource>>(`/dictionaries/${id}/toggle`),
 
@@ -15,3 +18,15 @@ export const dictionariesApi = {
 
   delete: (id: number) => apiClient.delete<ApiResponse<null>>(`/dictionaries/${id}`),
 }
+
+export const groupsApi = {
+  list: () => apiClient.get<ApiResponse<DictGroup[]>>('/groups'),
+
+  create: (data: DictGroupRequest) =>
+    apiClient.post<ApiResponse<DictGroup>>('/groups', data),
+
+  update: (id: number, data: DictGroupRequest) =>
+    apiClient.put<ApiResponse<DictGroup>>(`/groups/${id}`, data),
+
+  delete: (id: number) => apiClient.delete<ApiResponse<null>>(`/groups/${id}`),
+}
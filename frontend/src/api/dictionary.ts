@@ -2,20 +2,20 @@ import { apiClient } from './client'
 import type { ApiResponse, SearchResponse, SuggestResponse } from '@/types'
 
 export const searchApi = {
-  // 跨字典搜索
-  search: async (word: string): Promise<SearchResponse> => {
+  // 跨字典搜索，group 省略时使用默认分组
+  search: async (word: string, group?: string): Promise<SearchResponse> => {
     const response = await apiClient.get<ApiResponse<SearchResponse>>(
       '/search',
-      { params: { word } }
+      { params: { word, group } }
     )
     return response.data.data!
   },
 
   // 搜索建议（自动补全）
-  suggest: async (q: string, limit = 10): Promise<SuggestResponse> => {
+  suggest: async (q: string, limit = 10, group?: string): Promise<SuggestResponse> => {
     const response = await apiClient.get<ApiResponse<SuggestResponse>>(
       '/search/suggest',
-      { params: { q, limit } }
+      { params: { q, limit, group } }
     )
     return response.data.data!
   },
@@ -150,7 +150,7 @@ function DictManager() {
                     <TableCell>
                       <div>
                         <div className="font-medium">
-                          {dict.title || dict.name}
+                          {dict.display_title || dict.title || dict.name}
                         </div>
                         {dict.description && (
                           <div className="text-xs text-default-400 truncate max-w-xs">
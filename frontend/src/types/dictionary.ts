@@ -40,10 +40,50 @@ export interface DictSource {
   word_count: number
   has_mdd: boolean
   file_size: number
+  display_title: string
+  alias: string
+  source_lang: string
+  target_lang: string
+  tags: string
+  notes: string
+  homepage: string
   created_at: string
   updated_at: string
 }
 
+// 可编辑的词典信息，未提供的字段保持不变
+export type DictSourceUpdate = Partial<
+  Pick<DictSource, 'display_title' | 'alias' | 'source_lang' | 'target_lang' | 'tags' | 'notes' | 'homepage'>
+>
+
+export interface DictGroupMember {
+  id: number
+  group_id: number
+  dict_source_id: number
+  sort_order: number
+  enabled: boolean
+}
+
+export interface DictGroup {
+  id: number
+  name: string
+  description: string
+  is_default: boolean
+  sort_order: number
+  members: DictGroupMember[]
+  created_at: string
+  updated_at: string
+}
+
+// 创建或修改分组，members 按顺序替换全部成员
+export interface DictGroupRequest {
+  name?: string
+  description?: string
+  is_default?: boolean
+  sort_order?: number
+  members?: { dict_source_id: number; enabled?: boolean }[]
+}
+
 // 通用 API 响应包装
 export interface ApiResponse<T> {
   code: number